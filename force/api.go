@@ -1,7 +1,10 @@
 package force
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"sync"
 )
 
 const (
@@ -18,6 +21,9 @@ const (
 	resourcesUri = "/services/data/%v"
 )
 
+// ForceApi is safe for concurrent use by multiple goroutines once created. Sobject descriptions
+// are cached, and a session that expires while several requests are in flight is renewed once.
+// TraceOn and TraceOff must not be called while requests are in flight.
 type ForceApi struct {
 	apiVersion             string
 	oauth                  *forceOauth
@@ -25,10 +31,23 @@ type ForceApi struct {
 	apiSObjects            map[string]*SObjectMetaData
 	apiSObjectDescriptions map[string]*SObjectDescription
 	apiMaxBatchSize        int64
+	metaDataMu             sync.RWMutex
+	describeFlights        flightGroup
+	httpClient             *http.Client
+	retryPolicy            *RetryPolicy
+	apiUsage               ApiUsage
+	usageMu                sync.Mutex
+	usageThresholds        []float64
+	usageCallback          func(usage ApiUsage, threshold float64)
+	usageThrottle          *ApiUsageThrottle
+	userAgent              string
 	logger                 ForceApiLogger
 	logPrefix              string
+	// Error of an invalid option passed to CreateWithOptions.
+	optionErr error
 }
 
+// Deprecated: refresh responses are stored in the Token returned by GetToken.
 type RefreshTokenResponse struct {
 	ID          string `json:"id"`
 	IssuedAt    string `json:"issued_at"`
@@ -170,21 +189,24 @@ type ChildRelationship struct {
 	RelationshipName    string `json:"relationshipName"`
 }
 
-func (forceApi *ForceApi) getApiResources() error {
+func (forceApi *ForceApi) getApiResources(ctx context.Context) error {
 	uri := fmt.Sprintf(resourcesUri, forceApi.apiVersion)
 
-	return forceApi.Get(uri, nil, &forceApi.apiResources)
+	return forceApi.GetContext(ctx, uri, nil, &forceApi.apiResources)
 }
 
-func (forceApi *ForceApi) getApiSObjects() error {
+func (forceApi *ForceApi) getApiSObjects(ctx context.Context) error {
 	uri := forceApi.apiResources[sObjectsKey]
 
 	list := &SObjectApiResponse{}
-	err := forceApi.Get(uri, nil, list)
+	err := forceApi.GetContext(ctx, uri, nil, list)
 	if err != nil {
 		return err
 	}
 
+	forceApi.metaDataMu.Lock()
+	defer forceApi.metaDataMu.Unlock()
+
 	forceApi.apiMaxBatchSize = list.MaxBatchSize
 
 	// The API doesn't return the list of sobjects in a map. Convert it.
@@ -195,44 +217,65 @@ func (forceApi *ForceApi) getApiSObjects() error {
 	return nil
 }
 
-func (forceApi *ForceApi) getApiSObjectDescriptions() error {
+// sObjectMetaData returns the listed metadata of the named sobject, or nil if it wasn't listed.
+func (forceApi *ForceApi) sObjectMetaData(name string) *SObjectMetaData {
+	forceApi.metaDataMu.RLock()
+	defer forceApi.metaDataMu.RUnlock()
+
+	return forceApi.apiSObjects[name]
+}
+
+// sObjectMetaDataList returns a copy of the listed sobject metadata, keyed by sobject name.
+func (forceApi *ForceApi) sObjectMetaDataList() map[string]*SObjectMetaData {
+	forceApi.metaDataMu.RLock()
+	defer forceApi.metaDataMu.RUnlock()
+
+	list := make(map[string]*SObjectMetaData, len(forceApi.apiSObjects))
 	for name, metaData := range forceApi.apiSObjects {
+		list[name] = metaData
+	}
+
+	return list
+}
+
+func (forceApi *ForceApi) getApiSObjectDescriptions(ctx context.Context) error {
+	for name, metaData := range forceApi.sObjectMetaDataList() {
 		uri := metaData.URLs[sObjectDescribeKey]
 
 		desc := &SObjectDescription{}
-		err := forceApi.Get(uri, nil, desc)
+		err := forceApi.GetContext(ctx, uri, nil, desc)
 		if err != nil {
 			return err
 		}
 
+		forceApi.metaDataMu.Lock()
 		forceApi.apiSObjectDescriptions[name] = desc
+		forceApi.metaDataMu.Unlock()
 	}
 
 	return nil
 }
 
 func (forceApi *ForceApi) GetInstanceURL() string {
-	return forceApi.oauth.InstanceUrl
+	return forceApi.oauth.currentToken().InstanceUrl
 }
 
 func (forceApi *ForceApi) GetAccessToken() string {
-	return forceApi.oauth.AccessToken
+	return forceApi.oauth.currentToken().AccessToken
 }
 
+// RefreshToken exchanges the refresh token for a new access token.
 func (forceApi *ForceApi) RefreshToken() error {
-	res := &RefreshTokenResponse{}
-	payload := map[string]string{
-		"grant_type":    "refresh_token",
-		"refresh_token": forceApi.oauth.refreshToken,
-		"client_id":     forceApi.oauth.clientId,
-		"client_secret": forceApi.oauth.clientSecret,
-	}
+	return forceApi.RefreshTokenContext(context.Background())
+}
 
-	err := forceApi.Post("/services/oauth2/token", nil, payload, res)
-	if err != nil {
-		return err
-	}
+// RefreshTokenContext is like RefreshToken but carries a context.
+func (forceApi *ForceApi) RefreshTokenContext(ctx context.Context) error {
+	return forceApi.oauth.Refresh(ctx)
+}
 
-	forceApi.oauth.AccessToken = res.AccessToken
-	return nil
+// GetToken returns a copy of the current oauth token.
+func (forceApi *ForceApi) GetToken() *Token {
+	token := forceApi.oauth.currentToken()
+	return &token
 }
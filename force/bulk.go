@@ -0,0 +1,584 @@
+package force
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/nimajalali/go-force/forcejson"
+)
+
+const (
+	bulkUriPrefix = "/services/async/"
+	bulkUri       = bulkUriPrefix + "%v"
+	bulkJobsUri   = bulkUri + "/job"
+
+	bulkSessionHeader     = "X-SFDC-Session"
+	bulkXmlNamespace      = "http://www.force.com/2009/06/asyncapi/dataload"
+	bulkInvalidSessionId  = "InvalidSessionId"
+	xmlContentType        = "application/xml"
+	bulkCsvContentType    = csvContentType + "; charset=UTF-8"
+	bulkXmlContentType    = xmlContentType + "; charset=UTF-8"
+	bulkJsonContentType   = contentType + "; charset=UTF-8"
+	bulkXmlNilAttribute   = `xsi:nil="true"`
+	bulkXmlSchemaInstance = "http://www.w3.org/2001/XMLSchema-instance"
+)
+
+// JobStateClosed is the state of a Bulk API 1.0 job that accepts no more batches.
+const JobStateClosed BulkJobState = "Closed"
+
+// BulkContentType is the format of the batches, and results, of a Bulk API 1.0 job.
+type BulkContentType string
+
+const (
+	BulkContentTypeCSV  BulkContentType = "CSV"
+	BulkContentTypeJSON BulkContentType = "JSON"
+	BulkContentTypeXML  BulkContentType = "XML"
+)
+
+func (contentType BulkContentType) mediaType() string {
+	switch contentType {
+	case BulkContentTypeCSV:
+		return bulkCsvContentType
+	case BulkContentTypeXML:
+		return bulkXmlContentType
+	}
+
+	return bulkJsonContentType
+}
+
+// BulkBatchState is the processing state of a Bulk API 1.0 batch.
+type BulkBatchState string
+
+const (
+	BatchStateQueued     BulkBatchState = "Queued"
+	BatchStateInProgress BulkBatchState = "InProgress"
+	BatchStateCompleted  BulkBatchState = "Completed"
+	BatchStateFailed     BulkBatchState = "Failed"
+	// The original batch of a PK chunked query ends up NotProcessed once its chunks are queued.
+	BatchStateNotProcessed BulkBatchState = "NotProcessed"
+)
+
+// Done reports whether the batch has finished processing, successfully or not.
+func (state BulkBatchState) Done() bool {
+	return state == BatchStateCompleted || state == BatchStateFailed || state == BatchStateNotProcessed
+}
+
+// BulkJob describes a Bulk API 1.0 job.
+type BulkJob struct {
+	Id                      string          `force:"id,omitempty"`
+	Operation               BulkOperation   `force:"operation,omitempty"`
+	Object                  string          `force:"object,omitempty"`
+	ExternalIdFieldName     string          `force:"externalIdFieldName,omitempty"`
+	ContentType             BulkContentType `force:"contentType,omitempty"`
+	ConcurrencyMode         string          `force:"concurrencyMode,omitempty"`
+	State                   BulkJobState    `force:"state,omitempty"`
+	ApiVersion              float64         `force:"apiVersion,omitempty"`
+	CreatedById             string          `force:"createdById,omitempty"`
+	CreatedDate             string          `force:"createdDate,omitempty"`
+	SystemModstamp          string          `force:"systemModstamp,omitempty"`
+	NumberBatchesQueued     float64         `force:"numberBatchesQueued,omitempty"`
+	NumberBatchesInProgress float64         `force:"numberBatchesInProgress,omitempty"`
+	NumberBatchesCompleted  float64         `force:"numberBatchesCompleted,omitempty"`
+	NumberBatchesFailed     float64         `force:"numberBatchesFailed,omitempty"`
+	NumberBatchesTotal      float64         `force:"numberBatchesTotal,omitempty"`
+	NumberRecordsProcessed  float64         `force:"numberRecordsProcessed,omitempty"`
+	NumberRecordsFailed     float64         `force:"numberRecordsFailed,omitempty"`
+	NumberRetries           float64         `force:"numberRetries,omitempty"`
+	TotalProcessingTime     float64         `force:"totalProcessingTime,omitempty"`
+}
+
+// BulkBatch describes a batch of a Bulk API 1.0 job.
+type BulkBatch struct {
+	Id                     string         `force:"id,omitempty" xml:"id"`
+	JobId                  string         `force:"jobId,omitempty" xml:"jobId"`
+	State                  BulkBatchState `force:"state,omitempty" xml:"state"`
+	StateMessage           string         `force:"stateMessage,omitempty" xml:"stateMessage"`
+	CreatedDate            string         `force:"createdDate,omitempty" xml:"createdDate"`
+	SystemModstamp         string         `force:"systemModstamp,omitempty" xml:"systemModstamp"`
+	NumberRecordsProcessed float64        `force:"numberRecordsProcessed,omitempty" xml:"numberRecordsProcessed"`
+	NumberRecordsFailed    float64        `force:"numberRecordsFailed,omitempty" xml:"numberRecordsFailed"`
+	TotalProcessingTime    float64        `force:"totalProcessingTime,omitempty" xml:"totalProcessingTime"`
+}
+
+type bulkBatchList struct {
+	Batches []*BulkBatch `force:"batchInfo" xml:"batchInfo"`
+}
+
+// BulkBatchResult is the outcome of one record of an ingest batch, in the order the records were
+// sent. Csv results report failures in Error, json and xml results in Errors.
+type BulkBatchResult struct {
+	Id      string    `force:"Id"`
+	Success bool      `force:"Success"`
+	Created bool      `force:"Created"`
+	Error   string    `force:"Error,omitempty"`
+	Errors  ApiErrors `force:"errors,omitempty"`
+}
+
+type xmlBulkBatchResults struct {
+	Results []struct {
+		Id      string `xml:"id"`
+		Success bool   `xml:"success"`
+		Created bool   `xml:"created"`
+		Errors  []struct {
+			Fields     []string `xml:"fields"`
+			Message    string   `xml:"message"`
+			StatusCode string   `xml:"statusCode"`
+		} `xml:"errors"`
+	} `xml:"result"`
+}
+
+// BulkError is an error reported by Bulk API 1.0, which doesn't use the format of the REST api.
+// It's returned as the Err of a *RequestError, and can be extracted with errors.As.
+type BulkError struct {
+	ExceptionCode    string `force:"exceptionCode" xml:"exceptionCode"`
+	ExceptionMessage string `force:"exceptionMessage" xml:"exceptionMessage"`
+}
+
+func (e *BulkError) Error() string {
+	return fmt.Sprintf("%v: %v", e.ExceptionCode, e.ExceptionMessage)
+}
+
+// CreateBulkJob creates a Bulk API 1.0 job performing operation on the sobject of in. Batches of
+// the job, and their results, are in contentType. Upserts match records on in.ExternalIdApiName().
+// Use CreateBulkQueryJob for queries.
+func (forceApi *ForceApi) CreateBulkJob(operation BulkOperation, in SObject, contentType BulkContentType) (*BulkJob, error) {
+	return forceApi.CreateBulkJobContext(context.Background(), operation, in, contentType)
+}
+
+// CreateBulkJobContext is like CreateBulkJob but carries a context.
+func (forceApi *ForceApi) CreateBulkJobContext(ctx context.Context, operation BulkOperation, in SObject, contentType BulkContentType) (*BulkJob, error) {
+	payload := &BulkJob{
+		Operation:   operation,
+		Object:      in.ApiName(),
+		ContentType: contentType,
+	}
+	if operation == BulkUpsert {
+		payload.ExternalIdFieldName = in.ExternalIdApiName()
+	}
+
+	return forceApi.createBulkJob(ctx, payload, nil)
+}
+
+// GetBulkJob retrieves the current state of a job.
+func (forceApi *ForceApi) GetBulkJob(jobId string) (*BulkJob, error) {
+	return forceApi.GetBulkJobContext(context.Background(), jobId)
+}
+
+// GetBulkJobContext is like GetBulkJob but carries a context.
+func (forceApi *ForceApi) GetBulkJobContext(ctx context.Context, jobId string) (*BulkJob, error) {
+	job := &BulkJob{}
+	err := forceApi.bulkRequest(ctx, "GET", forceApi.bulkJobUri(jobId), nil, nil, job)
+
+	return job, err
+}
+
+// CloseBulkJob closes a job to further batches. Queued batches are still processed.
+func (forceApi *ForceApi) CloseBulkJob(jobId string) (*BulkJob, error) {
+	return forceApi.CloseBulkJobContext(context.Background(), jobId)
+}
+
+// CloseBulkJobContext is like CloseBulkJob but carries a context.
+func (forceApi *ForceApi) CloseBulkJobContext(ctx context.Context, jobId string) (*BulkJob, error) {
+	return forceApi.setBulkJobState(ctx, jobId, JobStateClosed)
+}
+
+// AbortBulkJob aborts a job. Batches that have not been processed are not processed.
+func (forceApi *ForceApi) AbortBulkJob(jobId string) (*BulkJob, error) {
+	return forceApi.AbortBulkJobContext(context.Background(), jobId)
+}
+
+// AbortBulkJobContext is like AbortBulkJob but carries a context.
+func (forceApi *ForceApi) AbortBulkJobContext(ctx context.Context, jobId string) (*BulkJob, error) {
+	return forceApi.setBulkJobState(ctx, jobId, JobStateAborted)
+}
+
+// AddBulkBatch adds records, a slice of SObject structs, as a batch of an open job. Records are
+// encoded in the content type of the job and named by force tags; empty omitempty fields are
+// left out, which leaves them untouched.
+func (forceApi *ForceApi) AddBulkBatch(job *BulkJob, records interface{}) (*BulkBatch, error) {
+	return forceApi.AddBulkBatchContext(context.Background(), job, records)
+}
+
+// AddBulkBatchContext is like AddBulkBatch but carries a context.
+func (forceApi *ForceApi) AddBulkBatchContext(ctx context.Context, job *BulkJob, records interface{}) (*BulkBatch, error) {
+	var data bytes.Buffer
+	if err := marshalBulkBatch(&data, job.ContentType, records); err != nil {
+		return nil, err
+	}
+
+	return forceApi.AddBulkBatchDataContext(ctx, job, bytes.NewReader(data.Bytes()))
+}
+
+// AddBulkBatchData streams data, already encoded in the content type of the job, as a batch of an
+// open job. Readers that can't seek are not retried when the session has expired.
+func (forceApi *ForceApi) AddBulkBatchData(job *BulkJob, data io.Reader) (*BulkBatch, error) {
+	return forceApi.AddBulkBatchDataContext(context.Background(), job, data)
+}
+
+// AddBulkBatchDataContext is like AddBulkBatchData but carries a context.
+func (forceApi *ForceApi) AddBulkBatchDataContext(ctx context.Context, job *BulkJob, data io.Reader) (*BulkBatch, error) {
+	resp, err := forceApi.sendBulk(ctx, "POST", forceApi.bulkJobUri(job.Id)+"/batch", job.ContentType, data)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	batch := &BulkBatch{}
+	if err := decodeBulkResponse(resp, batch); err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// GetBulkBatch retrieves the current state of a batch.
+func (forceApi *ForceApi) GetBulkBatch(jobId, batchId string) (*BulkBatch, error) {
+	return forceApi.GetBulkBatchContext(context.Background(), jobId, batchId)
+}
+
+// GetBulkBatchContext is like GetBulkBatch but carries a context.
+func (forceApi *ForceApi) GetBulkBatchContext(ctx context.Context, jobId, batchId string) (*BulkBatch, error) {
+	batch := &BulkBatch{}
+	uri := forceApi.bulkJobUri(jobId) + "/batch/" + batchId
+	err := forceApi.bulkRequest(ctx, "GET", uri, nil, nil, batch)
+
+	return batch, err
+}
+
+// GetBulkBatches retrieves the current state of every batch of a job, including the batches
+// Salesforce created to PK chunk a query.
+func (forceApi *ForceApi) GetBulkBatches(jobId string) ([]*BulkBatch, error) {
+	return forceApi.GetBulkBatchesContext(context.Background(), jobId)
+}
+
+// GetBulkBatchesContext is like GetBulkBatches but carries a context.
+func (forceApi *ForceApi) GetBulkBatchesContext(ctx context.Context, jobId string) ([]*BulkBatch, error) {
+	list := &bulkBatchList{}
+	err := forceApi.bulkRequest(ctx, "GET", forceApi.bulkJobUri(jobId)+"/batch", nil, nil, list)
+
+	return list.Batches, err
+}
+
+// WaitBulkBatches polls the batches of a job, backing off exponentially, until every one of them
+// has finished. The batches are returned together with an error if any of them failed.
+func (forceApi *ForceApi) WaitBulkBatches(jobId string) ([]*BulkBatch, error) {
+	return forceApi.WaitBulkBatchesContext(context.Background(), jobId)
+}
+
+// WaitBulkBatchesContext is like WaitBulkBatches but carries a context, which also bounds the
+// wait.
+func (forceApi *ForceApi) WaitBulkBatchesContext(ctx context.Context, jobId string) ([]*BulkBatch, error) {
+	var batches []*BulkBatch
+	err := pollBulkJob(ctx, func() (bool, error) {
+		var err error
+		batches, err = forceApi.GetBulkBatchesContext(ctx, jobId)
+		if err != nil {
+			return false, err
+		}
+		return bulkBatchesDone(batches), nil
+	})
+	if err != nil {
+		return batches, err
+	}
+
+	var failed []string
+	for _, batch := range batches {
+		if batch.State == BatchStateFailed {
+			failed = append(failed, fmt.Sprintf("%v: %v", batch.Id, batch.StateMessage))
+		}
+	}
+	if len(failed) > 0 {
+		return batches, fmt.Errorf("Bulk job %v has failed batches: %v", jobId, strings.Join(failed, "; "))
+	}
+
+	return batches, nil
+}
+
+// bulkBatchesDone reports whether every batch of a job has finished.
+func bulkBatchesDone(batches []*BulkBatch) bool {
+	for _, batch := range batches {
+		if !batch.State.Done() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetBulkBatchResults retrieves the outcome of each record of a completed ingest batch.
+func (forceApi *ForceApi) GetBulkBatchResults(job *BulkJob, batchId string) ([]*BulkBatchResult, error) {
+	return forceApi.GetBulkBatchResultsContext(context.Background(), job, batchId)
+}
+
+// GetBulkBatchResultsContext is like GetBulkBatchResults but carries a context.
+func (forceApi *ForceApi) GetBulkBatchResultsContext(ctx context.Context, job *BulkJob, batchId string) ([]*BulkBatchResult, error) {
+	uri := forceApi.bulkJobUri(job.Id) + "/batch/" + batchId + "/result"
+	resp, err := forceApi.sendBulk(ctx, "GET", uri, job.ContentType, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	results := []*BulkBatchResult{}
+	switch job.ContentType {
+	case BulkContentTypeCSV:
+		err = unmarshalCSV(resp.Body, &results)
+	case BulkContentTypeXML:
+		err = unmarshalXmlBulkBatchResults(resp.Body, &results)
+	default:
+		err = forcejson.NewDecoder(resp.Body).Decode(&results)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to unmarshal batch %v results: %v", batchId, err)
+	}
+
+	return results, nil
+}
+
+func (forceApi *ForceApi) createBulkJob(ctx context.Context, payload *BulkJob, header http.Header) (*BulkJob, error) {
+	job := &BulkJob{}
+	err := forceApi.bulkRequest(ctx, "POST", forceApi.bulkJobsUri(), header, payload, job)
+
+	return job, err
+}
+
+func (forceApi *ForceApi) setBulkJobState(ctx context.Context, jobId string, state BulkJobState) (*BulkJob, error) {
+	job := &BulkJob{}
+	err := forceApi.bulkRequest(ctx, "POST", forceApi.bulkJobUri(jobId), nil, &ingestJobState{State: state}, job)
+
+	return job, err
+}
+
+func (forceApi *ForceApi) bulkJobsUri() string {
+	// Bulk API 1.0 versions are not prefixed with a v.
+	return fmt.Sprintf(bulkJobsUri, strings.TrimPrefix(forceApi.apiVersion, "v"))
+}
+
+func (forceApi *ForceApi) bulkJobUri(jobId string) string {
+	return forceApi.bulkJobsUri() + "/" + jobId
+}
+
+// bulkRequest sends payload as json to a Bulk API 1.0 resource and unmarshals the json response
+// into out.
+func (forceApi *ForceApi) bulkRequest(ctx context.Context, method, path string, header http.Header, payload, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		jsonBytes, err := forcejson.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("Error marshaling encoded payload: %v", err)
+		}
+		body = bytes.NewReader(jsonBytes)
+	}
+
+	resp, err := forceApi.send(ctx, method, path, nil, header, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := bulkResponseError(resp); err != nil {
+		return err
+	}
+
+	return decodeBulkResponse(resp, out)
+}
+
+// sendBulk sends body, encoded in contentType, to a Bulk API 1.0 resource and asks for a
+// response in the same format. The caller must close the response body.
+func (forceApi *ForceApi) sendBulk(ctx context.Context, method, path string, contentType BulkContentType, body io.Reader) (*http.Response, error) {
+	header := http.Header{
+		"Content-Type": {contentType.mediaType()},
+		"Accept":       {contentType.mediaType()},
+	}
+
+	resp, err := forceApi.send(ctx, method, path, nil, header, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bulkResponseError(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// decodeBulkResponse unmarshals a json or xml response into out, depending on its Content-Type.
+func decodeBulkResponse(resp *http.Response, out interface{}) error {
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("Error reading response bytes: %v", err)
+	}
+
+	if strings.Contains(resp.Header.Get("Content-Type"), "xml") {
+		err = xml.Unmarshal(respBytes, out)
+	} else {
+		err = forcejson.Unmarshal(respBytes, out)
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to unmarshal response to object: %v", err)
+	}
+
+	return nil
+}
+
+// bulkResponseError returns the error described by a Bulk API 1.0 response with an error status,
+// or nil.
+func bulkResponseError(resp *http.Response) error {
+	if resp.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("Error reading response bytes: %v", err)
+	}
+
+	requestError := newRequestError(resp, nil, respBytes)
+	if bulkError := parseBulkError(respBytes); bulkError != nil {
+		requestError.Err, requestError.Body = bulkError, nil
+	}
+
+	return requestError
+}
+
+func parseBulkError(respBytes []byte) *BulkError {
+	bulkError := &BulkError{}
+	if err := forcejson.Unmarshal(respBytes, bulkError); err == nil && len(bulkError.ExceptionCode) > 0 {
+		return bulkError
+	}
+	if err := xml.Unmarshal(respBytes, bulkError); err == nil && len(bulkError.ExceptionCode) > 0 {
+		return bulkError
+	}
+
+	return nil
+}
+
+// bulkSessionExpired reports whether a Bulk API 1.0 response body rejects the session.
+func bulkSessionExpired(respBytes []byte) bool {
+	bulkError := parseBulkError(respBytes)
+
+	return bulkError != nil && bulkError.ExceptionCode == bulkInvalidSessionId
+}
+
+// marshalBulkBatch encodes records, a slice of SObject structs, in contentType.
+func marshalBulkBatch(w io.Writer, contentType BulkContentType, records interface{}) error {
+	if contentType == BulkContentTypeCSV {
+		return marshalCSV(w, records)
+	}
+
+	slice := reflect.ValueOf(records)
+	if slice.Kind() != reflect.Slice {
+		return fmt.Errorf("Unable to marshal %T to a batch, expected a slice", records)
+	}
+
+	rows := make([]map[string]interface{}, 0, slice.Len())
+	for i := 0; i < slice.Len(); i++ {
+		row := slice.Index(i)
+		if row.Kind() != reflect.Interface && row.Kind() != reflect.Ptr && row.CanAddr() {
+			row = row.Addr()
+		}
+		in, ok := row.Interface().(SObject)
+		if !ok {
+			return fmt.Errorf("Unable to marshal %v to a batch, expected an SObject", row.Type())
+		}
+
+		fields, err := sObjectRecord(in)
+		if err != nil {
+			return err
+		}
+		// Bulk API 1.0 records carry no attributes.
+		delete(fields, "attributes")
+		rows = append(rows, fields)
+	}
+
+	if contentType == BulkContentTypeXML {
+		return marshalXmlBulkBatch(w, rows)
+	}
+
+	return forcejson.NewEncoder(w).Encode(rows)
+}
+
+func marshalXmlBulkBatch(w io.Writer, rows []map[string]interface{}) error {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	fmt.Fprintf(&buf, `<sObjects xmlns="%v" xmlns:xsi="%v">`, bulkXmlNamespace, bulkXmlSchemaInstance)
+	for _, fields := range rows {
+		buf.WriteString("<sObject>")
+		if err := writeXmlFields(&buf, fields); err != nil {
+			return err
+		}
+		buf.WriteString("</sObject>")
+	}
+	buf.WriteString("</sObjects>")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeXmlFields writes fields as elements in name order. Nested fields, such as relationships
+// referenced by external id, are written as nested elements.
+func writeXmlFields(buf *bytes.Buffer, fields map[string]interface{}) error {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		switch value := fields[name].(type) {
+		case nil:
+			fmt.Fprintf(buf, "<%v %v/>", name, bulkXmlNilAttribute)
+		case map[string]interface{}:
+			fmt.Fprintf(buf, "<%v>", name)
+			if err := writeXmlFields(buf, value); err != nil {
+				return err
+			}
+			fmt.Fprintf(buf, "</%v>", name)
+		case []interface{}:
+			return fmt.Errorf("Unable to marshal field %v to xml", name)
+		default:
+			fmt.Fprintf(buf, "<%v>", name)
+			if err := xml.EscapeText(buf, []byte(fmt.Sprint(value))); err != nil {
+				return err
+			}
+			fmt.Fprintf(buf, "</%v>", name)
+		}
+	}
+
+	return nil
+}
+
+func unmarshalXmlBulkBatchResults(r io.Reader, out *[]*BulkBatchResult) error {
+	xmlResults := &xmlBulkBatchResults{}
+	if err := xml.NewDecoder(r).Decode(xmlResults); err != nil {
+		return err
+	}
+
+	for _, xmlResult := range xmlResults.Results {
+		result := &BulkBatchResult{
+			Id:      xmlResult.Id,
+			Success: xmlResult.Success,
+			Created: xmlResult.Created,
+		}
+		for _, xmlError := range xmlResult.Errors {
+			result.Errors = append(result.Errors, &ApiError{
+				Fields:     xmlError.Fields,
+				Message:    xmlError.Message,
+				StatusCode: xmlError.StatusCode,
+			})
+		}
+		*out = append(*out, result)
+	}
+
+	return nil
+}
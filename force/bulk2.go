@@ -0,0 +1,368 @@
+package force
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+const (
+	ingestJobsUri = resourcesUri + "/jobs/ingest"
+
+	csvContentType = "text/csv"
+)
+
+// Bulk API 2.0 polling starts at bulkPollInterval and doubles up to bulkMaxPollInterval.
+var (
+	bulkPollInterval    = time.Second
+	bulkMaxPollInterval = 30 * time.Second
+)
+
+// bulkAbortTimeout bounds the abort of a job that its caller gave up on.
+const bulkAbortTimeout = 30 * time.Second
+
+// BulkOperation is the operation a bulk job performs.
+type BulkOperation string
+
+const (
+	BulkInsert     BulkOperation = "insert"
+	BulkUpdate     BulkOperation = "update"
+	BulkUpsert     BulkOperation = "upsert"
+	BulkDelete     BulkOperation = "delete"
+	BulkHardDelete BulkOperation = "hardDelete"
+)
+
+// BulkJobState is the processing state of a Bulk API 2.0 job.
+type BulkJobState string
+
+const (
+	JobStateOpen           BulkJobState = "Open"
+	JobStateUploadComplete BulkJobState = "UploadComplete"
+	JobStateInProgress     BulkJobState = "InProgress"
+	JobStateJobComplete    BulkJobState = "JobComplete"
+	JobStateFailed         BulkJobState = "Failed"
+	JobStateAborted        BulkJobState = "Aborted"
+)
+
+// Done reports whether the job has finished processing, successfully or not.
+func (state BulkJobState) Done() bool {
+	return state == JobStateJobComplete || state == JobStateFailed || state == JobStateAborted
+}
+
+// IngestJob describes a Bulk API 2.0 ingest job.
+type IngestJob struct {
+	Id                     string        `force:"id,omitempty"`
+	Operation              BulkOperation `force:"operation,omitempty"`
+	Object                 string        `force:"object,omitempty"`
+	ExternalIdFieldName    string        `force:"externalIdFieldName,omitempty"`
+	State                  BulkJobState  `force:"state,omitempty"`
+	ContentType            string        `force:"contentType,omitempty"`
+	LineEnding             string        `force:"lineEnding,omitempty"`
+	ColumnDelimiter        string        `force:"columnDelimiter,omitempty"`
+	ConcurrencyMode        string        `force:"concurrencyMode,omitempty"`
+	JobType                string        `force:"jobType,omitempty"`
+	ApiVersion             float64       `force:"apiVersion,omitempty"`
+	CreatedById            string        `force:"createdById,omitempty"`
+	CreatedDate            string        `force:"createdDate,omitempty"`
+	SystemModstamp         string        `force:"systemModstamp,omitempty"`
+	NumberRecordsProcessed float64       `force:"numberRecordsProcessed,omitempty"`
+	NumberRecordsFailed    float64       `force:"numberRecordsFailed,omitempty"`
+	Retries                float64       `force:"retries,omitempty"`
+	TotalProcessingTime    float64       `force:"totalProcessingTime,omitempty"`
+	ErrorMessage           string        `force:"errorMessage,omitempty"`
+}
+
+// IngestResult holds the columns Bulk API 2.0 adds to result rows. Embed it in the struct the
+// results are decoded into to learn the id, creation and error of each record.
+type IngestResult struct {
+	SfId      string `force:"sf__Id,omitempty"`
+	SfCreated bool   `force:"sf__Created,omitempty"`
+	SfError   string `force:"sf__Error,omitempty"`
+}
+
+type ingestJobState struct {
+	State BulkJobState `force:"state"`
+}
+
+// CreateIngestJob creates a Bulk API 2.0 ingest job for the sobject of in. Upserts match records
+// on in.ExternalIdApiName().
+func (forceApi *ForceApi) CreateIngestJob(operation BulkOperation, in SObject) (*IngestJob, error) {
+	return forceApi.CreateIngestJobContext(context.Background(), operation, in)
+}
+
+// CreateIngestJobContext is like CreateIngestJob but carries a context.
+func (forceApi *ForceApi) CreateIngestJobContext(ctx context.Context, operation BulkOperation, in SObject) (*IngestJob, error) {
+	payload := &IngestJob{
+		Operation:   operation,
+		Object:      in.ApiName(),
+		ContentType: "CSV",
+		LineEnding:  "LF",
+	}
+	if operation == BulkUpsert {
+		payload.ExternalIdFieldName = in.ExternalIdApiName()
+	}
+
+	job := &IngestJob{}
+	err := forceApi.PostContext(ctx, fmt.Sprintf(ingestJobsUri, forceApi.apiVersion), nil, payload, job)
+
+	return job, err
+}
+
+// UploadIngestJobData uploads records, a slice of SObject structs, as the csv data of an open
+// job. Columns are named by force tags. Empty omitempty fields are left blank, which leaves the
+// field untouched; use the value #N/A to clear a field. A job accepts a single upload.
+//
+// The csv is streamed to the api as it's encoded, so it isn't held in memory, but the upload is
+// not retried when the session has expired or the request fails transiently.
+func (forceApi *ForceApi) UploadIngestJobData(jobId string, records interface{}) error {
+	return forceApi.UploadIngestJobDataContext(context.Background(), jobId, records)
+}
+
+// UploadIngestJobDataContext is like UploadIngestJobData but carries a context.
+func (forceApi *ForceApi) UploadIngestJobDataContext(ctx context.Context, jobId string, records interface{}) error {
+	reader, writer := io.Pipe()
+	marshalErr := make(chan error, 1)
+	go func() {
+		err := marshalCSV(writer, records)
+		writer.CloseWithError(err)
+		marshalErr <- err
+	}()
+
+	err := forceApi.UploadIngestJobCsvContext(ctx, jobId, reader)
+	// Unblock the encoder if the upload stopped reading early.
+	reader.CloseWithError(io.ErrClosedPipe)
+	if err := <-marshalErr; err != nil && !errors.Is(err, io.ErrClosedPipe) {
+		return err
+	}
+
+	return err
+}
+
+// UploadIngestJobCsv streams comma separated, LF terminated csv data to an open job. Readers that
+// can't seek are not retried when the session has expired.
+func (forceApi *ForceApi) UploadIngestJobCsv(jobId string, data io.Reader) error {
+	return forceApi.UploadIngestJobCsvContext(context.Background(), jobId, data)
+}
+
+// UploadIngestJobCsvContext is like UploadIngestJobCsv but carries a context.
+func (forceApi *ForceApi) UploadIngestJobCsvContext(ctx context.Context, jobId string, data io.Reader) error {
+	uri := fmt.Sprintf(ingestJobsUri+"/%v/batches", forceApi.apiVersion, jobId)
+	header := http.Header{"Content-Type": {csvContentType}}
+
+	resp, err := forceApi.send(ctx, "PUT", uri, nil, header, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return responseError(resp)
+}
+
+// CloseIngestJob marks the upload of a job complete, which queues it for processing.
+func (forceApi *ForceApi) CloseIngestJob(jobId string) (*IngestJob, error) {
+	return forceApi.CloseIngestJobContext(context.Background(), jobId)
+}
+
+// CloseIngestJobContext is like CloseIngestJob but carries a context.
+func (forceApi *ForceApi) CloseIngestJobContext(ctx context.Context, jobId string) (*IngestJob, error) {
+	return forceApi.setIngestJobState(ctx, jobId, JobStateUploadComplete)
+}
+
+// AbortIngestJob aborts a job. Records already processed are not rolled back.
+func (forceApi *ForceApi) AbortIngestJob(jobId string) (*IngestJob, error) {
+	return forceApi.AbortIngestJobContext(context.Background(), jobId)
+}
+
+// AbortIngestJobContext is like AbortIngestJob but carries a context.
+func (forceApi *ForceApi) AbortIngestJobContext(ctx context.Context, jobId string) (*IngestJob, error) {
+	return forceApi.setIngestJobState(ctx, jobId, JobStateAborted)
+}
+
+// GetIngestJob retrieves the current state of a job.
+func (forceApi *ForceApi) GetIngestJob(jobId string) (*IngestJob, error) {
+	return forceApi.GetIngestJobContext(context.Background(), jobId)
+}
+
+// GetIngestJobContext is like GetIngestJob but carries a context.
+func (forceApi *ForceApi) GetIngestJobContext(ctx context.Context, jobId string) (*IngestJob, error) {
+	job := &IngestJob{}
+	err := forceApi.GetContext(ctx, fmt.Sprintf(ingestJobsUri+"/%v", forceApi.apiVersion, jobId), nil, job)
+
+	return job, err
+}
+
+// DeleteIngestJob deletes a finished job along with its data and results.
+func (forceApi *ForceApi) DeleteIngestJob(jobId string) error {
+	return forceApi.DeleteIngestJobContext(context.Background(), jobId)
+}
+
+// DeleteIngestJobContext is like DeleteIngestJob but carries a context.
+func (forceApi *ForceApi) DeleteIngestJobContext(ctx context.Context, jobId string) error {
+	return forceApi.DeleteContext(ctx, fmt.Sprintf(ingestJobsUri+"/%v", forceApi.apiVersion, jobId), nil)
+}
+
+// WaitIngestJob polls a job, backing off exponentially, until it has finished. A job that failed
+// or was aborted is returned together with an error.
+func (forceApi *ForceApi) WaitIngestJob(jobId string) (*IngestJob, error) {
+	return forceApi.WaitIngestJobContext(context.Background(), jobId)
+}
+
+// WaitIngestJobContext is like WaitIngestJob but carries a context, which also bounds the wait.
+func (forceApi *ForceApi) WaitIngestJobContext(ctx context.Context, jobId string) (*IngestJob, error) {
+	var job *IngestJob
+	err := pollBulkJob(ctx, func() (bool, error) {
+		var err error
+		job, err = forceApi.GetIngestJobContext(ctx, jobId)
+		if err != nil {
+			return false, err
+		}
+		return job.State.Done(), nil
+	})
+	if err != nil {
+		return job, err
+	}
+
+	if job.State != JobStateJobComplete {
+		return job, fmt.Errorf("Bulk ingest job %v %v: %v", jobId, job.State, job.ErrorMessage)
+	}
+
+	return job, nil
+}
+
+// RunIngestJob creates a job for records, a slice of SObject structs, uploads them and waits for
+// the job to finish. Use the result methods to learn the outcome of each record.
+func (forceApi *ForceApi) RunIngestJob(operation BulkOperation, records interface{}) (*IngestJob, error) {
+	return forceApi.RunIngestJobContext(context.Background(), operation, records)
+}
+
+// RunIngestJobContext is like RunIngestJob but carries a context.
+func (forceApi *ForceApi) RunIngestJobContext(ctx context.Context, operation BulkOperation, records interface{}) (*IngestJob, error) {
+	recordsValue := reflect.ValueOf(records)
+	if recordsValue.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("RunIngestJob requires a slice of SObject, got %T", records)
+	}
+	in, err := sliceSObject(recordsValue.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := forceApi.CreateIngestJobContext(ctx, operation, in)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := forceApi.UploadIngestJobDataContext(ctx, job.Id, records); err != nil {
+		// The job can't be used without its data, so don't leave it open.
+		forceApi.abortIngestJob(job.Id)
+		return job, err
+	}
+
+	if _, err := forceApi.CloseIngestJobContext(ctx, job.Id); err != nil {
+		forceApi.abortIngestJob(job.Id)
+		return job, err
+	}
+
+	finished, err := forceApi.WaitIngestJobContext(ctx, job.Id)
+	if err != nil && (finished == nil || !finished.State.Done()) {
+		// Don't leave the job running when the wait was canceled or failed.
+		forceApi.abortIngestJob(job.Id)
+		return job, err
+	}
+
+	return finished, err
+}
+
+// abortIngestJob aborts a job that RunIngestJob gave up on, with a context of its own since the
+// caller's may be done.
+func (forceApi *ForceApi) abortIngestJob(jobId string) {
+	ctx, cancel := context.WithTimeout(context.Background(), bulkAbortTimeout)
+	defer cancel()
+
+	forceApi.AbortIngestJobContext(ctx, jobId)
+}
+
+// GetIngestJobSuccessfulResults decodes the records a job processed successfully into out, a
+// pointer to a slice of structs. Embed IngestResult to capture the sf__Id and sf__Created columns.
+func (forceApi *ForceApi) GetIngestJobSuccessfulResults(jobId string, out interface{}) error {
+	return forceApi.GetIngestJobSuccessfulResultsContext(context.Background(), jobId, out)
+}
+
+// GetIngestJobSuccessfulResultsContext is like GetIngestJobSuccessfulResults but carries a context.
+func (forceApi *ForceApi) GetIngestJobSuccessfulResultsContext(ctx context.Context, jobId string, out interface{}) error {
+	return forceApi.getIngestJobResults(ctx, jobId, "successfulResults", out)
+}
+
+// GetIngestJobFailedResults decodes the records a job failed to process into out, a pointer to a
+// slice of structs. Embed IngestResult to capture the sf__Id and sf__Error columns.
+func (forceApi *ForceApi) GetIngestJobFailedResults(jobId string, out interface{}) error {
+	return forceApi.GetIngestJobFailedResultsContext(context.Background(), jobId, out)
+}
+
+// GetIngestJobFailedResultsContext is like GetIngestJobFailedResults but carries a context.
+func (forceApi *ForceApi) GetIngestJobFailedResultsContext(ctx context.Context, jobId string, out interface{}) error {
+	return forceApi.getIngestJobResults(ctx, jobId, "failedResults", out)
+}
+
+// GetIngestJobUnprocessedRecords decodes the records a failed or aborted job did not process into
+// out, a pointer to a slice of structs.
+func (forceApi *ForceApi) GetIngestJobUnprocessedRecords(jobId string, out interface{}) error {
+	return forceApi.GetIngestJobUnprocessedRecordsContext(context.Background(), jobId, out)
+}
+
+// GetIngestJobUnprocessedRecordsContext is like GetIngestJobUnprocessedRecords but carries a context.
+func (forceApi *ForceApi) GetIngestJobUnprocessedRecordsContext(ctx context.Context, jobId string, out interface{}) error {
+	return forceApi.getIngestJobResults(ctx, jobId, "unprocessedrecords", out)
+}
+
+func (forceApi *ForceApi) setIngestJobState(ctx context.Context, jobId string, state BulkJobState) (*IngestJob, error) {
+	job := &IngestJob{}
+	uri := fmt.Sprintf(ingestJobsUri+"/%v", forceApi.apiVersion, jobId)
+	err := forceApi.PatchContext(ctx, uri, nil, &ingestJobState{State: state}, job)
+
+	return job, err
+}
+
+func (forceApi *ForceApi) getIngestJobResults(ctx context.Context, jobId, results string, out interface{}) error {
+	uri := fmt.Sprintf(ingestJobsUri+"/%v/%v/", forceApi.apiVersion, jobId, results)
+	header := http.Header{"Accept": {csvContentType}}
+
+	resp, err := forceApi.send(ctx, "GET", uri, nil, header, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := responseError(resp); err != nil {
+		return err
+	}
+
+	return unmarshalCSV(resp.Body, out)
+}
+
+// pollBulkJob calls done until it reports true, waiting with exponential backoff in between.
+func pollBulkJob(ctx context.Context, done func() (bool, error)) error {
+	interval := bulkPollInterval
+	for {
+		finished, err := done()
+		if err != nil || finished {
+			return err
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		interval *= 2
+		if interval > bulkMaxPollInterval {
+			interval = bulkMaxPollInterval
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package force
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	queryJobsUri = resourcesUri + "/jobs/query"
+
+	locatorHeader         = "Sforce-Locator"
+	numberOfRecordsHeader = "Sforce-NumberOfRecords"
+)
+
+const (
+	BulkQuery    BulkOperation = "query"
+	BulkQueryAll BulkOperation = "queryAll"
+)
+
+// QueryJob describes a Bulk API 2.0 query job.
+type QueryJob struct {
+	Id                     string        `force:"id,omitempty"`
+	Operation              BulkOperation `force:"operation,omitempty"`
+	Query                  string        `force:"query,omitempty"`
+	Object                 string        `force:"object,omitempty"`
+	State                  BulkJobState  `force:"state,omitempty"`
+	ContentType            string        `force:"contentType,omitempty"`
+	LineEnding             string        `force:"lineEnding,omitempty"`
+	ColumnDelimiter        string        `force:"columnDelimiter,omitempty"`
+	ConcurrencyMode        string        `force:"concurrencyMode,omitempty"`
+	JobType                string        `force:"jobType,omitempty"`
+	ApiVersion             float64       `force:"apiVersion,omitempty"`
+	CreatedById            string        `force:"createdById,omitempty"`
+	CreatedDate            string        `force:"createdDate,omitempty"`
+	SystemModstamp         string        `force:"systemModstamp,omitempty"`
+	NumberRecordsProcessed float64       `force:"numberRecordsProcessed,omitempty"`
+	Retries                float64       `force:"retries,omitempty"`
+	TotalProcessingTime    float64       `force:"totalProcessingTime,omitempty"`
+	ErrorMessage           string        `force:"errorMessage,omitempty"`
+}
+
+// CreateQueryJob creates a Bulk API 2.0 query job. operation is BulkQuery, or BulkQueryAll to
+// include deleted and archived records.
+func (forceApi *ForceApi) CreateQueryJob(operation BulkOperation, query string) (*QueryJob, error) {
+	return forceApi.CreateQueryJobContext(context.Background(), operation, query)
+}
+
+// CreateQueryJobContext is like CreateQueryJob but carries a context.
+func (forceApi *ForceApi) CreateQueryJobContext(ctx context.Context, operation BulkOperation, query string) (*QueryJob, error) {
+	payload := &QueryJob{
+		Operation:   operation,
+		Query:       query,
+		ContentType: "CSV",
+		LineEnding:  "LF",
+	}
+
+	job := &QueryJob{}
+	err := forceApi.PostContext(ctx, fmt.Sprintf(queryJobsUri, forceApi.apiVersion), nil, payload, job)
+
+	return job, err
+}
+
+// GetQueryJob retrieves the current state of a job.
+func (forceApi *ForceApi) GetQueryJob(jobId string) (*QueryJob, error) {
+	return forceApi.GetQueryJobContext(context.Background(), jobId)
+}
+
+// GetQueryJobContext is like GetQueryJob but carries a context.
+func (forceApi *ForceApi) GetQueryJobContext(ctx context.Context, jobId string) (*QueryJob, error) {
+	job := &QueryJob{}
+	err := forceApi.GetContext(ctx, fmt.Sprintf(queryJobsUri+"/%v", forceApi.apiVersion, jobId), nil, job)
+
+	return job, err
+}
+
+// AbortQueryJob aborts a job that has not finished.
+func (forceApi *ForceApi) AbortQueryJob(jobId string) (*QueryJob, error) {
+	return forceApi.AbortQueryJobContext(context.Background(), jobId)
+}
+
+// AbortQueryJobContext is like AbortQueryJob but carries a context.
+func (forceApi *ForceApi) AbortQueryJobContext(ctx context.Context, jobId string) (*QueryJob, error) {
+	job := &QueryJob{}
+	uri := fmt.Sprintf(queryJobsUri+"/%v", forceApi.apiVersion, jobId)
+	err := forceApi.PatchContext(ctx, uri, nil, &ingestJobState{State: JobStateAborted}, job)
+
+	return job, err
+}
+
+// DeleteQueryJob deletes a finished job along with its results.
+func (forceApi *ForceApi) DeleteQueryJob(jobId string) error {
+	return forceApi.DeleteQueryJobContext(context.Background(), jobId)
+}
+
+// DeleteQueryJobContext is like DeleteQueryJob but carries a context.
+func (forceApi *ForceApi) DeleteQueryJobContext(ctx context.Context, jobId string) error {
+	return forceApi.DeleteContext(ctx, fmt.Sprintf(queryJobsUri+"/%v", forceApi.apiVersion, jobId), nil)
+}
+
+// WaitQueryJob polls a job, backing off exponentially, until it has finished. A job that failed
+// or was aborted is returned together with an error.
+func (forceApi *ForceApi) WaitQueryJob(jobId string) (*QueryJob, error) {
+	return forceApi.WaitQueryJobContext(context.Background(), jobId)
+}
+
+// WaitQueryJobContext is like WaitQueryJob but carries a context, which also bounds the wait.
+func (forceApi *ForceApi) WaitQueryJobContext(ctx context.Context, jobId string) (*QueryJob, error) {
+	var job *QueryJob
+	err := pollBulkJob(ctx, func() (bool, error) {
+		var err error
+		job, err = forceApi.GetQueryJobContext(ctx, jobId)
+		if err != nil {
+			return false, err
+		}
+		return job.State.Done(), nil
+	})
+	if err != nil {
+		return job, err
+	}
+
+	if job.State != JobStateJobComplete {
+		return job, fmt.Errorf("Bulk query job %v %v: %v", jobId, job.State, job.ErrorMessage)
+	}
+
+	return job, nil
+}
+
+// RunQueryJob creates a query job, waits for it to finish and returns its results.
+func (forceApi *ForceApi) RunQueryJob(operation BulkOperation, query string) (*QueryJobResults, error) {
+	return forceApi.RunQueryJobContext(context.Background(), operation, query)
+}
+
+// RunQueryJobContext is like RunQueryJob but carries a context, which is also used to read the
+// results.
+func (forceApi *ForceApi) RunQueryJobContext(ctx context.Context, operation BulkOperation, query string) (*QueryJobResults, error) {
+	job, err := forceApi.CreateQueryJobContext(ctx, operation, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if finished, err := forceApi.WaitQueryJobContext(ctx, job.Id); err != nil {
+		if finished == nil || !finished.State.Done() {
+			// Don't leave the job running when the wait was canceled or failed.
+			forceApi.abortQueryJob(job.Id)
+		}
+		return nil, err
+	}
+
+	return forceApi.GetQueryJobResultsContext(ctx, job.Id, 0), nil
+}
+
+// abortQueryJob aborts a job that RunQueryJob gave up on, with a context of its own since the
+// caller's may be done.
+func (forceApi *ForceApi) abortQueryJob(jobId string) {
+	ctx, cancel := context.WithTimeout(context.Background(), bulkAbortTimeout)
+	defer cancel()
+
+	forceApi.AbortQueryJobContext(ctx, jobId)
+}
+
+// GetQueryJobResults returns the results of a finished job. Results are requested a page at a
+// time, following the Sforce-Locator header, and are streamed rather than held in memory.
+// maxRecords limits the number of records in each page; zero lets Salesforce choose.
+func (forceApi *ForceApi) GetQueryJobResults(jobId string, maxRecords int) *QueryJobResults {
+	return forceApi.GetQueryJobResultsContext(context.Background(), jobId, maxRecords)
+}
+
+// GetQueryJobResultsContext is like GetQueryJobResults but carries a context used for every page
+// request.
+func (forceApi *ForceApi) GetQueryJobResultsContext(ctx context.Context, jobId string, maxRecords int) *QueryJobResults {
+	return &QueryJobResults{
+		forceApi:   forceApi,
+		ctx:        ctx,
+		jobId:      jobId,
+		maxRecords: maxRecords,
+	}
+}
+
+// QueryJobResults reads the csv results of a query job. Either walk the rows with Next and
+// Decode, or the raw csv of each page with NextPage and Page; don't mix the two. Close must be
+// called if the results are not read to the end.
+//
+//	results := forceApi.GetQueryJobResults(jobId, 0)
+//	defer results.Close()
+//	for results.Next() {
+//		account := &sobjects.Account{}
+//		if err := results.Decode(account); err != nil {
+//			...
+//		}
+//	}
+//	if err := results.Err(); err != nil {
+//		...
+//	}
+type QueryJobResults struct {
+	forceApi   *ForceApi
+	ctx        context.Context
+	jobId      string
+	maxRecords int
+
+	locator         string
+	lastPage        bool
+	numberOfRecords int
+
+	body    io.ReadCloser
+	decoder *csvDecoder
+	err     error
+}
+
+// NextPage requests the next page of results, discarding the rest of the current one. It
+// returns false when there are no more pages or an error occurred.
+func (results *QueryJobResults) NextPage() bool {
+	results.closePage()
+	if results.err != nil || results.lastPage {
+		return false
+	}
+
+	params := url.Values{}
+	if len(results.locator) > 0 {
+		params.Set("locator", results.locator)
+	}
+	if results.maxRecords > 0 {
+		params.Set("maxRecords", strconv.Itoa(results.maxRecords))
+	}
+
+	uri := fmt.Sprintf(queryJobsUri+"/%v/results", results.forceApi.apiVersion, results.jobId)
+	header := http.Header{"Accept": {csvContentType}}
+
+	resp, err := results.forceApi.send(results.ctx, "GET", uri, params, header, nil)
+	if err != nil {
+		results.err = err
+		return false
+	}
+	if err := responseError(resp); err != nil {
+		resp.Body.Close()
+		results.err = err
+		return false
+	}
+
+	// The last page carries the locator "null".
+	results.locator = resp.Header.Get(locatorHeader)
+	results.lastPage = len(results.locator) == 0 || results.locator == "null"
+	results.numberOfRecords, _ = strconv.Atoi(resp.Header.Get(numberOfRecordsHeader))
+	results.body = resp.Body
+
+	return true
+}
+
+// Page returns the csv of the current page, header line included.
+func (results *QueryJobResults) Page() io.Reader {
+	if results.body == nil {
+		return eofReader{}
+	}
+
+	return results.body
+}
+
+// NumberOfRecords returns the number of records in the current page.
+func (results *QueryJobResults) NumberOfRecords() int {
+	return results.numberOfRecords
+}
+
+// Next advances to the next record, requesting the next page when the current one is exhausted.
+// It returns false when there are no more records or an error occurred.
+func (results *QueryJobResults) Next() bool {
+	for results.err == nil {
+		if results.decoder != nil {
+			err := results.decoder.Read()
+			if err == nil {
+				return true
+			}
+			if err != io.EOF {
+				results.err = err
+				break
+			}
+		}
+
+		if !results.NextPage() {
+			break
+		}
+		decoder, err := newCSVDecoder(results.body)
+		if err != nil {
+			results.err = err
+			break
+		}
+		results.decoder = decoder
+	}
+
+	results.closePage()
+	return false
+}
+
+// Decode unmarshals the current record into out, a pointer to a struct whose force tags name the
+// selected fields. Relationship fields are named like "Account.Name".
+func (results *QueryJobResults) Decode(out interface{}) error {
+	if results.decoder == nil {
+		return fmt.Errorf("Decode called without a current record")
+	}
+
+	return results.decoder.DecodeRow(out)
+}
+
+// Err returns the error, if any, that stopped the iteration.
+func (results *QueryJobResults) Err() error {
+	return results.err
+}
+
+// Close releases the current page.
+func (results *QueryJobResults) Close() error {
+	results.closePage()
+	return nil
+}
+
+func (results *QueryJobResults) closePage() {
+	if results.body != nil {
+		results.body.Close()
+		results.body = nil
+	}
+	results.decoder = nil
+}
+
+type eofReader struct{}
+
+func (eofReader) Read([]byte) (int, error) {
+	return 0, io.EOF
+}
@@ -0,0 +1,180 @@
+package force
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type bulkQueryAccount struct {
+	Id          string `force:"Id"`
+	Name        string `force:"Name"`
+	OwnerName   string `force:"Owner.Name"`
+	NumberOfEmp int    `force:"NumberOfEmployees"`
+}
+
+func TestRunQueryJob(t *testing.T) {
+	defer func(interval time.Duration) { bulkPollInterval = interval }(bulkPollInterval)
+	bulkPollInterval = time.Millisecond
+
+	jobs := fmt.Sprintf(queryJobsUri, testVersion)
+	polls := 0
+	var locators []string
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST " + jobs:
+			job := map[string]string{}
+			json.NewDecoder(r.Body).Decode(&job)
+			if job["operation"] != "queryAll" || job["query"] != "SELECT Id, Name FROM Account" {
+				t.Errorf("Unexpected job: %v", job)
+			}
+			fmt.Fprint(w, `{"id":"750Q","state":"UploadComplete","operation":"queryAll"}`)
+		case "GET " + jobs + "/750Q":
+			polls++
+			state := "InProgress"
+			if polls > 1 {
+				state = "JobComplete"
+			}
+			fmt.Fprintf(w, `{"id":"750Q","state":"%v"}`, state)
+		case "GET " + jobs + "/750Q/results":
+			if r.Header.Get("Accept") != csvContentType {
+				t.Errorf("Unexpected accept header: %v", r.Header.Get("Accept"))
+			}
+			locator := r.URL.Query().Get("locator")
+			locators = append(locators, locator)
+			switch locator {
+			case "":
+				w.Header().Set(locatorHeader, "MTAw")
+				w.Header().Set(numberOfRecordsHeader, "2")
+				fmt.Fprint(w, "\"Id\",\"Name\",\"Owner.Name\",\"NumberOfEmployees\"\n001A,Acme,Ann,10\n001B,\"Initech, Inc\",Bob,\n")
+			case "MTAw":
+				w.Header().Set(locatorHeader, "null")
+				w.Header().Set(numberOfRecordsHeader, "1")
+				fmt.Fprint(w, "\"Id\",\"Name\",\"Owner.Name\",\"NumberOfEmployees\"\n001C,Globex,Cat,30\n")
+			default:
+				t.Errorf("Unexpected locator: %v", locator)
+			}
+		default:
+			t.Errorf("Unexpected request: %v %v", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	results, err := forceApi.RunQueryJob(BulkQueryAll, "SELECT Id, Name FROM Account")
+	if err != nil {
+		t.Fatalf("Failed to run query job: %v", err)
+	}
+	defer results.Close()
+
+	var accounts []bulkQueryAccount
+	for results.Next() {
+		account := bulkQueryAccount{}
+		if err := results.Decode(&account); err != nil {
+			t.Fatalf("Failed to decode record: %v", err)
+		}
+		accounts = append(accounts, account)
+	}
+	if err := results.Err(); err != nil {
+		t.Fatalf("Failed to read results: %v", err)
+	}
+
+	if fmt.Sprint(locators) != "[ MTAw]" {
+		t.Fatalf("Expected the locator to be followed, got %v", locators)
+	}
+	expected := "[{001A Acme Ann 10} {001B Initech, Inc Bob 0} {001C Globex Cat 30}]"
+	if fmt.Sprint(accounts) != expected {
+		t.Fatalf("Unexpected accounts: %v", accounts)
+	}
+}
+
+func TestRunQueryJobAborted(t *testing.T) {
+	defer func(interval time.Duration) { bulkPollInterval = interval }(bulkPollInterval)
+	bulkPollInterval = time.Millisecond
+
+	jobs := fmt.Sprintf(queryJobsUri, testVersion)
+	var aborted bool
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST " + jobs:
+			fmt.Fprint(w, `{"id":"750Q","state":"UploadComplete"}`)
+		case "PATCH " + jobs + "/750Q":
+			state := map[string]string{}
+			json.NewDecoder(r.Body).Decode(&state)
+			aborted = state["state"] == "Aborted"
+			fmt.Fprintf(w, `{"id":"750Q","state":"%v"}`, state["state"])
+		case "GET " + jobs + "/750Q":
+			fmt.Fprint(w, `{"id":"750Q","state":"InProgress"}`)
+		default:
+			t.Errorf("Unexpected request: %v %v", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := forceApi.RunQueryJobContext(ctx, BulkQuery, "SELECT Id FROM Account"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the wait to time out, got %v", err)
+	}
+	if !aborted {
+		t.Fatal("Expected the job to be aborted")
+	}
+}
+
+func TestQueryJobResultsPages(t *testing.T) {
+	jobs := fmt.Sprintf(queryJobsUri, testVersion)
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != jobs+"/750Q/results" || r.URL.Query().Get("maxRecords") != "1" {
+			t.Errorf("Unexpected request: %v %v", r.Method, r.URL)
+		}
+		if r.URL.Query().Get("locator") == "" {
+			w.Header().Set(locatorHeader, "MQ")
+			fmt.Fprint(w, "Id\n001A\n")
+			return
+		}
+		w.Header().Set(locatorHeader, "null")
+		fmt.Fprint(w, "Id\n001B\n")
+	}))
+	defer server.Close()
+
+	results := forceApi.GetQueryJobResults("750Q", 1)
+	defer results.Close()
+
+	var pages []string
+	for results.NextPage() {
+		data, err := ioutil.ReadAll(results.Page())
+		if err != nil {
+			t.Fatalf("Failed to read page: %v", err)
+		}
+		pages = append(pages, string(data))
+	}
+	if err := results.Err(); err != nil {
+		t.Fatalf("Failed to read results: %v", err)
+	}
+
+	if len(pages) != 2 || pages[0] != "Id\n001A\n" || pages[1] != "Id\n001B\n" {
+		t.Fatalf("Unexpected pages: %q", pages)
+	}
+}
+
+func TestQueryJobResultsError(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `[{"message":"Job not complete","errorCode":"INVALIDJOBSTATE"}]`)
+	}))
+	defer server.Close()
+
+	results := forceApi.GetQueryJobResults("750Q", 0)
+	if results.Next() {
+		t.Fatal("Expected no records")
+	}
+	if apiErrors := (ApiErrors{}); !errors.As(results.Err(), &apiErrors) {
+		t.Fatalf("Expected ApiErrors, got: %#v", results.Err())
+	}
+}
@@ -0,0 +1,168 @@
+package force
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nimajalali/go-force/sobjects"
+)
+
+func TestRunIngestJob(t *testing.T) {
+	defer func(interval time.Duration) { bulkPollInterval = interval }(bulkPollInterval)
+	bulkPollInterval = time.Millisecond
+
+	jobs := fmt.Sprintf(ingestJobsUri, testVersion)
+	polls := 0
+	var uploaded string
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST " + jobs:
+			job := map[string]string{}
+			json.NewDecoder(r.Body).Decode(&job)
+			if job["operation"] != "upsert" || job["object"] != "Account" || job["externalIdFieldName"] != "External_Id__c" {
+				t.Errorf("Unexpected job: %v", job)
+			}
+			fmt.Fprint(w, `{"id":"750A","state":"Open","operation":"upsert","object":"Account"}`)
+		case "PUT " + jobs + "/750A/batches":
+			if r.Header.Get("Content-Type") != csvContentType {
+				t.Errorf("Unexpected content type: %v", r.Header.Get("Content-Type"))
+			}
+			data, _ := ioutil.ReadAll(r.Body)
+			uploaded = string(data)
+			w.WriteHeader(http.StatusCreated)
+		case "PATCH " + jobs + "/750A":
+			state := map[string]string{}
+			json.NewDecoder(r.Body).Decode(&state)
+			if state["state"] != "UploadComplete" {
+				t.Errorf("Unexpected state change: %v", state)
+			}
+			fmt.Fprint(w, `{"id":"750A","state":"UploadComplete"}`)
+		case "GET " + jobs + "/750A":
+			polls++
+			state := "InProgress"
+			if polls > 2 {
+				state = "JobComplete"
+			}
+			fmt.Fprintf(w, `{"id":"750A","state":"%v","numberRecordsProcessed":2}`, state)
+		case "GET " + jobs + "/750A/successfulResults/":
+			w.Header().Set("Content-Type", csvContentType)
+			fmt.Fprint(w, "\"sf__Id\",\"sf__Created\",Name,External_Id__c\n001A,true,Acme,a\n001B,false,Initech,b\n")
+		default:
+			t.Errorf("Unexpected request: %v %v", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	acme := &ExternalAccount{ExternalId: "a"}
+	acme.Name = "Acme"
+	initech := &ExternalAccount{ExternalId: "b"}
+	initech.Name = "Initech"
+
+	job, err := forceApi.RunIngestJob(BulkUpsert, []*ExternalAccount{acme, initech})
+	if err != nil {
+		t.Fatalf("Failed to run ingest job: %v", err)
+	}
+	if job.State != JobStateJobComplete || job.NumberRecordsProcessed != 2 || polls != 3 {
+		t.Fatalf("Unexpected job after %v polls: %+v", polls, job)
+	}
+	if uploaded != "Name,External_Id__c\nAcme,a\nInitech,b\n" {
+		t.Fatalf("Unexpected upload: %q", uploaded)
+	}
+
+	type result struct {
+		IngestResult
+		ExternalAccount
+	}
+	var results []result
+	if err := forceApi.GetIngestJobSuccessfulResults(job.Id, &results); err != nil {
+		t.Fatalf("Failed to get successful results: %v", err)
+	}
+	if len(results) != 2 || results[0].SfId != "001A" || !results[0].SfCreated || results[1].ExternalId != "b" {
+		t.Fatalf("Unexpected results: %+v", results)
+	}
+}
+
+func TestRunIngestJobAborted(t *testing.T) {
+	defer func(interval time.Duration) { bulkPollInterval = interval }(bulkPollInterval)
+	bulkPollInterval = time.Millisecond
+
+	jobs := fmt.Sprintf(ingestJobsUri, testVersion)
+	var aborted bool
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST " + jobs:
+			fmt.Fprint(w, `{"id":"750A","state":"Open"}`)
+		case "PUT " + jobs + "/750A/batches":
+			w.WriteHeader(http.StatusCreated)
+		case "PATCH " + jobs + "/750A":
+			state := map[string]string{}
+			json.NewDecoder(r.Body).Decode(&state)
+			aborted = state["state"] == "Aborted"
+			fmt.Fprintf(w, `{"id":"750A","state":"%v"}`, state["state"])
+		case "GET " + jobs + "/750A":
+			fmt.Fprint(w, `{"id":"750A","state":"InProgress"}`)
+		default:
+			t.Errorf("Unexpected request: %v %v", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := forceApi.RunIngestJobContext(ctx, BulkInsert, []*ExternalAccount{{ExternalId: "a"}}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the wait to time out, got %v", err)
+	}
+	if !aborted {
+		t.Fatal("Expected the job to be aborted")
+	}
+}
+
+func TestUploadIngestJobDataErrors(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `[{"errorCode":"NOT_FOUND","message":"The requested resource does not exist"}]`)
+	}))
+	defer server.Close()
+
+	if err := forceApi.UploadIngestJobData("750A", []*ExternalAccount{{ExternalId: "a"}}); !IsNotFound(err) {
+		t.Fatalf("Expected the upload error to be returned, got %v", err)
+	}
+	if err := forceApi.UploadIngestJobData("750A", "Acme"); err == nil || !strings.Contains(err.Error(), "expected a slice") {
+		t.Fatalf("Expected the csv encoding error to be returned, got %v", err)
+	}
+}
+
+func TestWaitIngestJobFailed(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"750A","state":"Failed","errorMessage":"InvalidBatch : Field name not found : Foo"}`)
+	}))
+	defer server.Close()
+
+	job, err := forceApi.WaitIngestJob("750A")
+	if err == nil || job.State != JobStateFailed {
+		t.Fatalf("Expected failed job to return an error, got %v %+v", err, job)
+	}
+}
+
+func TestGetIngestJobResultsError(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `[{"errorCode":"NOT_FOUND","message":"The requested resource does not exist"}]`)
+	}))
+	defer server.Close()
+
+	var results []sobjects.Account
+	err := forceApi.GetIngestJobFailedResults("750A", &results)
+	if !IsNotFound(err) {
+		t.Fatalf("Expected ApiErrors, got: %#v", err)
+	}
+}
@@ -0,0 +1,342 @@
+package force
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nimajalali/go-force/forcejson"
+)
+
+const pkChunkingHeader = "Sforce-Enable-PKChunking"
+
+// PKChunking asks Salesforce to split a bulk query into batches by ranges of record id, which
+// lets queries over very large objects finish. Zero values use the Salesforce defaults.
+type PKChunking struct {
+	// Number of records in each chunk, up to 250,000. Defaults to 100,000.
+	ChunkSize int
+	// Parent object whose ids the chunks are ranged over, when querying a sharing or history
+	// object such as AccountShare.
+	Parent string
+	// Id of the first record of the first chunk.
+	StartRow string
+}
+
+func (chunking *PKChunking) header() string {
+	var options []string
+	if chunking.ChunkSize > 0 {
+		options = append(options, "chunkSize="+strconv.Itoa(chunking.ChunkSize))
+	}
+	if len(chunking.Parent) > 0 {
+		options = append(options, "parent="+chunking.Parent)
+	}
+	if len(chunking.StartRow) > 0 {
+		options = append(options, "startRow="+chunking.StartRow)
+	}
+
+	if len(options) == 0 {
+		return "true"
+	}
+
+	return strings.Join(options, "; ")
+}
+
+type bulkResultList struct {
+	Results []string `xml:"result"`
+}
+
+// CreateBulkQueryJob creates a Bulk API 1.0 job running queries against the sobject of in.
+// operation is BulkQuery, or BulkQueryAll to include deleted and archived records. Results are
+// in contentType. When chunking is not nil the query is PK chunked, and its results are spread
+// over the batches Salesforce creates for each chunk.
+func (forceApi *ForceApi) CreateBulkQueryJob(operation BulkOperation, in SObject, contentType BulkContentType, chunking *PKChunking) (*BulkJob, error) {
+	return forceApi.CreateBulkQueryJobContext(context.Background(), operation, in, contentType, chunking)
+}
+
+// CreateBulkQueryJobContext is like CreateBulkQueryJob but carries a context.
+func (forceApi *ForceApi) CreateBulkQueryJobContext(ctx context.Context, operation BulkOperation, in SObject, contentType BulkContentType, chunking *PKChunking) (*BulkJob, error) {
+	payload := &BulkJob{
+		Operation:   operation,
+		Object:      in.ApiName(),
+		ContentType: contentType,
+	}
+
+	var header http.Header
+	if chunking != nil {
+		header = http.Header{pkChunkingHeader: {chunking.header()}}
+	}
+
+	return forceApi.createBulkJob(ctx, payload, header)
+}
+
+// AddBulkQueryBatch adds a SOQL query as a batch of an open query job.
+func (forceApi *ForceApi) AddBulkQueryBatch(job *BulkJob, query string) (*BulkBatch, error) {
+	return forceApi.AddBulkQueryBatchContext(context.Background(), job, query)
+}
+
+// AddBulkQueryBatchContext is like AddBulkQueryBatch but carries a context.
+func (forceApi *ForceApi) AddBulkQueryBatchContext(ctx context.Context, job *BulkJob, query string) (*BulkBatch, error) {
+	return forceApi.AddBulkBatchDataContext(ctx, job, strings.NewReader(query))
+}
+
+// RunBulkQuery runs query in a Bulk API 1.0 job, PK chunked when chunking is not nil, and waits
+// for every batch to finish. The results of all batches are returned as a single result set.
+func (forceApi *ForceApi) RunBulkQuery(operation BulkOperation, in SObject, query string, contentType BulkContentType, chunking *PKChunking) (*BulkQueryResults, error) {
+	return forceApi.RunBulkQueryContext(context.Background(), operation, in, query, contentType, chunking)
+}
+
+// RunBulkQueryContext is like RunBulkQuery but carries a context, which is also used to read the
+// results.
+func (forceApi *ForceApi) RunBulkQueryContext(ctx context.Context, operation BulkOperation, in SObject, query string, contentType BulkContentType, chunking *PKChunking) (*BulkQueryResults, error) {
+	job, err := forceApi.CreateBulkQueryJobContext(ctx, operation, in, contentType, chunking)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := forceApi.AddBulkQueryBatchContext(ctx, job, query); err != nil {
+		forceApi.abortBulkJob(job.Id)
+		return nil, err
+	}
+
+	if _, err := forceApi.CloseBulkJobContext(ctx, job.Id); err != nil {
+		forceApi.abortBulkJob(job.Id)
+		return nil, err
+	}
+
+	batches, err := forceApi.WaitBulkBatchesContext(ctx, job.Id)
+	if err != nil {
+		if len(batches) == 0 || !bulkBatchesDone(batches) {
+			// Don't leave the job running when the wait was canceled or failed.
+			forceApi.abortBulkJob(job.Id)
+		}
+		return nil, err
+	}
+
+	return forceApi.GetBulkQueryResultsContext(ctx, job, batches), nil
+}
+
+// abortBulkJob aborts a job that RunBulkQuery gave up on, with a context of its own since the
+// caller's may be done.
+func (forceApi *ForceApi) abortBulkJob(jobId string) {
+	ctx, cancel := context.WithTimeout(context.Background(), bulkAbortTimeout)
+	defer cancel()
+
+	forceApi.AbortBulkJobContext(ctx, jobId)
+}
+
+// GetBulkQueryResults returns the results of the completed batches of a query job as a single
+// result set. Pass every batch of the job, as returned by WaitBulkBatches, to merge the results
+// of a PK chunked query. Each result file is requested when it's reached and streamed rather than
+// held in memory.
+func (forceApi *ForceApi) GetBulkQueryResults(job *BulkJob, batches []*BulkBatch) *BulkQueryResults {
+	return forceApi.GetBulkQueryResultsContext(context.Background(), job, batches)
+}
+
+// GetBulkQueryResultsContext is like GetBulkQueryResults but carries a context used for every
+// request.
+func (forceApi *ForceApi) GetBulkQueryResultsContext(ctx context.Context, job *BulkJob, batches []*BulkBatch) *BulkQueryResults {
+	results := &BulkQueryResults{
+		forceApi: forceApi,
+		ctx:      ctx,
+		job:      job,
+	}
+	for _, batch := range batches {
+		// The original batch of a PK chunked query has no results of its own.
+		if batch.State == BatchStateCompleted {
+			results.batches = append(results.batches, batch)
+		}
+	}
+
+	return results
+}
+
+// BulkQueryResults reads the results of a bulk query job. Either walk the records with Next and
+// Decode, or the raw data of each result file with NextResult and Result; don't mix the two.
+// Decode supports csv and json jobs; xml results can only be read raw. Close must be called if the
+// results are not read to the end.
+type BulkQueryResults struct {
+	forceApi *ForceApi
+	ctx      context.Context
+	job      *BulkJob
+
+	batches   []*BulkBatch
+	batchId   string
+	resultIds []string
+
+	body        io.ReadCloser
+	csvDecoder  *csvDecoder
+	jsonDecoder *json.Decoder
+	record      json.RawMessage
+	err         error
+}
+
+// NextResult requests the next result file, moving on to the next batch when the results of the
+// current one are exhausted, and discards the rest of the current file. It returns false when
+// there are no more results or an error occurred.
+func (results *BulkQueryResults) NextResult() bool {
+	results.closeResult()
+
+	for results.err == nil && len(results.resultIds) == 0 {
+		if len(results.batches) == 0 {
+			return false
+		}
+		results.batchId = results.batches[0].Id
+		results.batches = results.batches[1:]
+		results.resultIds, results.err = results.getResultIds(results.batchId)
+	}
+	if results.err != nil {
+		return false
+	}
+
+	uri := results.batchUri(results.batchId) + "/result/" + results.resultIds[0]
+	results.resultIds = results.resultIds[1:]
+
+	resp, err := results.forceApi.sendBulk(results.ctx, "GET", uri, results.job.ContentType, nil)
+	if err != nil {
+		results.err = err
+		return false
+	}
+	results.body = resp.Body
+
+	return true
+}
+
+// Result returns the data of the current result file.
+func (results *BulkQueryResults) Result() io.Reader {
+	if results.body == nil {
+		return eofReader{}
+	}
+
+	return results.body
+}
+
+// Next advances to the next record, requesting the next result file when the current one is
+// exhausted. It returns false when there are no more records or an error occurred.
+func (results *BulkQueryResults) Next() bool {
+	for results.err == nil {
+		if results.body != nil {
+			more, err := results.nextRecord()
+			if more {
+				return true
+			}
+			if err != nil {
+				results.err = err
+				break
+			}
+		}
+
+		if !results.NextResult() {
+			break
+		}
+	}
+
+	results.closeResult()
+	return false
+}
+
+// Decode unmarshals the current record into out, a pointer to a struct.
+func (results *BulkQueryResults) Decode(out interface{}) error {
+	switch {
+	case results.csvDecoder != nil:
+		return results.csvDecoder.DecodeRow(out)
+	case results.record != nil:
+		return forcejson.Unmarshal(results.record, out)
+	}
+
+	return fmt.Errorf("Decode called without a current record")
+}
+
+// Err returns the error, if any, that stopped the iteration.
+func (results *BulkQueryResults) Err() error {
+	return results.err
+}
+
+// Close releases the current result file.
+func (results *BulkQueryResults) Close() error {
+	results.closeResult()
+	return nil
+}
+
+// nextRecord reads the next record of the current result file. It returns false with a nil error
+// at the end of the file.
+func (results *BulkQueryResults) nextRecord() (bool, error) {
+	switch results.job.ContentType {
+	case BulkContentTypeCSV:
+		if results.csvDecoder == nil {
+			decoder, err := newCSVDecoder(results.body)
+			if err != nil {
+				return false, err
+			}
+			results.csvDecoder = decoder
+		}
+		if err := results.csvDecoder.Read(); err != nil {
+			if err == io.EOF {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+
+	case BulkContentTypeJSON:
+		// Records are read one at a time from the json array.
+		if results.jsonDecoder == nil {
+			results.jsonDecoder = json.NewDecoder(results.body)
+			if token, err := results.jsonDecoder.Token(); err != nil || token != json.Delim('[') {
+				return false, fmt.Errorf("Unable to read bulk query results: expected an array")
+			}
+		}
+		if !results.jsonDecoder.More() {
+			return false, nil
+		}
+		results.record = nil
+		if err := results.jsonDecoder.Decode(&results.record); err != nil {
+			return false, fmt.Errorf("Unable to read bulk query results: %v", err)
+		}
+		return true, nil
+	}
+
+	return false, fmt.Errorf("Unable to decode %v bulk query results, read them with NextResult", results.job.ContentType)
+}
+
+func (results *BulkQueryResults) getResultIds(batchId string) ([]string, error) {
+	resp, err := results.forceApi.sendBulk(results.ctx, "GET", results.batchUri(batchId)+"/result", results.job.ContentType, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Csv and xml jobs list result ids in xml, json jobs in a json array.
+	if results.job.ContentType == BulkContentTypeJSON {
+		var resultIds []string
+		if err := forcejson.NewDecoder(resp.Body).Decode(&resultIds); err != nil {
+			return nil, fmt.Errorf("Unable to unmarshal batch %v result list: %v", batchId, err)
+		}
+		return resultIds, nil
+	}
+
+	list := &bulkResultList{}
+	if err := xml.NewDecoder(resp.Body).Decode(list); err != nil {
+		return nil, fmt.Errorf("Unable to unmarshal batch %v result list: %v", batchId, err)
+	}
+
+	return list.Results, nil
+}
+
+func (results *BulkQueryResults) batchUri(batchId string) string {
+	return results.forceApi.bulkJobUri(results.job.Id) + "/batch/" + batchId
+}
+
+func (results *BulkQueryResults) closeResult() {
+	if results.body != nil {
+		results.body.Close()
+		results.body = nil
+	}
+	results.csvDecoder = nil
+	results.jsonDecoder = nil
+	results.record = nil
+}
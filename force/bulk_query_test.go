@@ -0,0 +1,155 @@
+package force
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nimajalali/go-force/sobjects"
+)
+
+func TestRunBulkQueryPKChunking(t *testing.T) {
+	defer func(interval time.Duration) { bulkPollInterval = interval }(bulkPollInterval)
+	bulkPollInterval = time.Millisecond
+
+	jobs := fmt.Sprintf(bulkJobsUri, strings.TrimPrefix(testVersion, "v"))
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST " + jobs:
+			if chunking := r.Header.Get(pkChunkingHeader); chunking != "chunkSize=2; startRow=001A" {
+				t.Errorf("Unexpected PK chunking header: %v", chunking)
+			}
+			fmt.Fprint(w, `{"id":"750A","state":"Open","operation":"query","object":"Account","contentType":"CSV"}`)
+		case "POST " + jobs + "/750A/batch":
+			data, _ := ioutil.ReadAll(r.Body)
+			if string(data) != "SELECT Id, Name FROM Account" {
+				t.Errorf("Unexpected query: %s", data)
+			}
+			w.Header().Set("Content-Type", xmlContentType)
+			fmt.Fprint(w, `<batchInfo><id>751O</id><state>Queued</state></batchInfo>`)
+		case "POST " + jobs + "/750A":
+			fmt.Fprint(w, `{"id":"750A","state":"Closed"}`)
+		case "GET " + jobs + "/750A/batch":
+			fmt.Fprint(w, `{"batchInfo":[{"id":"751O","state":"NotProcessed"},{"id":"7511","state":"Completed"},{"id":"7512","state":"Completed"}]}`)
+		case "GET " + jobs + "/750A/batch/7511/result":
+			w.Header().Set("Content-Type", xmlContentType)
+			fmt.Fprint(w, `<result-list xmlns="http://www.force.com/2009/06/asyncapi/dataload"><result>752A</result><result>752B</result></result-list>`)
+		case "GET " + jobs + "/750A/batch/7512/result":
+			w.Header().Set("Content-Type", xmlContentType)
+			fmt.Fprint(w, `<result-list xmlns="http://www.force.com/2009/06/asyncapi/dataload"><result>752C</result></result-list>`)
+		case "GET " + jobs + "/750A/batch/7511/result/752A":
+			fmt.Fprint(w, "\"Id\",\"Name\"\n\"001A\",\"Acme\"\n")
+		case "GET " + jobs + "/750A/batch/7511/result/752B":
+			fmt.Fprint(w, "\"Id\",\"Name\"\n")
+		case "GET " + jobs + "/750A/batch/7512/result/752C":
+			fmt.Fprint(w, "\"Id\",\"Name\"\n\"001B\",\"Initech\"\n\"001C\",\"Globex\"\n")
+		default:
+			t.Errorf("Unexpected request: %v %v", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	chunking := &PKChunking{ChunkSize: 2, StartRow: "001A"}
+	results, err := forceApi.RunBulkQuery(BulkQuery, &sobjects.Account{}, "SELECT Id, Name FROM Account", BulkContentTypeCSV, chunking)
+	if err != nil {
+		t.Fatalf("Failed to run bulk query: %v", err)
+	}
+	defer results.Close()
+
+	var names []string
+	for results.Next() {
+		account := &sobjects.Account{}
+		if err := results.Decode(account); err != nil {
+			t.Fatalf("Failed to decode record: %v", err)
+		}
+		names = append(names, account.Id+" "+account.Name)
+	}
+	if err := results.Err(); err != nil {
+		t.Fatalf("Failed to read results: %v", err)
+	}
+
+	if fmt.Sprint(names) != "[001A Acme 001B Initech 001C Globex]" {
+		t.Fatalf("Expected the results of every chunk, got %v", names)
+	}
+}
+
+func TestRunBulkQueryAborted(t *testing.T) {
+	defer func(interval time.Duration) { bulkPollInterval = interval }(bulkPollInterval)
+	bulkPollInterval = time.Millisecond
+
+	jobs := fmt.Sprintf(bulkJobsUri, strings.TrimPrefix(testVersion, "v"))
+	var aborted bool
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST " + jobs:
+			fmt.Fprint(w, `{"id":"750A","state":"Open","operation":"query","object":"Account","contentType":"CSV"}`)
+		case "POST " + jobs + "/750A/batch":
+			w.Header().Set("Content-Type", xmlContentType)
+			fmt.Fprint(w, `<batchInfo><id>7511</id><state>Queued</state></batchInfo>`)
+		case "POST " + jobs + "/750A":
+			state := map[string]string{}
+			json.NewDecoder(r.Body).Decode(&state)
+			aborted = state["state"] == "Aborted"
+			fmt.Fprintf(w, `{"id":"750A","state":"%v"}`, state["state"])
+		case "GET " + jobs + "/750A/batch":
+			fmt.Fprint(w, `{"batchInfo":[{"id":"7511","state":"InProgress"}]}`)
+		default:
+			t.Errorf("Unexpected request: %v %v", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := forceApi.RunBulkQueryContext(ctx, BulkQuery, &sobjects.Account{}, "SELECT Id FROM Account", BulkContentTypeCSV, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the wait to time out, got %v", err)
+	}
+	if !aborted {
+		t.Fatal("Expected the job to be aborted")
+	}
+}
+
+func TestBulkQueryResultsJSON(t *testing.T) {
+	jobs := fmt.Sprintf(bulkJobsUri, strings.TrimPrefix(testVersion, "v"))
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case jobs + "/750A/batch/751A/result":
+			fmt.Fprint(w, `["752A"]`)
+		case jobs + "/750A/batch/751A/result/752A":
+			fmt.Fprint(w, `[{"attributes":{"type":"Account"},"Id":"001A","Name":"Acme"},{"attributes":{"type":"Account"},"Id":"001B","Name":"Initech"}]`)
+		default:
+			t.Errorf("Unexpected request: %v %v", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	job := &BulkJob{Id: "750A", ContentType: BulkContentTypeJSON}
+	results := forceApi.GetBulkQueryResults(job, []*BulkBatch{{Id: "751A", State: BatchStateCompleted}})
+	defer results.Close()
+
+	var accounts []*sobjects.Account
+	for results.Next() {
+		account := &sobjects.Account{}
+		if err := results.Decode(account); err != nil {
+			t.Fatalf("Failed to decode record: %v", err)
+		}
+		accounts = append(accounts, account)
+	}
+	if err := results.Err(); err != nil {
+		t.Fatalf("Failed to read results: %v", err)
+	}
+
+	if len(accounts) != 2 || accounts[1].Name != "Initech" {
+		t.Fatalf("Unexpected accounts: %+v", accounts)
+	}
+}
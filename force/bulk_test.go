@@ -0,0 +1,141 @@
+package force
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nimajalali/go-force/sobjects"
+)
+
+func TestBulkJobBatches(t *testing.T) {
+	defer func(interval time.Duration) { bulkPollInterval = interval }(bulkPollInterval)
+	bulkPollInterval = time.Millisecond
+
+	jobs := fmt.Sprintf(bulkJobsUri, strings.TrimPrefix(testVersion, "v"))
+	polls := 0
+	var uploaded string
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(bulkSessionHeader) != "test-access-token" {
+			t.Errorf("Expected the session header on %v", r.URL.Path)
+		}
+
+		switch r.Method + " " + r.URL.Path {
+		case "POST " + jobs:
+			job := map[string]string{}
+			json.NewDecoder(r.Body).Decode(&job)
+			if job["operation"] != "insert" || job["object"] != "Account" || job["contentType"] != "XML" {
+				t.Errorf("Unexpected job: %v", job)
+			}
+			fmt.Fprint(w, `{"id":"750A","state":"Open","operation":"insert","object":"Account","contentType":"XML"}`)
+		case "POST " + jobs + "/750A/batch":
+			if !strings.HasPrefix(r.Header.Get("Content-Type"), xmlContentType) {
+				t.Errorf("Unexpected content type: %v", r.Header.Get("Content-Type"))
+			}
+			data, _ := ioutil.ReadAll(r.Body)
+			uploaded = string(data)
+			w.Header().Set("Content-Type", xmlContentType)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><batchInfo xmlns="http://www.force.com/2009/06/asyncapi/dataload"><id>751A</id><jobId>750A</jobId><state>Queued</state></batchInfo>`)
+		case "POST " + jobs + "/750A":
+			state := map[string]string{}
+			json.NewDecoder(r.Body).Decode(&state)
+			if state["state"] != "Closed" {
+				t.Errorf("Unexpected state change: %v", state)
+			}
+			fmt.Fprint(w, `{"id":"750A","state":"Closed"}`)
+		case "GET " + jobs + "/750A/batch":
+			polls++
+			state := "InProgress"
+			if polls > 1 {
+				state = "Completed"
+			}
+			fmt.Fprintf(w, `{"batchInfo":[{"id":"751A","jobId":"750A","state":"%v"}]}`, state)
+		case "GET " + jobs + "/750A/batch/751A/result":
+			w.Header().Set("Content-Type", xmlContentType)
+			fmt.Fprint(w, `<results xmlns="http://www.force.com/2009/06/asyncapi/dataload">`+
+				`<result><id>001A</id><success>true</success><created>true</created></result>`+
+				`<result><success>false</success><created>false</created><errors><fields>Name</fields><message>Required</message><statusCode>REQUIRED_FIELD_MISSING</statusCode></errors></result>`+
+				`</results>`)
+		default:
+			t.Errorf("Unexpected request: %v %v", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	job, err := forceApi.CreateBulkJob(BulkInsert, &sobjects.Account{}, BulkContentTypeXML)
+	if err != nil {
+		t.Fatalf("Failed to create bulk job: %v", err)
+	}
+
+	acme := sobjects.Account{}
+	acme.Name = "Acme & Co"
+	batch, err := forceApi.AddBulkBatch(job, []sobjects.Account{acme, {}})
+	if err != nil {
+		t.Fatalf("Failed to add batch: %v", err)
+	}
+	if batch.Id != "751A" || batch.State != BatchStateQueued {
+		t.Fatalf("Unexpected batch: %+v", batch)
+	}
+	if !strings.Contains(uploaded, "<sObject><Name>Acme &amp; Co</Name></sObject><sObject></sObject>") {
+		t.Fatalf("Unexpected batch data: %v", uploaded)
+	}
+
+	if _, err := forceApi.CloseBulkJob(job.Id); err != nil {
+		t.Fatalf("Failed to close job: %v", err)
+	}
+	if _, err := forceApi.WaitBulkBatches(job.Id); err != nil {
+		t.Fatalf("Failed to wait for batches: %v", err)
+	}
+
+	results, err := forceApi.GetBulkBatchResults(job, batch.Id)
+	if err != nil {
+		t.Fatalf("Failed to get batch results: %v", err)
+	}
+	if len(results) != 2 || !results[0].Created || results[0].Id != "001A" ||
+		results[1].Success || results[1].Errors[0].StatusCode != "REQUIRED_FIELD_MISSING" {
+		t.Fatalf("Unexpected results: %+v %+v", results[0], results[1])
+	}
+}
+
+func TestBulkError(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"exceptionCode":"InvalidJob","exceptionMessage":"Invalid job id: 750B"}`)
+	}))
+	defer server.Close()
+
+	_, err := forceApi.GetBulkJob("750B")
+	bulkError := &BulkError{}
+	if !errors.As(err, &bulkError) || bulkError.ExceptionCode != "InvalidJob" {
+		t.Fatalf("Expected a BulkError, got: %#v", err)
+	}
+	requestError := &RequestError{}
+	if !errors.As(err, &requestError) || requestError.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected a RequestError, got: %#v", err)
+	}
+	if !hasErrorCode(err, "InvalidJob") || IsRateLimited(err) {
+		t.Fatalf("Misclassified error: %v", err)
+	}
+}
+
+func TestWaitBulkBatchesFailed(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"batchInfo":[{"id":"751A","state":"Completed"},{"id":"751B","state":"Failed","stateMessage":"InvalidBatch"}]}`)
+	}))
+	defer server.Close()
+
+	batches, err := forceApi.WaitBulkBatches("750A")
+	if err == nil || !strings.Contains(err.Error(), "751B: InvalidBatch") {
+		t.Fatalf("Expected the failed batch to be reported, got: %v", err)
+	}
+	if len(batches) != 2 {
+		t.Fatalf("Expected the batches to be returned, got %v", batches)
+	}
+}
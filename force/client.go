@@ -2,11 +2,13 @@ package force
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/nimajalali/go-force/forcejson"
 )
@@ -21,46 +23,59 @@ const (
 // Get issues a GET to the specified path with the given params and put the
 // umarshalled (json) result in the third parameter
 func (forceApi *ForceApi) Get(path string, params url.Values, out interface{}) error {
-	return forceApi.request("GET", path, params, nil, out)
+	return forceApi.GetContext(context.Background(), path, params, out)
+}
+
+// GetContext is like Get but carries a context that controls cancellation and
+// deadlines of the request, including any re-authentication it triggers.
+func (forceApi *ForceApi) GetContext(ctx context.Context, path string, params url.Values, out interface{}) error {
+	return forceApi.request(ctx, "GET", path, params, nil, out)
 }
 
 // Post issues a POST to the specified path with the given params and payload
 // and put the unmarshalled (json) result in the third parameter
 func (forceApi *ForceApi) Post(path string, params url.Values, payload, out interface{}) error {
-	return forceApi.request("POST", path, params, payload, out)
+	return forceApi.PostContext(context.Background(), path, params, payload, out)
+}
+
+// PostContext is like Post but carries a context.
+func (forceApi *ForceApi) PostContext(ctx context.Context, path string, params url.Values, payload, out interface{}) error {
+	return forceApi.request(ctx, "POST", path, params, payload, out)
 }
 
 // Put issues a PUT to the specified path with the given params and payload
 // and put the unmarshalled (json) result in the third parameter
 func (forceApi *ForceApi) Put(path string, params url.Values, payload, out interface{}) error {
-	return forceApi.request("PUT", path, params, payload, out)
+	return forceApi.PutContext(context.Background(), path, params, payload, out)
+}
+
+// PutContext is like Put but carries a context.
+func (forceApi *ForceApi) PutContext(ctx context.Context, path string, params url.Values, payload, out interface{}) error {
+	return forceApi.request(ctx, "PUT", path, params, payload, out)
 }
 
 // Patch issues a PATCH to the specified path with the given params and payload
 // and put the unmarshalled (json) result in the third parameter
 func (forceApi *ForceApi) Patch(path string, params url.Values, payload, out interface{}) error {
-	return forceApi.request("PATCH", path, params, payload, out)
+	return forceApi.PatchContext(context.Background(), path, params, payload, out)
+}
+
+// PatchContext is like Patch but carries a context.
+func (forceApi *ForceApi) PatchContext(ctx context.Context, path string, params url.Values, payload, out interface{}) error {
+	return forceApi.request(ctx, "PATCH", path, params, payload, out)
 }
 
 // Delete issues a DELETE to the specified path with the given payload
 func (forceApi *ForceApi) Delete(path string, params url.Values) error {
-	return forceApi.request("DELETE", path, params, nil, nil)
+	return forceApi.DeleteContext(context.Background(), path, params)
 }
 
-func (forceApi *ForceApi) request(method, path string, params url.Values, payload, out interface{}) error {
-	if err := forceApi.oauth.Validate(); err != nil {
-		return fmt.Errorf("Error creating %v request: %v", method, err)
-	}
-
-	// Build Uri
-	var uri bytes.Buffer
-	uri.WriteString(forceApi.oauth.InstanceUrl)
-	uri.WriteString(path)
-	if params != nil && len(params) != 0 {
-		uri.WriteString("?")
-		uri.WriteString(params.Encode())
-	}
+// DeleteContext is like Delete but carries a context.
+func (forceApi *ForceApi) DeleteContext(ctx context.Context, path string, params url.Values) error {
+	return forceApi.request(ctx, "DELETE", path, params, nil, nil)
+}
 
+func (forceApi *ForceApi) request(ctx context.Context, method, path string, params url.Values, payload, out interface{}) error {
 	// Build body
 	var body io.Reader
 	if payload != nil {
@@ -73,73 +88,217 @@ func (forceApi *ForceApi) request(method, path string, params url.Values, payloa
 		body = bytes.NewReader(jsonBytes)
 	}
 
+	resp, err := forceApi.send(ctx, method, path, params, nil, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Sometimes the force API returns no body, we should catch this early
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("Error reading response bytes: %v", err)
+	}
+	forceApi.traceResponseBody(respBytes)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErrors := ApiErrors{}
+		if forcejson.Unmarshal(respBytes, &apiErrors) == nil && apiErrors.Validate() {
+			return newRequestError(resp, apiErrors, respBytes)
+		}
+
+		return newRequestError(resp, nil, respBytes)
+	}
+
+	// Sometimes no response is expected. For example delete and update.
+	if out == nil {
+		return nil
+	}
+
+	if err := forcejson.Unmarshal(respBytes, out); err != nil {
+		return fmt.Errorf("Unable to unmarshal response to object: %v", err)
+	}
+
+	return nil
+}
+
+// send issues a request with the given body and returns the unread response. The body is sent as
+// json unless header sets another Content-Type. When the session has expired send reauthenticates
+// and retries, and transient failures are retried according to the retry policy, provided body
+// can be rewound. The caller must close the response body.
+func (forceApi *ForceApi) send(ctx context.Context, method, path string, params url.Values, header http.Header, body io.Reader) (*http.Response, error) {
+	if err := forceApi.throttle(ctx); err != nil {
+		return nil, err
+	}
+
+	policy := forceApi.retryPolicy
+	attempts, reauthentications := 0, 0
+	for {
+		attempts++
+		resp, accessToken, err := forceApi.sendOnce(ctx, method, path, params, header, body)
+		if err != nil {
+			if ctx.Err() != nil || accessToken == "" || !policy.retryError(attempts, method) || !rewind(body) {
+				return nil, err
+			}
+			if waitErr := policy.wait(ctx, attempts, 0); waitErr != nil {
+				return nil, waitErr
+			}
+			continue
+		}
+
+		if resp.StatusCode < http.StatusBadRequest {
+			return resp, nil
+		}
+
+		respBytes, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Error reading response bytes: %v", err)
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(respBytes))
+
+		switch {
+		case forceApi.sessionExpired(path, resp.StatusCode, respBytes):
+			// A session that expires again straight after it was renewed won't be fixed by
+			// renewing it once more.
+			if reauthentications >= policy.maxReauthentications() || !rewind(body) {
+				return resp, nil
+			}
+
+			// Don't reauthenticate on behalf of a caller that has given up
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+
+			// Reauthenticate then attempt request again
+			forceApi.traceResponseBody(respBytes)
+			if oauthErr := forceApi.oauth.reauthenticate(ctx, accessToken); oauthErr != nil {
+				return nil, oauthErr
+			}
+			reauthentications++
+			// Reauthenticating doesn't use up an attempt.
+			attempts--
+
+		case policy.retryResponse(attempts, method, resp.StatusCode, respBytes) && rewind(body):
+			forceApi.traceResponseBody(respBytes)
+			if err := policy.wait(ctx, attempts, retryAfter(resp)); err != nil {
+				return nil, err
+			}
+
+		default:
+			return resp, nil
+		}
+	}
+}
+
+// sendOnce makes a single attempt at a request, see send. It returns the access token the request
+// was sent with, which is empty if the request could not be built.
+func (forceApi *ForceApi) sendOnce(ctx context.Context, method, path string, params url.Values, header http.Header, body io.Reader) (*http.Response, string, error) {
+	if err := forceApi.oauth.loadToken(ctx); err != nil {
+		return nil, "", err
+	}
+	if err := forceApi.oauth.Validate(); err != nil {
+		return nil, "", fmt.Errorf("Error creating %v request: %v", method, err)
+	}
+	// The token may be renewed by another request at any point, so one copy is used throughout.
+	token := forceApi.oauth.currentToken()
+
+	// Build Uri. Absolute urls, such as the identity url, are used as they are, provided they're
+	// served by the instance or the identity host, so the access token isn't sent elsewhere.
+	var uri bytes.Buffer
+	if !strings.HasPrefix(path, "https://") && !strings.HasPrefix(path, "http://") {
+		uri.WriteString(token.InstanceUrl)
+	} else if !sameOrigin(path, token.InstanceUrl) && !sameOrigin(path, token.Id) {
+		return nil, "", fmt.Errorf("Error creating %v request: %v is not served by the instance or identity host", method, path)
+	}
+	uri.WriteString(path)
+	if params != nil && len(params) != 0 {
+		uri.WriteString("?")
+		uri.WriteString(params.Encode())
+	}
+
 	// Build Request
-	req, err := http.NewRequest(method, uri.String(), body)
+	req, err := http.NewRequestWithContext(ctx, method, uri.String(), body)
 	if err != nil {
-		return fmt.Errorf("Error creating %v request: %v", method, err)
+		return nil, "", fmt.Errorf("Error creating %v request: %v", method, err)
 	}
 
 	// Add Headers
-	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("User-Agent", forceApi.userAgent)
 	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("Accept", responseType)
-	req.Header.Set("Authorization", fmt.Sprintf("%v %v", "Bearer", forceApi.oauth.AccessToken))
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("%v %v", "Bearer", token.AccessToken))
+	if strings.HasPrefix(path, bulkUriPrefix) {
+		// Bulk API 1.0 takes the session from its own header.
+		req.Header.Set(bulkSessionHeader, token.AccessToken)
+	}
 
 	// Send
 	forceApi.traceRequest(req)
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := forceApi.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("Error sending %v request: %v", method, err)
+		return nil, token.AccessToken, fmt.Errorf("Error sending %v request: %w", method, err)
 	}
-	defer resp.Body.Close()
 	forceApi.traceResponse(resp)
+	forceApi.recordApiUsage(resp.Header)
 
-	// Sometimes the force API returns no body, we should catch this early
-	if resp.StatusCode == http.StatusNoContent {
-		return nil
-	}
+	return resp, token.AccessToken, nil
+}
 
-	respBytes, err := ioutil.ReadAll(resp.Body)
+// sameOrigin reports whether the urls a and b have the same scheme and host.
+func sameOrigin(a, b string) bool {
+	aUrl, err := url.Parse(a)
 	if err != nil {
-		return fmt.Errorf("Error reading response bytes: %v", err)
+		return false
+	}
+	bUrl, err := url.Parse(b)
+	if err != nil {
+		return false
 	}
-	forceApi.traceResponseBody(respBytes)
 
-	// Attempt to parse response into out
-	var objectUnmarshalErr error
-	if out != nil {
-		objectUnmarshalErr = forcejson.Unmarshal(respBytes, out)
-		if objectUnmarshalErr == nil {
-			return nil
-		}
+	return len(aUrl.Host) > 0 && strings.EqualFold(aUrl.Scheme, bUrl.Scheme) && strings.EqualFold(aUrl.Host, bUrl.Host)
+}
+
+// sessionExpired reports whether an error response rejected the session of the request.
+func (forceApi *ForceApi) sessionExpired(path string, statusCode int, respBytes []byte) bool {
+	// Bulk API 1.0 rejects an expired session with a 400.
+	if statusCode != http.StatusUnauthorized &&
+		(statusCode != http.StatusBadRequest || !strings.HasPrefix(path, bulkUriPrefix)) {
+		return false
 	}
 
-	// Attempt to parse response as a force.com api error before returning object unmarshal err
 	apiErrors := ApiErrors{}
-	if marshalErr := forcejson.Unmarshal(respBytes, &apiErrors); marshalErr == nil {
-		if apiErrors.Validate() {
-			// Check if error is oauth token expired
-			if forceApi.oauth.Expired(apiErrors) {
-				// Reauthenticate then attempt query again
-				oauthErr := forceApi.oauth.Authenticate()
-				if oauthErr != nil {
-					return oauthErr
-				}
-
-				return forceApi.request(method, path, params, payload, out)
-			}
+	if forcejson.Unmarshal(respBytes, &apiErrors) == nil && forceApi.oauth.Expired(apiErrors) {
+		return true
+	}
 
-			return apiErrors
-		}
+	return bulkSessionExpired(respBytes)
+}
+
+// responseError returns the error described by a response with an error status, or nil.
+func responseError(resp *http.Response) error {
+	if resp.StatusCode < http.StatusBadRequest {
+		return nil
 	}
 
-	if objectUnmarshalErr != nil {
-		// Not a force.com api error. Just an unmarshalling error.
-		return fmt.Errorf("Unable to unmarshal response to object: %v", objectUnmarshalErr)
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("Error reading response bytes: %v", err)
 	}
 
-	// Sometimes no response is expected. For example delete and update. We still have to make sure an error wasn't returned.
-	return nil
+	apiErrors := ApiErrors{}
+	if err := forcejson.Unmarshal(respBytes, &apiErrors); err == nil && apiErrors.Validate() {
+		return newRequestError(resp, apiErrors, respBytes)
+	}
+
+	return newRequestError(resp, nil, respBytes)
 }
 
 func (forceApi *ForceApi) traceRequest(req *http.Request) {
@@ -0,0 +1,237 @@
+package force
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/nimajalali/go-force/forcejson"
+)
+
+const (
+	sObjectCollectionsUri = resourcesUri + "/composite/sobjects"
+
+	// Maximum number of records the sObject Collections resource accepts in one call.
+	maxCollectionSize = 200
+	// Maximum number of ids the sObject Collections resource retrieves in one call.
+	maxCollectionRetrieveSize = 2000
+)
+
+type sObjectCollectionRequest struct {
+	AllOrNone bool                     `force:"allOrNone"`
+	Records   []map[string]interface{} `force:"records"`
+}
+
+type sObjectCollectionRetrieveRequest struct {
+	Ids    []string `force:"ids"`
+	Fields []string `force:"fields"`
+}
+
+// InsertSObjects creates up to 200 records per call using the sObject Collections resource.
+// Larger slices are split into several calls. The returned responses are in the same order as
+// in. allOrNone rolls back every record of a call when one of them fails; it does not span calls.
+func (forceApi *ForceApi) InsertSObjects(in []SObject, allOrNone bool) ([]*SObjectResponse, error) {
+	return forceApi.InsertSObjectsContext(context.Background(), in, allOrNone)
+}
+
+// InsertSObjectsContext is like InsertSObjects but carries a context.
+func (forceApi *ForceApi) InsertSObjectsContext(ctx context.Context, in []SObject, allOrNone bool) ([]*SObjectResponse, error) {
+	uri := fmt.Sprintf(sObjectCollectionsUri, forceApi.apiVersion)
+
+	return forceApi.sendSObjects(ctx, "POST", uri, in, allOrNone, false)
+}
+
+// UpdateSObjects updates records using the sObject Collections resource. Every record must have
+// its Id set. See InsertSObjects for chunking and allOrNone behavior.
+func (forceApi *ForceApi) UpdateSObjects(in []SObject, allOrNone bool) ([]*SObjectResponse, error) {
+	return forceApi.UpdateSObjectsContext(context.Background(), in, allOrNone)
+}
+
+// UpdateSObjectsContext is like UpdateSObjects but carries a context.
+func (forceApi *ForceApi) UpdateSObjectsContext(ctx context.Context, in []SObject, allOrNone bool) ([]*SObjectResponse, error) {
+	uri := fmt.Sprintf(sObjectCollectionsUri, forceApi.apiVersion)
+
+	return forceApi.sendSObjects(ctx, "PATCH", uri, in, allOrNone, true)
+}
+
+// UpsertSObjectsByExternalId upserts records matched on their ExternalIdApiName field. Every
+// record must be of the same SObject type. SObjectResponse.Created reports whether a record
+// was inserted. See InsertSObjects for chunking and allOrNone behavior.
+func (forceApi *ForceApi) UpsertSObjectsByExternalId(in []SObject, allOrNone bool) ([]*SObjectResponse, error) {
+	return forceApi.UpsertSObjectsByExternalIdContext(context.Background(), in, allOrNone)
+}
+
+// UpsertSObjectsByExternalIdContext is like UpsertSObjectsByExternalId but carries a context.
+func (forceApi *ForceApi) UpsertSObjectsByExternalIdContext(ctx context.Context, in []SObject, allOrNone bool) ([]*SObjectResponse, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+
+	apiName, externalId := in[0].ApiName(), in[0].ExternalIdApiName()
+	for _, record := range in {
+		if record.ApiName() != apiName || record.ExternalIdApiName() != externalId {
+			return nil, fmt.Errorf("Unable to upsert mixed sobjects %v.%v and %v.%v", apiName, externalId,
+				record.ApiName(), record.ExternalIdApiName())
+		}
+	}
+
+	uri := fmt.Sprintf("%v/%v/%v", fmt.Sprintf(sObjectCollectionsUri, forceApi.apiVersion), apiName, externalId)
+
+	return forceApi.sendSObjects(ctx, "PATCH", uri, in, allOrNone, false)
+}
+
+// DeleteSObjects deletes records by id using the sObject Collections resource. See
+// InsertSObjects for chunking and allOrNone behavior.
+func (forceApi *ForceApi) DeleteSObjects(ids []string, allOrNone bool) ([]*SObjectResponse, error) {
+	return forceApi.DeleteSObjectsContext(context.Background(), ids, allOrNone)
+}
+
+// DeleteSObjectsContext is like DeleteSObjects but carries a context.
+func (forceApi *ForceApi) DeleteSObjectsContext(ctx context.Context, ids []string, allOrNone bool) ([]*SObjectResponse, error) {
+	uri := fmt.Sprintf(sObjectCollectionsUri, forceApi.apiVersion)
+
+	resp := make([]*SObjectResponse, 0, len(ids))
+	for start := 0; start < len(ids); start += maxCollectionSize {
+		end := chunkEnd(start, maxCollectionSize, len(ids))
+
+		params := url.Values{
+			"ids":       {strings.Join(ids[start:end], ",")},
+			"allOrNone": {fmt.Sprint(allOrNone)},
+		}
+
+		chunk := []*SObjectResponse{}
+		if err := forceApi.request(ctx, "DELETE", uri, params, nil, &chunk); err != nil {
+			return resp, err
+		}
+		resp = append(resp, chunk...)
+	}
+
+	return resp, nil
+}
+
+// GetSObjects retrieves records by id using the sObject Collections resource. out must be a
+// pointer to a slice of an SObject type, e.g. *[]*sobjects.Account, and is filled in the order
+// of ids. Ids that were not found are left as nil or zero values. When no fields are given all
+// fields of the sobject are retrieved.
+func (forceApi *ForceApi) GetSObjects(ids []string, fields []string, out interface{}) error {
+	return forceApi.GetSObjectsContext(context.Background(), ids, fields, out)
+}
+
+// GetSObjectsContext is like GetSObjects but carries a context.
+func (forceApi *ForceApi) GetSObjectsContext(ctx context.Context, ids []string, fields []string, out interface{}) error {
+	outValue := reflect.ValueOf(out)
+	if outValue.Kind() != reflect.Ptr || outValue.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("GetSObjects requires a pointer to a slice, got %T", out)
+	}
+	sliceValue := outValue.Elem()
+
+	in, err := sliceSObject(sliceValue.Type())
+	if err != nil {
+		return err
+	}
+
+	if len(fields) == 0 {
+		desc, err := forceApi.DescribeSObjectContext(ctx, in)
+		if err != nil {
+			return err
+		}
+		fields = strings.Split(desc.AllFields, ", ")
+	}
+
+	uri := fmt.Sprintf("%v/%v", fmt.Sprintf(sObjectCollectionsUri, forceApi.apiVersion), in.ApiName())
+
+	for start := 0; start < len(ids); start += maxCollectionRetrieveSize {
+		end := chunkEnd(start, maxCollectionRetrieveSize, len(ids))
+
+		payload := &sObjectCollectionRetrieveRequest{
+			Ids:    ids[start:end],
+			Fields: fields,
+		}
+
+		chunk := reflect.New(sliceValue.Type())
+		if err := forceApi.request(ctx, "POST", uri, nil, payload, chunk.Interface()); err != nil {
+			return err
+		}
+		sliceValue.Set(reflect.AppendSlice(sliceValue, chunk.Elem()))
+	}
+
+	return nil
+}
+
+func (forceApi *ForceApi) sendSObjects(ctx context.Context, method, uri string, in []SObject, allOrNone, requireId bool) ([]*SObjectResponse, error) {
+	resp := make([]*SObjectResponse, 0, len(in))
+	for start := 0; start < len(in); start += maxCollectionSize {
+		end := chunkEnd(start, maxCollectionSize, len(in))
+
+		payload := &sObjectCollectionRequest{
+			AllOrNone: allOrNone,
+			Records:   make([]map[string]interface{}, 0, end-start),
+		}
+		for _, record := range in[start:end] {
+			fields, err := sObjectRecord(record)
+			if err != nil {
+				return resp, err
+			}
+			if requireId && fields["Id"] == nil {
+				return resp, fmt.Errorf("Unable to update %v without an Id", record.ApiName())
+			}
+			payload.Records = append(payload.Records, fields)
+		}
+
+		chunk := []*SObjectResponse{}
+		if err := forceApi.request(ctx, method, uri, nil, payload, &chunk); err != nil {
+			return resp, err
+		}
+		resp = append(resp, chunk...)
+	}
+
+	return resp, nil
+}
+
+// sObjectRecord converts in to a map of its fields with the type attribute the composite
+// resources require to tell records apart.
+func sObjectRecord(in SObject) (map[string]interface{}, error) {
+	jsonBytes, err := forcejson.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("Error marshaling %v: %v", in.ApiName(), err)
+	}
+
+	fields := map[string]interface{}{}
+	decoder := forcejson.NewDecoder(bytes.NewReader(jsonBytes))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, fmt.Errorf("Error marshaling %v: %v", in.ApiName(), err)
+	}
+
+	fields["attributes"] = map[string]interface{}{"type": in.ApiName()}
+
+	return fields, nil
+}
+
+// sliceSObject returns an empty record of the SObject type held by a slice of type t, which is
+// used to learn the name of the sobject.
+func sliceSObject(t reflect.Type) (SObject, error) {
+	elemType := t.Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	in, ok := reflect.New(elemType).Interface().(SObject)
+	if !ok {
+		return nil, fmt.Errorf("Expected a slice of SObject, got %v", t)
+	}
+
+	return in, nil
+}
+
+// chunkEnd returns the end of the chunk of at most size elements starting at start.
+func chunkEnd(start, size, length int) int {
+	if start+size < length {
+		return start + size
+	}
+
+	return length
+}
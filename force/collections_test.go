@@ -0,0 +1,161 @@
+package force
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/nimajalali/go-force/sobjects"
+)
+
+type ExternalAccount struct {
+	sobjects.Account
+	ExternalId string `force:"External_Id__c,omitempty"`
+}
+
+func (a *ExternalAccount) ExternalIdApiName() string {
+	return "External_Id__c"
+}
+
+func TestInsertSObjects(t *testing.T) {
+	collections := fmt.Sprintf(sObjectCollectionsUri, testVersion)
+	var chunks []int
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != collections {
+			t.Errorf("Unexpected request: %v %v", r.Method, r.URL.Path)
+		}
+
+		payload := struct {
+			AllOrNone bool                     `json:"allOrNone"`
+			Records   []map[string]interface{} `json:"records"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("Unable to decode payload: %v", err)
+		}
+		if !payload.AllOrNone {
+			t.Errorf("Expected allOrNone to be sent")
+		}
+		chunks = append(chunks, len(payload.Records))
+
+		results := make([]string, len(payload.Records))
+		for i, record := range payload.Records {
+			if record["attributes"].(map[string]interface{})["type"] != "Account" {
+				t.Errorf("Expected type attribute on record: %v", record)
+			}
+			results[i] = fmt.Sprintf(`{"id":"001%v","success":true,"errors":[]}`, record["Name"])
+		}
+		fmt.Fprintf(w, "[%v]", strings.Join(results, ","))
+	}))
+	defer server.Close()
+
+	in := make([]SObject, 250)
+	for i := range in {
+		acc := &sobjects.Account{}
+		acc.Name = fmt.Sprint(i)
+		in[i] = acc
+	}
+
+	resp, err := forceApi.InsertSObjects(in, true)
+	if err != nil {
+		t.Fatalf("Failed to insert sobjects: %v", err)
+	}
+
+	if fmt.Sprint(chunks) != "[200 50]" {
+		t.Fatalf("Expected records to be split in chunks of 200, got %v", chunks)
+	}
+	if len(resp) != len(in) {
+		t.Fatalf("Expected %v responses, got %v", len(in), len(resp))
+	}
+	for i, r := range resp {
+		if !r.Success || r.Id != fmt.Sprintf("001%v", i) {
+			t.Fatalf("Response %v does not match its input: %+v", i, r)
+		}
+	}
+}
+
+func TestUpdateSObjectsRequiresId(t *testing.T) {
+	forceApi, server := createTestServer(t, http.NotFoundHandler())
+	defer server.Close()
+
+	if _, err := forceApi.UpdateSObjects([]SObject{&sobjects.Account{}}, false); err == nil {
+		t.Fatal("Expected an error updating a record without an Id")
+	}
+}
+
+func TestUpsertSObjectsByExternalId(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expected := fmt.Sprintf(sObjectCollectionsUri, testVersion) + "/Account/External_Id__c"
+		if r.Method != "PATCH" || r.URL.Path != expected {
+			t.Errorf("Unexpected request: %v %v", r.Method, r.URL.Path)
+		}
+		fmt.Fprint(w, `[{"id":"001A","success":true,"created":true,"errors":[]},`+
+			`{"success":false,"errors":[{"statusCode":"DUPLICATE_EXTERNAL_ID","message":"dupe","fields":["External_Id__c"]}]}]`)
+	}))
+	defer server.Close()
+
+	resp, err := forceApi.UpsertSObjectsByExternalId([]SObject{
+		&ExternalAccount{ExternalId: "a"},
+		&ExternalAccount{ExternalId: "b"},
+	}, false)
+	if err != nil {
+		t.Fatalf("Failed to upsert sobjects: %v", err)
+	}
+
+	if !resp[0].Created || resp[1].Success || resp[1].Errors[0].StatusCode != "DUPLICATE_EXTERNAL_ID" {
+		t.Fatalf("Unexpected responses: %+v %+v", resp[0], resp[1])
+	}
+}
+
+func TestDeleteSObjects(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" || r.URL.Query().Get("ids") != "001A,001B" || r.URL.Query().Get("allOrNone") != "false" {
+			t.Errorf("Unexpected request: %v %v", r.Method, r.URL)
+		}
+		fmt.Fprint(w, `[{"id":"001A","success":true,"errors":[]},{"id":"001B","success":true,"errors":[]}]`)
+	}))
+	defer server.Close()
+
+	resp, err := forceApi.DeleteSObjects([]string{"001A", "001B"}, false)
+	if err != nil {
+		t.Fatalf("Failed to delete sobjects: %v", err)
+	}
+	if len(resp) != 2 || resp[1].Id != "001B" {
+		t.Fatalf("Unexpected responses: %+v", resp)
+	}
+}
+
+func TestGetSObjects(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expected := fmt.Sprintf(sObjectCollectionsUri, testVersion) + "/Account"
+		if r.Method != "POST" || r.URL.Path != expected {
+			t.Errorf("Unexpected request: %v %v", r.Method, r.URL.Path)
+		}
+		fmt.Fprint(w, `[{"Id":"001A","Name":"a"},null]`)
+	}))
+	defer server.Close()
+
+	accounts := []*sobjects.Account{}
+	err := forceApi.GetSObjects([]string{"001A", "001B"}, []string{"Id", "Name"}, &accounts)
+	if err != nil {
+		t.Fatalf("Failed to get sobjects: %v", err)
+	}
+	if len(accounts) != 2 || accounts[0].Name != "a" || accounts[1] != nil {
+		t.Fatalf("Unexpected accounts: %+v", accounts)
+	}
+}
+
+func TestSObjectsApiErrors(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `[{"message":"Exceeded max limit","errorCode":"EXCEEDED_ID_LIMIT"}]`)
+	}))
+	defer server.Close()
+
+	_, err := forceApi.DeleteSObjects([]string{"001A"}, false)
+	if apiErrors := (ApiErrors{}); !errors.As(err, &apiErrors) {
+		t.Fatalf("Expected ApiErrors, got: %#v", err)
+	}
+}
@@ -0,0 +1,278 @@
+package force
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/nimajalali/go-force/forcejson"
+)
+
+const (
+	compositeUri = resourcesUri + "/composite"
+
+	// Maximum number of subrequests the composite resource accepts in one call.
+	maxCompositeSize = 25
+)
+
+// Composite builds a request for the composite resource, which executes a series of
+// subrequests in a single call. Later subrequests can use the output of earlier ones through
+// reference ids, e.g. setting a Contact's AccountId field to "@{newAccount.id}".
+//
+//	composite := forceApi.Composite(true)
+//	accountResp := composite.Insert("newAccount", account)
+//	contactResp := composite.Insert("newContact", &Contact{AccountId: "@{newAccount.id}"})
+//	if _, err := composite.Execute(); err != nil {
+//		...
+//	}
+type Composite struct {
+	forceApi  *ForceApi
+	allOrNone bool
+
+	subrequests []*compositeSubrequest
+	outs        []interface{}
+}
+
+type compositeRequest struct {
+	AllOrNone   bool                   `force:"allOrNone"`
+	Subrequests []*compositeSubrequest `force:"compositeRequest"`
+}
+
+type compositeSubrequest struct {
+	Method      string      `force:"method"`
+	Url         string      `force:"url"`
+	ReferenceId string      `force:"referenceId"`
+	Body        interface{} `force:"body,omitempty"`
+}
+
+// CompositeResponse is the result of executing a Composite request. Responses are in the order
+// the subrequests were added.
+type CompositeResponse struct {
+	Responses []*CompositeSubresponse `force:"compositeResponse"`
+}
+
+// CompositeSubresponse is the result of a single subrequest.
+type CompositeSubresponse struct {
+	Body           forcejson.RawMessage `force:"body"`
+	HttpHeaders    map[string]string    `force:"httpHeaders"`
+	HttpStatusCode int                  `force:"httpStatusCode"`
+	ReferenceId    string               `force:"referenceId"`
+}
+
+// SubrequestError holds the errors of a failed subrequest, at Index in the order the subrequests
+// were added.
+type SubrequestError struct {
+	Index       int
+	ReferenceId string
+	StatusCode  int
+	Errors      ApiErrors
+}
+
+func (e *SubrequestError) Error() string {
+	name := e.ReferenceId
+	if len(name) == 0 {
+		name = fmt.Sprint(e.Index)
+	}
+
+	return fmt.Sprintf("Subrequest %v: %v %v: %v", name, e.StatusCode, http.StatusText(e.StatusCode),
+		strings.Join(strings.Split(e.Errors.String(), "\n"), "; "))
+}
+
+// Unwrap returns the api errors, so that errors.As can extract them.
+func (e *SubrequestError) Unwrap() error {
+	return e.Errors
+}
+
+// SubrequestErrors holds an error for every failed subrequest. errors.As extracts the api errors
+// of all of them as ApiErrors.
+type SubrequestErrors []*SubrequestError
+
+func (e SubrequestErrors) Error() string {
+	s := make([]string, len(e))
+	for i, err := range e {
+		s[i] = err.Error()
+	}
+
+	return strings.Join(s, "\n")
+}
+
+// Unwrap returns the api errors of every failed subrequest.
+func (e SubrequestErrors) Unwrap() error {
+	var apiErrors ApiErrors
+	for _, err := range e {
+		apiErrors = append(apiErrors, err.Errors...)
+	}
+
+	return apiErrors
+}
+
+// Composite returns an empty Composite request. When allOrNone is true every subrequest is
+// rolled back if one of them fails.
+func (forceApi *ForceApi) Composite(allOrNone bool) *Composite {
+	return &Composite{
+		forceApi:  forceApi,
+		allOrNone: allOrNone,
+	}
+}
+
+// Insert queues the creation of in. The returned response is filled when the request is
+// executed.
+func (composite *Composite) Insert(referenceId string, in SObject) *SObjectResponse {
+	resp := &SObjectResponse{}
+	composite.add("POST", sObjectUri(composite.forceApi, in, ""), referenceId, in, resp)
+
+	return resp
+}
+
+// Update queues an update of the record with the given id, which may be a reference such as
+// "@{newAccount.id}".
+func (composite *Composite) Update(referenceId, id string, in SObject) {
+	composite.add("PATCH", sObjectUri(composite.forceApi, in, id), referenceId, in, nil)
+}
+
+// Delete queues the deletion of the record with the given id.
+func (composite *Composite) Delete(referenceId, id string, in SObject) {
+	composite.add("DELETE", sObjectUri(composite.forceApi, in, id), referenceId, nil, nil)
+}
+
+// Get queues the retrieval of the record with the given id into out.
+func (composite *Composite) Get(referenceId, id string, fields []string, out SObject) {
+	uri := sObjectUri(composite.forceApi, out, id)
+	if len(fields) > 0 {
+		uri += "?" + url.Values{"fields": {strings.Join(fields, ",")}}.Encode()
+	}
+
+	composite.add("GET", uri, referenceId, nil, out)
+}
+
+// Query queues a SOQL query whose response is unmarshalled into out.
+func (composite *Composite) Query(referenceId, query string, out interface{}) {
+	uri := composite.forceApi.apiResources[queryKey] + "?" + url.Values{"q": {query}}.Encode()
+
+	composite.add("GET", uri, referenceId, nil, out)
+}
+
+// Execute sends the queued subrequests. Successful subresponses are unmarshalled into the outputs
+// given when the subrequests were queued. If any subrequest failed the returned error is a
+// SubrequestErrors, holding the reference id, status and errors of every failed subrequest; the
+// CompositeResponse is returned either way so each subresponse can be inspected. With allOrNone,
+// subrequests rolled back because another one failed are among the failed ones.
+func (composite *Composite) Execute() (*CompositeResponse, error) {
+	return composite.ExecuteContext(context.Background())
+}
+
+// ExecuteContext is like Execute but carries a context.
+func (composite *Composite) ExecuteContext(ctx context.Context) (*CompositeResponse, error) {
+	if len(composite.subrequests) > maxCompositeSize {
+		return nil, fmt.Errorf("Composite requests are limited to %v subrequests, got %v",
+			maxCompositeSize, len(composite.subrequests))
+	}
+
+	uri := fmt.Sprintf(compositeUri, composite.forceApi.apiVersion)
+	payload := &compositeRequest{
+		AllOrNone:   composite.allOrNone,
+		Subrequests: composite.subrequests,
+	}
+
+	resp := &CompositeResponse{}
+	if err := composite.forceApi.PostContext(ctx, uri, nil, payload, resp); err != nil {
+		return nil, err
+	}
+
+	if err := decodeSubresponses(resp.Responses, composite.outs); err != nil {
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+// Errors returns the errors of a failed subresponse, or nil if it succeeded.
+func (subresponse *CompositeSubresponse) Errors() ApiErrors {
+	return subresponseErrors(subresponse.HttpStatusCode, subresponse.Body)
+}
+
+// Decode unmarshals the body of the subresponse into out.
+func (subresponse *CompositeSubresponse) Decode(out interface{}) error {
+	return decodeSubresponse(subresponse.Body, out)
+}
+
+func (composite *Composite) add(method, uri, referenceId string, body, out interface{}) {
+	composite.subrequests = append(composite.subrequests, &compositeSubrequest{
+		Method:      method,
+		Url:         uri,
+		ReferenceId: referenceId,
+		Body:        body,
+	})
+	composite.outs = append(composite.outs, out)
+}
+
+// sObjectUri returns the uri of the sobject resource of in, or of the record with the given id.
+func sObjectUri(forceApi *ForceApi, in SObject, id string) string {
+	metaData := forceApi.sObjectMetaData(in.ApiName())
+	if metaData == nil {
+		// Fall back on the documented layout for objects the api did not list.
+		uri := fmt.Sprintf(resourcesUri+"/%v/%v", forceApi.apiVersion, sObjectsKey, in.ApiName())
+		if len(id) > 0 {
+			uri += "/" + id
+		}
+		return uri
+	}
+
+	if len(id) == 0 {
+		return metaData.URLs[sObjectKey]
+	}
+
+	return strings.Replace(metaData.URLs[rowTemplateKey], idKey, id, 1)
+}
+
+func subresponseErrors(statusCode int, body forcejson.RawMessage) ApiErrors {
+	if statusCode < 400 {
+		return nil
+	}
+
+	apiErrors := ApiErrors{}
+	if err := forcejson.Unmarshal(body, &apiErrors); err != nil {
+		return ApiErrors{{Message: string(body)}}
+	}
+
+	return apiErrors
+}
+
+func decodeSubresponse(body forcejson.RawMessage, out interface{}) error {
+	if len(body) == 0 {
+		return nil
+	}
+
+	return forcejson.Unmarshal(body, out)
+}
+
+// decodeSubresponses unmarshals the body of each successful subresponse into the matching out and
+// collects the errors of the failed ones.
+func decodeSubresponses(responses []*CompositeSubresponse, outs []interface{}) error {
+	var failed SubrequestErrors
+	for i, subresponse := range responses {
+		if apiErrors := subresponse.Errors(); apiErrors != nil {
+			failed = append(failed, &SubrequestError{
+				Index:       i,
+				ReferenceId: subresponse.ReferenceId,
+				StatusCode:  subresponse.HttpStatusCode,
+				Errors:      apiErrors,
+			})
+			continue
+		}
+
+		if i < len(outs) && outs[i] != nil {
+			if err := subresponse.Decode(outs[i]); err != nil {
+				return fmt.Errorf("Unable to unmarshal subresponse %v: %v", subresponse.ReferenceId, err)
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return failed
+	}
+
+	return nil
+}
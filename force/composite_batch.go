@@ -0,0 +1,404 @@
+package force
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/nimajalali/go-force/forcejson"
+)
+
+const (
+	compositeBatchUri = compositeUri + "/batch"
+	sObjectTreeUri    = compositeUri + "/tree/%v"
+
+	// Maximum number of records, across all levels, the sObject Tree resource accepts in one call.
+	maxSObjectTreeSize = 200
+)
+
+// CompositeBatch builds a request for the composite batch resource, which executes up to 25
+// independent subrequests in a single call. Unlike Composite, subrequests can't reference each
+// other and are not rolled back together.
+type CompositeBatch struct {
+	forceApi    *ForceApi
+	haltOnError bool
+
+	subrequests []*compositeBatchSubrequest
+	outs        []interface{}
+}
+
+type compositeBatchRequest struct {
+	HaltOnError bool                        `force:"haltOnError"`
+	Subrequests []*compositeBatchSubrequest `force:"batchRequests"`
+}
+
+type compositeBatchSubrequest struct {
+	Method    string      `force:"method"`
+	Url       string      `force:"url"`
+	RichInput interface{} `force:"richInput,omitempty"`
+}
+
+// CompositeBatchResponse is the result of executing a CompositeBatch request. Results are in the
+// order the subrequests were added.
+type CompositeBatchResponse struct {
+	HasErrors bool                    `force:"hasErrors"`
+	Results   []*CompositeBatchResult `force:"results"`
+}
+
+// CompositeBatchResult is the result of a single batch subrequest.
+type CompositeBatchResult struct {
+	StatusCode int                  `force:"statusCode"`
+	Result     forcejson.RawMessage `force:"result"`
+}
+
+// CompositeBatch returns an empty CompositeBatch request. When haltOnError is true the
+// subrequests following a failed one are not executed.
+func (forceApi *ForceApi) CompositeBatch(haltOnError bool) *CompositeBatch {
+	return &CompositeBatch{
+		forceApi:    forceApi,
+		haltOnError: haltOnError,
+	}
+}
+
+// Insert queues the creation of in. The returned response is filled when the request is
+// executed.
+func (batch *CompositeBatch) Insert(in SObject) *SObjectResponse {
+	resp := &SObjectResponse{}
+	batch.add("POST", sObjectUri(batch.forceApi, in, ""), in, resp)
+
+	return resp
+}
+
+// Update queues an update of the record with the given id.
+func (batch *CompositeBatch) Update(id string, in SObject) {
+	batch.add("PATCH", sObjectUri(batch.forceApi, in, id), in, nil)
+}
+
+// Delete queues the deletion of the record with the given id.
+func (batch *CompositeBatch) Delete(id string, in SObject) {
+	batch.add("DELETE", sObjectUri(batch.forceApi, in, id), nil, nil)
+}
+
+// Get queues the retrieval of the record with the given id into out.
+func (batch *CompositeBatch) Get(id string, fields []string, out SObject) {
+	uri := sObjectUri(batch.forceApi, out, id)
+	if len(fields) > 0 {
+		uri += "?" + url.Values{"fields": {strings.Join(fields, ",")}}.Encode()
+	}
+
+	batch.add("GET", uri, nil, out)
+}
+
+// Query queues a SOQL query whose response is unmarshalled into out.
+func (batch *CompositeBatch) Query(query string, out interface{}) {
+	uri := batch.forceApi.apiResources[queryKey] + "?" + url.Values{"q": {query}}.Encode()
+
+	batch.add("GET", uri, nil, out)
+}
+
+// Execute sends the queued subrequests. Successful results are unmarshalled into the outputs given
+// when the subrequests were queued. If any subrequest failed the returned error is a
+// SubrequestErrors, holding the index, status and errors of every failed subrequest; the
+// CompositeBatchResponse is returned either way so each result can be inspected.
+func (batch *CompositeBatch) Execute() (*CompositeBatchResponse, error) {
+	return batch.ExecuteContext(context.Background())
+}
+
+// ExecuteContext is like Execute but carries a context.
+func (batch *CompositeBatch) ExecuteContext(ctx context.Context) (*CompositeBatchResponse, error) {
+	if len(batch.subrequests) > maxCompositeSize {
+		return nil, fmt.Errorf("Composite batch requests are limited to %v subrequests, got %v",
+			maxCompositeSize, len(batch.subrequests))
+	}
+
+	uri := fmt.Sprintf(compositeBatchUri, batch.forceApi.apiVersion)
+	payload := &compositeBatchRequest{
+		HaltOnError: batch.haltOnError,
+		Subrequests: batch.subrequests,
+	}
+
+	resp := &CompositeBatchResponse{}
+	if err := batch.forceApi.PostContext(ctx, uri, nil, payload, resp); err != nil {
+		return nil, err
+	}
+
+	var failed SubrequestErrors
+	for i, result := range resp.Results {
+		if apiErrors := result.Errors(); apiErrors != nil {
+			failed = append(failed, &SubrequestError{Index: i, StatusCode: result.StatusCode, Errors: apiErrors})
+			continue
+		}
+
+		if i < len(batch.outs) && batch.outs[i] != nil {
+			if err := result.Decode(batch.outs[i]); err != nil {
+				return resp, fmt.Errorf("Unable to unmarshal batch result %v: %v", i, err)
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return resp, failed
+	}
+
+	return resp, nil
+}
+
+// Errors returns the errors of a failed result, or nil if it succeeded.
+func (result *CompositeBatchResult) Errors() ApiErrors {
+	return subresponseErrors(result.StatusCode, result.Result)
+}
+
+// Decode unmarshals the result into out.
+func (result *CompositeBatchResult) Decode(out interface{}) error {
+	return decodeSubresponse(result.Result, out)
+}
+
+func (batch *CompositeBatch) add(method, uri string, body, out interface{}) {
+	// Batch subrequest urls are relative to /services/data.
+	uri = strings.TrimPrefix(uri, "/services/data/")
+
+	batch.subrequests = append(batch.subrequests, &compositeBatchSubrequest{
+		Method:    method,
+		Url:       uri,
+		RichInput: body,
+	})
+	batch.outs = append(batch.outs, out)
+}
+
+// SObjectTreeResponse is the result of inserting record trees.
+type SObjectTreeResponse struct {
+	HasErrors bool                 `force:"hasErrors"`
+	Results   []*SObjectTreeResult `force:"results"`
+}
+
+// SObjectTreeResult holds the id generated for the record with the given reference id, or the
+// errors that prevented its creation.
+type SObjectTreeResult struct {
+	ReferenceId string    `force:"referenceId"`
+	Id          string    `force:"id,omitempty"`
+	Errors      ApiErrors `force:"errors,omitempty"`
+}
+
+// Ids returns the generated record ids keyed by reference id.
+func (resp *SObjectTreeResponse) Ids() map[string]string {
+	ids := make(map[string]string, len(resp.Results))
+	for _, result := range resp.Results {
+		if len(result.Id) > 0 {
+			ids[result.ReferenceId] = result.Id
+		}
+	}
+
+	return ids
+}
+
+// InsertSObjectTree creates the given records, all of the same type, together with their child
+// records using the sObject Tree resource. Children are read from slice fields of SObject values
+// whose force name is the ChildRelationship.RelationshipName, e.g.
+//
+//	type Account struct {
+//		sobjects.Account
+//		Contacts []*Contact `force:"Contacts,omitempty"`
+//	}
+//
+// Each record is identified by Attributes.ReferenceId. Records without one are given the
+// reference ids ref1, ref2, ... after their position in depth first order, skipping ids given to
+// other records. Up to 200 records are accepted in total and either all of them are created or
+// none are.
+func (forceApi *ForceApi) InsertSObjectTree(in []SObject) (*SObjectTreeResponse, error) {
+	return forceApi.InsertSObjectTreeContext(context.Background(), in)
+}
+
+// InsertSObjectTreeContext is like InsertSObjectTree but carries a context.
+func (forceApi *ForceApi) InsertSObjectTreeContext(ctx context.Context, in []SObject) (*SObjectTreeResponse, error) {
+	if len(in) == 0 {
+		return &SObjectTreeResponse{}, nil
+	}
+
+	builder := &sObjectTreeBuilder{
+		referenceIds: make(map[string]bool),
+		unreferenced: make(map[int]map[string]interface{}),
+	}
+	records := make([]map[string]interface{}, 0, len(in))
+	for _, record := range in {
+		if record.ApiName() != in[0].ApiName() {
+			return nil, fmt.Errorf("Unable to insert mixed sobject trees %v and %v", in[0].ApiName(), record.ApiName())
+		}
+
+		fields, err := builder.record(record)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, fields)
+	}
+	builder.generateReferenceIds()
+
+	if builder.count > maxSObjectTreeSize {
+		return nil, fmt.Errorf("sObject tree requests are limited to %v records, got %v", maxSObjectTreeSize, builder.count)
+	}
+
+	uri := fmt.Sprintf(sObjectTreeUri, forceApi.apiVersion, in[0].ApiName())
+	payload := map[string]interface{}{"records": records}
+
+	resp := &SObjectTreeResponse{}
+	if err := forceApi.PostContext(ctx, uri, nil, payload, resp); err != nil {
+		// Records that fail are reported in the regular response body, with an error status.
+		var requestError *RequestError
+		if !errors.As(err, &requestError) || forcejson.Unmarshal(requestError.Body, resp) != nil || !resp.HasErrors {
+			return nil, err
+		}
+	}
+
+	if resp.HasErrors {
+		var failed ApiErrors
+		for _, result := range resp.Results {
+			failed = append(failed, result.Errors...)
+		}
+		return resp, failed
+	}
+
+	return resp, nil
+}
+
+type sObjectTreeBuilder struct {
+	count int
+	// Reference ids in use, and the attributes of records still to be given one, by position.
+	referenceIds map[string]bool
+	unreferenced map[int]map[string]interface{}
+}
+
+// record converts in and its children to the nested format of the sObject Tree resource.
+func (builder *sObjectTreeBuilder) record(in SObject) (map[string]interface{}, error) {
+	fields, err := sObjectRecord(in)
+	if err != nil {
+		return nil, err
+	}
+
+	builder.count++
+	attributes := fields["attributes"].(map[string]interface{})
+
+	// Keep the caller's reference id, which sObjectRecord replaced along with the other attributes.
+	value := reflect.Indirect(reflect.ValueOf(in))
+	if referenceId := sObjectReferenceId(value); len(referenceId) > 0 {
+		attributes["referenceId"] = referenceId
+		builder.referenceIds[referenceId] = true
+	} else {
+		builder.unreferenced[builder.count] = attributes
+	}
+
+	for _, relationship := range sObjectChildren(value) {
+		delete(fields, relationship.name)
+		if len(relationship.records) == 0 {
+			continue
+		}
+
+		childRecords := make([]map[string]interface{}, 0, len(relationship.records))
+		for _, child := range relationship.records {
+			childFields, err := builder.record(child)
+			if err != nil {
+				return nil, err
+			}
+			childRecords = append(childRecords, childFields)
+		}
+		fields[relationship.name] = map[string]interface{}{"records": childRecords}
+	}
+
+	return fields, nil
+}
+
+// generateReferenceIds gives the records without a reference id one, once the reference ids of
+// all the records are known.
+func (builder *sObjectTreeBuilder) generateReferenceIds() {
+	n := 0
+	for position := 1; position <= builder.count; position++ {
+		attributes := builder.unreferenced[position]
+		if attributes == nil {
+			continue
+		}
+
+		if n < position {
+			n = position
+		}
+		for builder.referenceIds[fmt.Sprintf("ref%v", n)] {
+			n++
+		}
+		referenceId := fmt.Sprintf("ref%v", n)
+		attributes["referenceId"] = referenceId
+		builder.referenceIds[referenceId] = true
+	}
+}
+
+// sObjectReferenceId returns the Attributes.ReferenceId of the struct v, if it has one.
+func sObjectReferenceId(v reflect.Value) string {
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	attrs := v.FieldByName("Attributes")
+	if attrs.Kind() != reflect.Struct {
+		return ""
+	}
+	if referenceId := attrs.FieldByName("ReferenceId"); referenceId.Kind() == reflect.String {
+		return referenceId.String()
+	}
+
+	return ""
+}
+
+var sObjectType = reflect.TypeOf((*SObject)(nil)).Elem()
+
+type sObjectChildRelationship struct {
+	name    string
+	records []SObject
+}
+
+// sObjectChildren returns the SObject values held in slice fields of the struct v, named after
+// the force name of the field, in field order.
+func sObjectChildren(v reflect.Value) []sObjectChildRelationship {
+	var relationships []sObjectChildRelationship
+	if v.Kind() != reflect.Struct {
+		return relationships
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Anonymous {
+			relationships = append(relationships, sObjectChildren(reflect.Indirect(v.Field(i)))...)
+			continue
+		}
+
+		if field.PkgPath != "" || field.Type.Kind() != reflect.Slice {
+			continue
+		}
+		elem := field.Type.Elem()
+		if !elem.Implements(sObjectType) && !reflect.PtrTo(elem).Implements(sObjectType) {
+			continue
+		}
+
+		name := strings.SplitN(field.Tag.Get("force"), ",", 2)[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		slice := v.Field(i)
+		relationship := sObjectChildRelationship{name: name}
+		for j := 0; j < slice.Len(); j++ {
+			item := slice.Index(j)
+			if item.Kind() == reflect.Ptr && item.IsNil() {
+				continue
+			}
+			if item.Type().Implements(sObjectType) {
+				relationship.records = append(relationship.records, item.Interface().(SObject))
+			} else {
+				relationship.records = append(relationship.records, item.Addr().Interface().(SObject))
+			}
+		}
+		relationships = append(relationships, relationship)
+	}
+
+	return relationships
+}
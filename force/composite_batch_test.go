@@ -0,0 +1,171 @@
+package force
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/nimajalali/go-force/sobjects"
+)
+
+type treeContact struct {
+	sobjects.BaseSObject
+	LastName string `force:",omitempty"`
+}
+
+func (c *treeContact) ApiName() string {
+	return "Contact"
+}
+
+type treeAccount struct {
+	sobjects.Account
+	Contacts []*treeContact `force:"Contacts,omitempty"`
+}
+
+func TestCompositeBatch(t *testing.T) {
+	resources := fmt.Sprintf(resourcesUri, testVersion)
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != resources+"/composite/batch" {
+			t.Errorf("Unexpected request: %v %v", r.Method, r.URL.Path)
+		}
+
+		payload := struct {
+			HaltOnError bool `json:"haltOnError"`
+			Subrequests []struct {
+				Method    string                 `json:"method"`
+				Url       string                 `json:"url"`
+				RichInput map[string]interface{} `json:"richInput"`
+			} `json:"batchRequests"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("Unable to decode payload: %v", err)
+		}
+
+		if !payload.HaltOnError || len(payload.Subrequests) != 2 {
+			t.Fatalf("Unexpected payload: %+v", payload)
+		}
+		if get := payload.Subrequests[0]; get.Method != "GET" || get.Url != testVersion+"/sobjects/Account/001A?fields=Name" {
+			t.Errorf("Unexpected get subrequest: %+v", get)
+		}
+		if update := payload.Subrequests[1]; update.Method != "PATCH" || update.RichInput["Name"] != "Acme" {
+			t.Errorf("Unexpected update subrequest: %+v", update)
+		}
+
+		fmt.Fprint(w, `{"hasErrors":true,"results":[{"statusCode":200,"result":{"Name":"Old"}},`+
+			`{"statusCode":400,"result":[{"errorCode":"FIELD_CUSTOM_VALIDATION_EXCEPTION","message":"nope"}]}]}`)
+	}))
+	defer server.Close()
+
+	acc := &sobjects.Account{}
+	update := &sobjects.Account{}
+	update.Name = "Acme"
+
+	batch := forceApi.CompositeBatch(true)
+	batch.Get("001A", []string{"Name"}, acc)
+	batch.Update("001A", update)
+
+	resp, err := batch.Execute()
+	subrequestErrors, ok := err.(SubrequestErrors)
+	if !ok || len(subrequestErrors) != 1 {
+		t.Fatalf("Expected SubrequestErrors for the failed subrequest, got: %#v", err)
+	}
+	if failed := subrequestErrors[0]; failed.Index != 1 || failed.StatusCode != http.StatusBadRequest ||
+		failed.Errors[0].ErrorCode != "FIELD_CUSTOM_VALIDATION_EXCEPTION" {
+		t.Fatalf("Unexpected subrequest error: %+v", failed)
+	}
+	if !resp.HasErrors || acc.Name != "Old" {
+		t.Fatalf("Unexpected batch response: %+v %+v", resp, acc)
+	}
+}
+
+func TestInsertSObjectTree(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != fmt.Sprintf(resourcesUri, testVersion)+"/composite/tree/Account" {
+			t.Errorf("Unexpected request: %v %v", r.Method, r.URL.Path)
+		}
+
+		var payload interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("Unable to decode payload: %v", err)
+		}
+
+		records := payload.(map[string]interface{})["records"].([]interface{})
+		account := records[0].(map[string]interface{})
+		attributes := account["attributes"].(map[string]interface{})
+		if attributes["type"] != "Account" || attributes["referenceId"] != "acme" || account["Name"] != "Acme" {
+			t.Errorf("Unexpected account record: %v", account)
+		}
+		contacts := account["Contacts"].(map[string]interface{})["records"].([]interface{})
+		if len(contacts) != 2 {
+			t.Fatalf("Expected 2 contacts, got: %v", contacts)
+		}
+		contact := contacts[1].(map[string]interface{})
+		attributes = contact["attributes"].(map[string]interface{})
+		if attributes["type"] != "Contact" || attributes["referenceId"] != "ref3" || contact["LastName"] != "Two" {
+			t.Errorf("Unexpected contact record: %v", contact)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"hasErrors":false,"results":[{"referenceId":"acme","id":"001A"},`+
+			`{"referenceId":"ref2","id":"003A"},{"referenceId":"ref3","id":"003B"}]}`)
+	}))
+	defer server.Close()
+
+	acc := &treeAccount{Contacts: []*treeContact{{LastName: "One"}, {LastName: "Two"}}}
+	acc.Name = "Acme"
+	acc.Attributes.ReferenceId = "acme"
+
+	resp, err := forceApi.InsertSObjectTree([]SObject{acc})
+	if err != nil {
+		t.Fatalf("Failed to insert sobject tree: %v", err)
+	}
+
+	ids := resp.Ids()
+	if ids["acme"] != "001A" || ids["ref2"] != "003A" || ids["ref3"] != "003B" {
+		t.Fatalf("Unexpected ids: %v", ids)
+	}
+}
+
+func TestInsertSObjectTreeErrors(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"hasErrors":true,"results":[{"referenceId":"ref2","errors":[`+
+			`{"statusCode":"REQUIRED_FIELD_MISSING","message":"Required fields are missing: [LastName]","fields":["LastName"]}]}]}`)
+	}))
+	defer server.Close()
+
+	acc := &treeAccount{Contacts: []*treeContact{{}}}
+	resp, err := forceApi.InsertSObjectTree([]SObject{acc})
+	apiErrors, ok := err.(ApiErrors)
+	if !ok || apiErrors[0].StatusCode != "REQUIRED_FIELD_MISSING" {
+		t.Fatalf("Expected ApiErrors, got: %#v", err)
+	}
+	if resp.Results[0].ReferenceId != "ref2" {
+		t.Fatalf("Unexpected tree response: %+v", resp)
+	}
+}
+
+func TestSObjectTreeReferenceIds(t *testing.T) {
+	acc := &treeAccount{Contacts: []*treeContact{{}, {}, {}}}
+	acc.Contacts[0].Attributes.ReferenceId = "ref3"
+	acc.Contacts[2].Attributes.ReferenceId = "ref1"
+
+	builder := &sObjectTreeBuilder{
+		referenceIds: make(map[string]bool),
+		unreferenced: make(map[int]map[string]interface{}),
+	}
+	record, err := builder.record(acc)
+	if err != nil {
+		t.Fatalf("Unable to build tree: %v", err)
+	}
+	builder.generateReferenceIds()
+
+	referenceIds := []interface{}{record["attributes"].(map[string]interface{})["referenceId"]}
+	for _, contact := range record["Contacts"].(map[string]interface{})["records"].([]map[string]interface{}) {
+		referenceIds = append(referenceIds, contact["attributes"].(map[string]interface{})["referenceId"])
+	}
+	if fmt.Sprint(referenceIds) != "[ref2 ref3 ref4 ref1]" {
+		t.Fatalf("Unexpected reference ids: %v", referenceIds)
+	}
+}
@@ -0,0 +1,112 @@
+package force
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/nimajalali/go-force/sobjects"
+)
+
+func TestComposite(t *testing.T) {
+	resources := fmt.Sprintf(resourcesUri, testVersion)
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != resources+"/composite" {
+			t.Errorf("Unexpected request: %v %v", r.Method, r.URL.Path)
+		}
+
+		payload := struct {
+			AllOrNone   bool `json:"allOrNone"`
+			Subrequests []struct {
+				Method      string                 `json:"method"`
+				Url         string                 `json:"url"`
+				ReferenceId string                 `json:"referenceId"`
+				Body        map[string]interface{} `json:"body"`
+			} `json:"compositeRequest"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("Unable to decode payload: %v", err)
+		}
+
+		if !payload.AllOrNone || len(payload.Subrequests) != 3 {
+			t.Fatalf("Unexpected payload: %+v", payload)
+		}
+		insert, update, query := payload.Subrequests[0], payload.Subrequests[1], payload.Subrequests[2]
+		if insert.Method != "POST" || insert.Url != resources+"/sobjects/Account" || insert.Body["Name"] != "Acme" {
+			t.Errorf("Unexpected insert subrequest: %+v", insert)
+		}
+		if update.Method != "PATCH" || update.Url != resources+"/sobjects/Account/@{newAccount.id}" {
+			t.Errorf("Unexpected update subrequest: %+v", update)
+		}
+		if query.Method != "GET" || query.Url != resources+"/query?q=SELECT+Name+FROM+Account" {
+			t.Errorf("Unexpected query subrequest: %+v", query)
+		}
+
+		fmt.Fprint(w, `{"compositeResponse":[`+
+			`{"body":{"id":"001A","success":true,"errors":[]},"httpHeaders":{},"httpStatusCode":201,"referenceId":"newAccount"},`+
+			`{"body":null,"httpHeaders":{},"httpStatusCode":204,"referenceId":"updateAccount"},`+
+			`{"body":{"done":true,"totalSize":1,"records":[{"Name":"Acme Inc"}]},"httpHeaders":{},"httpStatusCode":200,"referenceId":"accounts"}]}`)
+	}))
+	defer server.Close()
+
+	acc := &sobjects.Account{}
+	acc.Name = "Acme"
+	update := &sobjects.Account{}
+	update.Name = "Acme Inc"
+	accounts := &AccountQueryResponse{}
+
+	composite := forceApi.Composite(true)
+	insertResp := composite.Insert("newAccount", acc)
+	composite.Update("updateAccount", "@{newAccount.id}", update)
+	composite.Query("accounts", "SELECT Name FROM Account", accounts)
+
+	resp, err := composite.Execute()
+	if err != nil {
+		t.Fatalf("Failed to execute composite request: %v", err)
+	}
+
+	if len(resp.Responses) != 3 || resp.Responses[1].HttpStatusCode != http.StatusNoContent {
+		t.Fatalf("Unexpected composite response: %+v", resp)
+	}
+	if insertResp.Id != "001A" || !insertResp.Success {
+		t.Fatalf("Unexpected insert response: %+v", insertResp)
+	}
+	if len(accounts.Records) != 1 || accounts.Records[0].Name != "Acme Inc" {
+		t.Fatalf("Unexpected query response: %+v", accounts)
+	}
+}
+
+func TestCompositeErrors(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"compositeResponse":[`+
+			`{"body":[{"errorCode":"REQUIRED_FIELD_MISSING","message":"Required fields are missing: [Name]","fields":["Name"]}],"httpHeaders":{},"httpStatusCode":400,"referenceId":"newAccount"},`+
+			`{"body":[{"errorCode":"PROCESSING_HALTED","message":"The transaction was rolled back since another operation in the same transaction failed."}],"httpHeaders":{},"httpStatusCode":400,"referenceId":"deleteAccount"}]}`)
+	}))
+	defer server.Close()
+
+	composite := forceApi.Composite(true)
+	composite.Insert("newAccount", &sobjects.Account{})
+	composite.Delete("deleteAccount", "@{newAccount.id}", &sobjects.Account{})
+
+	resp, err := composite.Execute()
+	subrequestErrors, ok := err.(SubrequestErrors)
+	if !ok || len(subrequestErrors) != 2 {
+		t.Fatalf("Expected SubrequestErrors for every failed subrequest, got: %#v", err)
+	}
+	if failed := subrequestErrors[0]; failed.Index != 0 || failed.ReferenceId != "newAccount" ||
+		failed.StatusCode != http.StatusBadRequest || failed.Errors[0].ErrorCode != "REQUIRED_FIELD_MISSING" {
+		t.Fatalf("Unexpected subrequest error: %+v", failed)
+	}
+	if failed := subrequestErrors[1]; failed.Index != 1 || failed.ReferenceId != "deleteAccount" {
+		t.Fatalf("Unexpected subrequest error: %+v", failed)
+	}
+	var apiErrors ApiErrors
+	if !errors.As(err, &apiErrors) || len(apiErrors) != 2 {
+		t.Fatalf("Expected the ApiErrors of every failed subrequest, got: %v", apiErrors)
+	}
+	if resp.Responses[1].Errors()[0].ErrorCode != "PROCESSING_HALTED" {
+		t.Fatalf("Unexpected subresponse errors: %v", resp.Responses[1].Errors())
+	}
+}
@@ -0,0 +1,457 @@
+package force
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/nimajalali/go-force/forcejson"
+)
+
+var (
+	marshalerType   = reflect.TypeOf((*forcejson.Marshaler)(nil)).Elem()
+	unmarshalerType = reflect.TypeOf((*forcejson.Unmarshaler)(nil)).Elem()
+)
+
+// csvField is a struct field that maps to a csv column.
+type csvField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// csvFields returns the fields of struct type t that map to csv columns. Like forcejson, columns
+// are named by the force tag or the field name, and fields of embedded structs are promoted
+// unless a shallower field has the same name. Nested structs, slices and maps have no column.
+func csvFields(t reflect.Type) []csvField {
+	var fields []csvField
+	depths := map[string]int{}
+	positions := map[string]int{}
+
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			fieldIndex := append(append([]int{}, index...), i)
+
+			tag := sf.Tag.Get("force")
+			if tag == "-" {
+				continue
+			}
+			name, options := tag, ""
+			if comma := strings.Index(tag, ","); comma >= 0 {
+				name, options = tag[:comma], tag[comma+1:]
+			}
+
+			fieldType := sf.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+
+			if sf.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+				walk(fieldType, fieldIndex)
+				continue
+			}
+			if sf.PkgPath != "" || !csvSupported(sf.Type) {
+				continue
+			}
+			if name == "" {
+				name = sf.Name
+			}
+
+			field := csvField{
+				name:      name,
+				index:     fieldIndex,
+				omitEmpty: strings.Contains(","+options+",", ",omitempty,"),
+			}
+			if depth, ok := depths[name]; ok {
+				if depth <= len(fieldIndex) {
+					continue
+				}
+				fields[positions[name]] = field
+			} else {
+				positions[name] = len(fields)
+				fields = append(fields, field)
+			}
+			depths[name] = len(fieldIndex)
+		}
+	}
+	walk(t, nil)
+
+	return fields
+}
+
+func csvSupported(t reflect.Type) bool {
+	if t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return csvSupported(t.Elem())
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex but returns an invalid value rather than
+// panicking on a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v
+}
+
+// marshalCSV writes records, a slice of structs or pointers to structs, as csv. The header holds
+// every column that has a value in at least one record; empty omitempty fields are left blank.
+func marshalCSV(w io.Writer, records interface{}) error {
+	slice := reflect.ValueOf(records)
+	if slice.Kind() != reflect.Slice {
+		return fmt.Errorf("Unable to marshal %T to csv, expected a slice", records)
+	}
+
+	// Records may be held in an interface slice, such as []SObject.
+	rows := make([]reflect.Value, 0, slice.Len())
+	var structType reflect.Type
+	for i := 0; i < slice.Len(); i++ {
+		row := slice.Index(i)
+		for row.Kind() == reflect.Interface || row.Kind() == reflect.Ptr {
+			row = row.Elem()
+		}
+		if row.Kind() != reflect.Struct {
+			return fmt.Errorf("Unable to marshal %v to csv, expected a struct", slice.Index(i).Type())
+		}
+		if structType == nil {
+			structType = row.Type()
+		} else if row.Type() != structType {
+			return fmt.Errorf("Unable to marshal mixed %v and %v records to csv", structType, row.Type())
+		}
+		rows = append(rows, row)
+	}
+	if structType == nil {
+		return nil
+	}
+
+	// Only keep columns with at least one value.
+	var columns []csvField
+	for _, field := range csvFields(structType) {
+		for _, row := range rows {
+			value := fieldByIndex(row, field.index)
+			if value.IsValid() && (!field.omitEmpty || !isEmptyValue(value)) {
+				columns = append(columns, field)
+				break
+			}
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	header := make([]string, len(columns))
+	for i, field := range columns {
+		header[i] = field.name
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	line := make([]string, len(columns))
+	for _, row := range rows {
+		for i, field := range columns {
+			value := fieldByIndex(row, field.index)
+			if !value.IsValid() || (field.omitEmpty && isEmptyValue(value)) {
+				line[i] = ""
+				continue
+			}
+
+			text, err := csvValue(value)
+			if err != nil {
+				return fmt.Errorf("Unable to marshal field %v to csv: %v", field.name, err)
+			}
+			line[i] = text
+		}
+		if err := writer.Write(line); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func csvValue(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", nil
+		}
+		if !v.Type().Implements(marshalerType) {
+			return csvValue(v.Elem())
+		}
+	}
+
+	if !v.Type().Implements(marshalerType) && v.CanAddr() && v.Addr().Type().Implements(marshalerType) {
+		v = v.Addr()
+	}
+	if marshaler, ok := v.Interface().(forcejson.Marshaler); ok {
+		jsonBytes, err := marshaler.MarshalJSON()
+		if err != nil {
+			return "", err
+		}
+		if text, err := strconv.Unquote(string(jsonBytes)); err == nil {
+			return text, nil
+		}
+		return string(jsonBytes), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+
+	return "", fmt.Errorf("unsupported type %v", v.Type())
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+
+	return false
+}
+
+// csvDecoder decodes csv rows into structs, matching columns to fields by force name. Columns
+// without a matching field are ignored.
+type csvDecoder struct {
+	reader  *csv.Reader
+	header  []string
+	row     []string
+	columns map[reflect.Type][]*csvField
+}
+
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return &csvDecoder{reader: reader}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read csv header: %v", err)
+	}
+
+	return &csvDecoder{
+		reader:  reader,
+		header:  append([]string(nil), header...),
+		columns: map[reflect.Type][]*csvField{},
+	}, nil
+}
+
+// Decode reads the next row into out, a pointer to a struct. It returns io.EOF when there are no
+// more rows.
+func (decoder *csvDecoder) Decode(out interface{}) error {
+	if err := decoder.Read(); err != nil {
+		return err
+	}
+
+	return decoder.DecodeRow(out)
+}
+
+// Read advances to the next row. It returns io.EOF when there are no more rows.
+func (decoder *csvDecoder) Read() error {
+	decoder.row = nil
+	if decoder.header == nil {
+		return io.EOF
+	}
+
+	row, err := decoder.reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return err
+		}
+		return fmt.Errorf("Unable to read csv row: %v", err)
+	}
+	decoder.row = row
+
+	return nil
+}
+
+// DecodeRow decodes the row last returned by Read into out, a pointer to a struct.
+func (decoder *csvDecoder) DecodeRow(out interface{}) error {
+	if decoder.row == nil {
+		return fmt.Errorf("DecodeRow called without a current row")
+	}
+
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("Unable to decode csv into %T, expected a pointer", out)
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("Unable to decode csv into %T, expected a struct", out)
+	}
+
+	columns, ok := decoder.columns[v.Type()]
+	if !ok {
+		byName := map[string]csvField{}
+		for _, field := range csvFields(v.Type()) {
+			byName[field.name] = field
+		}
+		columns = make([]*csvField, len(decoder.header))
+		for i, name := range decoder.header {
+			if field, ok := byName[name]; ok {
+				columns[i] = &field
+			}
+		}
+		decoder.columns[v.Type()] = columns
+	}
+
+	row := decoder.row
+	for i, field := range columns {
+		if field == nil || i >= len(row) || len(row[i]) == 0 {
+			continue
+		}
+		if err := setCSVValue(fieldByIndexAlloc(v, field.index), row[i]); err != nil {
+			return fmt.Errorf("Unable to decode csv column %v: %v", field.name, err)
+		}
+	}
+
+	return nil
+}
+
+// fieldByIndexAlloc is like reflect.Value.FieldByIndex but allocates nil embedded pointers.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v
+}
+
+func setCSVValue(v reflect.Value, text string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		if !v.Type().Implements(unmarshalerType) {
+			return setCSVValue(v.Elem(), text)
+		}
+	}
+
+	if v.CanAddr() && v.Addr().Type().Implements(unmarshalerType) {
+		v = v.Addr()
+	}
+	if unmarshaler, ok := v.Interface().(forcejson.Unmarshaler); ok {
+		// Values are passed to custom types the way json would hold them.
+		jsonText := strconv.Quote(text)
+		if text == "true" || text == "false" {
+			jsonText = text
+		} else if _, err := strconv.ParseFloat(text, 64); err == nil {
+			jsonText = text
+		}
+		return unmarshaler.UnmarshalJSON([]byte(jsonText))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(text)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(text, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(text, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(text, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+
+	return nil
+}
+
+// unmarshalCSV appends every row read from r to out, a pointer to a slice of structs or
+// pointers to structs.
+func unmarshalCSV(r io.Reader, out interface{}) error {
+	outValue := reflect.ValueOf(out)
+	if outValue.Kind() != reflect.Ptr || outValue.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("Unable to unmarshal csv into %T, expected a pointer to a slice", out)
+	}
+	sliceValue := outValue.Elem()
+	elemType := sliceValue.Type().Elem()
+
+	decoder, err := newCSVDecoder(r)
+	if err != nil {
+		return err
+	}
+
+	for {
+		elem := reflect.New(elemType)
+		if err := decoder.Decode(elem.Interface()); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		sliceValue.Set(reflect.Append(sliceValue, elem.Elem()))
+	}
+}
@@ -0,0 +1,58 @@
+package force
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nimajalali/go-force/sobjects"
+)
+
+func TestMarshalCSV(t *testing.T) {
+	first := &sobjects.Opportunity{Name: "Deal, big", Amount: 1.5, CloseDate: sobjects.AsTime(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC))}
+	second := &sobjects.Opportunity{StageName: "Closed Won"}
+	second.Id = "006A"
+
+	var data bytes.Buffer
+	if err := marshalCSV(&data, []SObject{first, second}); err != nil {
+		t.Fatalf("Unable to marshal csv: %v", err)
+	}
+
+	expected := "Id,Name,Amount,CloseDate,StageName\n" +
+		",\"Deal, big\",1.5,2020-01-02T00:00:00.000+0000,\n" +
+		"006A,,,,Closed Won\n"
+	if data.String() != expected {
+		t.Fatalf("Unexpected csv:\n%v\nexpected:\n%v", data.String(), expected)
+	}
+}
+
+func TestUnmarshalCSV(t *testing.T) {
+	data := "sf__Id,sf__Created,Name,Amount,CloseDate,IsWon,Unknown\n" +
+		"006A,true,Deal,2.25,2020-01-02T00:00:00.000+0000,true,x\n" +
+		"006B,false,,,,,\n"
+
+	type result struct {
+		IngestResult
+		sobjects.Opportunity
+	}
+
+	var results []*result
+	if err := unmarshalCSV(strings.NewReader(data), &results); err != nil {
+		t.Fatalf("Unable to unmarshal csv: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %v", len(results))
+	}
+	first := results[0]
+	if first.SfId != "006A" || !first.SfCreated || first.Name != "Deal" || first.Amount != 2.25 || !first.IsWon {
+		t.Fatalf("Unexpected first result: %+v", first)
+	}
+	if !first.CloseDate.Time().Equal(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Unexpected close date: %v", first.CloseDate)
+	}
+	if results[1].SfId != "006B" || results[1].CloseDate != nil {
+		t.Fatalf("Unexpected second result: %+v", results[1])
+	}
+}
@@ -1,10 +1,26 @@
 package force
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 )
 
+const (
+	requestIdHeader = "X-SFDC-Request-Id"
+
+	notFoundErrorCode              = "NOT_FOUND"
+	duplicateValueErrorCode        = "DUPLICATE_VALUE"
+	duplicatesDetectedErrorCode    = "DUPLICATES_DETECTED"
+	fieldIntegrityExceptionCode    = "FIELD_INTEGRITY_EXCEPTION"
+	requestLimitExceededErrorCode  = "REQUEST_LIMIT_EXCEEDED"
+	requestLimitExceededStatusCode = http.StatusTooManyRequests
+
+	// Bulk API 1.0 exception code of jobs and batches beyond the daily limit.
+	exceededQuotaExceptionCode = "ExceededQuota"
+)
+
 // Custom Error to handle salesforce api responses.
 type ApiErrors []*ApiError
 
@@ -12,6 +28,7 @@ type ApiError struct {
 	Fields           []string `json:"fields,omitempty" force:"fields,omitempty"`
 	Message          string   `json:"message,omitempty" force:"message,omitempty"`
 	ErrorCode        string   `json:"errorCode,omitempty" force:"errorCode,omitempty"`
+	StatusCode       string   `json:"statusCode,omitempty" force:"statusCode,omitempty"`
 	ErrorName        string   `json:"error,omitempty" force:"error,omitempty"`
 	ErrorDescription string   `json:"error_description,omitempty" force:"error_description,omitempty"`
 }
@@ -41,14 +58,174 @@ func (e ApiError) Error() string {
 	return e.String()
 }
 
+// String formats the error as its code followed by its message, such as
+// "INVALID_FIELD: No such column 'Foo' on entity 'Account' (fields: Foo)".
 func (e ApiError) String() string {
-	return fmt.Sprintf("%#v", e)
+	code, message := e.Code(), e.Message
+	if len(code) == 0 {
+		// Oauth endpoints report errors in their own format.
+		code, message = e.ErrorName, e.ErrorDescription
+	}
+
+	var s strings.Builder
+	s.WriteString(code)
+	if len(message) > 0 {
+		if s.Len() > 0 {
+			s.WriteString(": ")
+		}
+		s.WriteString(message)
+	}
+	if len(e.Fields) > 0 {
+		fmt.Fprintf(&s, " (fields: %v)", strings.Join(e.Fields, ", "))
+	}
+
+	return s.String()
+}
+
+// Code returns the error code, which some resources, such as sObject Collections, report as the
+// status code.
+func (e ApiError) Code() string {
+	if len(e.ErrorCode) > 0 {
+		return e.ErrorCode
+	}
+
+	return e.StatusCode
 }
 
 func (e ApiError) Validate() bool {
-	if len(e.Fields) != 0 || len(e.Message) != 0 || len(e.ErrorCode) != 0 || len(e.ErrorName) != 0 || len(e.ErrorDescription) != 0 {
+	if len(e.Fields) != 0 || len(e.Message) != 0 || len(e.ErrorCode) != 0 || len(e.StatusCode) != 0 || len(e.ErrorName) != 0 || len(e.ErrorDescription) != 0 {
+		return true
+	}
+
+	return false
+}
+
+// RequestError is returned for requests the api responded to with an error status. The errors
+// the api reported, if any, are in Errors, which errors.As can also extract as ApiErrors. Errors
+// reported in other formats, by the oauth endpoints and Bulk API 1.0, are in Err instead, which
+// errors.As can extract as an *ApiError or *BulkError.
+type RequestError struct {
+	StatusCode int
+	Method     string
+	Path       string
+	// Id Salesforce assigned to the request, for support cases.
+	RequestId string
+	Errors    ApiErrors
+	Err       error
+	// Response body, kept when it isn't an error in a known format.
+	Body []byte
+}
+
+func newRequestError(resp *http.Response, apiErrors ApiErrors, body []byte) *RequestError {
+	requestError := &RequestError{
+		StatusCode: resp.StatusCode,
+		RequestId:  resp.Header.Get(requestIdHeader),
+		Errors:     apiErrors,
+	}
+	if resp.Request != nil {
+		requestError.Method = resp.Request.Method
+		requestError.Path = resp.Request.URL.Path
+	}
+	if len(apiErrors) == 0 {
+		requestError.Body = body
+	}
+
+	return requestError
+}
+
+func (e *RequestError) Error() string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "%v %v: %v %v", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode))
+	if len(e.Errors) > 0 {
+		s.WriteString(": ")
+		s.WriteString(strings.Join(strings.Split(e.Errors.String(), "\n"), "; "))
+	} else if e.Err != nil {
+		fmt.Fprintf(&s, ": %v", e.Err)
+	} else if len(e.Body) > 0 {
+		fmt.Fprintf(&s, ": %s", e.Body)
+	}
+	if len(e.RequestId) > 0 {
+		fmt.Fprintf(&s, " (request id %v)", e.RequestId)
+	}
+
+	return s.String()
+}
+
+// Unwrap returns the api errors, or the error in another format, so that errors.As can extract
+// them.
+func (e *RequestError) Unwrap() error {
+	if len(e.Errors) == 0 {
+		return e.Err
+	}
+
+	return e.Errors
+}
+
+// IsNotFound reports whether err is due to a record or resource that doesn't exist.
+func IsNotFound(err error) bool {
+	var requestError *RequestError
+	if errors.As(err, &requestError) && requestError.StatusCode == http.StatusNotFound {
+		return true
+	}
+
+	return hasErrorCode(err, notFoundErrorCode)
+}
+
+// IsDuplicate reports whether err is due to a record that would duplicate another, either by a
+// unique field or by a duplicate rule.
+func IsDuplicate(err error) bool {
+	return hasErrorCode(err, duplicateValueErrorCode, duplicatesDetectedErrorCode)
+}
+
+// IsFieldIntegrity reports whether err is due to a field value that breaks the integrity of the
+// record, such as a reference to a record of the wrong type.
+func IsFieldIntegrity(err error) bool {
+	return hasErrorCode(err, fieldIntegrityExceptionCode)
+}
+
+// IsRateLimited reports whether err is due to the org exceeding its API request or Bulk API
+// limits, or to the request being refused by the ApiUsageThrottle.
+func IsRateLimited(err error) bool {
+	if errors.Is(err, ErrApiUsageLimit) {
 		return true
 	}
 
+	var requestError *RequestError
+	if errors.As(err, &requestError) && requestError.StatusCode == requestLimitExceededStatusCode {
+		return true
+	}
+
+	return hasErrorCode(err, requestLimitExceededErrorCode, exceededQuotaExceptionCode)
+}
+
+// hasErrorCode reports whether err holds an api error, or Bulk API 1.0 error, with one of codes.
+func hasErrorCode(err error, codes ...string) bool {
+	bulkError := &BulkError{}
+	if errors.As(err, &bulkError) {
+		for _, code := range codes {
+			if bulkError.ExceptionCode == code {
+				return true
+			}
+		}
+		return false
+	}
+
+	var apiErrors ApiErrors
+	if !errors.As(err, &apiErrors) {
+		apiError := &ApiError{}
+		if !errors.As(err, &apiError) {
+			return false
+		}
+		apiErrors = ApiErrors{apiError}
+	}
+
+	for _, apiError := range apiErrors {
+		for _, code := range codes {
+			if apiError.Code() == code {
+				return true
+			}
+		}
+	}
+
 	return false
 }
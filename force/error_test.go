@@ -0,0 +1,123 @@
+package force
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestApiErrorString(t *testing.T) {
+	tests := []struct {
+		apiError *ApiError
+		expected string
+	}{
+		{&ApiError{ErrorCode: "INVALID_FIELD", Message: "No such column 'Foo' on entity 'Account'", Fields: []string{"Foo"}},
+			"INVALID_FIELD: No such column 'Foo' on entity 'Account' (fields: Foo)"},
+		{&ApiError{StatusCode: "REQUIRED_FIELD_MISSING", Message: "Required fields are missing"},
+			"REQUIRED_FIELD_MISSING: Required fields are missing"},
+		{&ApiError{ErrorName: "invalid_grant", ErrorDescription: "authentication failure"},
+			"invalid_grant: authentication failure"},
+	}
+	for _, test := range tests {
+		if s := test.apiError.String(); s != test.expected {
+			t.Fatalf("Expected %q, got %q", test.expected, s)
+		}
+	}
+}
+
+func TestRequestError(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(requestIdHeader, "4ZfS9n")
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `[{"message":"The requested resource does not exist","errorCode":"NOT_FOUND"}]`)
+		case "/duplicate":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `[{"message":"duplicate value found","errorCode":"DUPLICATE_VALUE","fields":[]}]`)
+		case "/limited":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `[{"message":"TotalRequests Limit exceeded.","errorCode":"REQUEST_LIMIT_EXCEEDED"}]`)
+		case "/unauthorized":
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":"invalid_token","error_description":"expired"}`)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprint(w, `<html>Bad Gateway</html>`)
+		}
+	}))
+	defer server.Close()
+
+	err := forceApi.Get("/missing", nil, &map[string]interface{}{})
+	requestError := &RequestError{}
+	if !errors.As(err, &requestError) {
+		t.Fatalf("Expected a RequestError, got %#v", err)
+	}
+	if requestError.StatusCode != http.StatusNotFound || requestError.Method != "GET" || requestError.Path != "/missing" ||
+		requestError.RequestId != "4ZfS9n" || len(requestError.Errors) != 1 {
+		t.Fatalf("Unexpected RequestError: %+v", requestError)
+	}
+	if s := err.Error(); s != "GET /missing: 404 Not Found: NOT_FOUND: The requested resource does not exist (request id 4ZfS9n)" {
+		t.Fatalf("Unexpected error message: %v", s)
+	}
+	if apiErrors := (ApiErrors{}); !errors.As(err, &apiErrors) || apiErrors[0].ErrorCode != notFoundErrorCode {
+		t.Fatalf("Expected the ApiErrors to be unwrapped, got %#v", err)
+	}
+	if !IsNotFound(err) || IsDuplicate(err) || IsRateLimited(err) {
+		t.Fatalf("Misclassified error: %v", err)
+	}
+
+	err = forceApi.Post("/duplicate", nil, map[string]string{}, nil)
+	if !IsDuplicate(fmt.Errorf("Insert failed: %w", err)) || IsNotFound(err) {
+		t.Fatalf("Misclassified error: %v", err)
+	}
+
+	err = forceApi.Get("/limited", nil, nil)
+	if !IsRateLimited(err) || IsFieldIntegrity(err) {
+		t.Fatalf("Misclassified error: %v", err)
+	}
+	if !IsRateLimited(ErrApiUsageLimit) {
+		t.Fatal("Expected throttled requests to be rate limited")
+	}
+
+	// Json error bodies that aren't api errors are still errors.
+	out := map[string]interface{}{}
+	err = forceApi.Get("/unauthorized", nil, &out)
+	if !errors.As(err, &requestError) || requestError.StatusCode != http.StatusUnauthorized || len(out) != 0 {
+		t.Fatalf("Expected a RequestError, got %#v", err)
+	}
+
+	err = forceApi.Get("/gateway", nil, &map[string]interface{}{})
+	if !errors.As(err, &requestError) || requestError.StatusCode != http.StatusBadGateway ||
+		string(requestError.Body) != "<html>Bad Gateway</html>" || errors.Unwrap(err) != nil {
+		t.Fatalf("Expected a RequestError with the body, got %#v", err)
+	}
+}
+
+func TestIsFieldIntegrity(t *testing.T) {
+	err := ApiErrors{{StatusCode: fieldIntegrityExceptionCode, Message: "Account ID: id value of incorrect type"}}
+	if !IsFieldIntegrity(err) || IsDuplicate(err) {
+		t.Fatalf("Misclassified error: %v", err)
+	}
+	if !IsFieldIntegrity(&ApiError{ErrorCode: fieldIntegrityExceptionCode}) {
+		t.Fatal("Expected a single ApiError to be classified")
+	}
+}
+
+func TestIsRateLimited(t *testing.T) {
+	tests := []error{
+		&RequestError{StatusCode: http.StatusTooManyRequests},
+		&RequestError{StatusCode: http.StatusBadRequest, Err: &BulkError{ExceptionCode: exceededQuotaExceptionCode}},
+		fmt.Errorf("Unable to create job: %w", &RequestError{StatusCode: http.StatusForbidden,
+			Errors: ApiErrors{{ErrorCode: requestLimitExceededErrorCode}}}),
+	}
+	for _, err := range tests {
+		if !IsRateLimited(err) {
+			t.Fatalf("Expected %v to be rate limited", err)
+		}
+	}
+	if IsRateLimited(&RequestError{StatusCode: http.StatusBadRequest, Err: &BulkError{ExceptionCode: "InvalidJob"}}) {
+		t.Fatal("Expected an invalid job not to be rate limited")
+	}
+}
@@ -0,0 +1,73 @@
+package force
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// flightTimeout bounds a shared call, so that a call that hangs doesn't hold up every later caller
+// with the same key.
+const flightTimeout = 2 * time.Minute
+
+// flightGroup collapses concurrent calls sharing a key into a single call.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done    chan struct{}
+	value   interface{}
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// do calls fn, unless a call with the same key is already in flight, in which case it waits for
+// that call and returns its result. fn is given a context that isn't tied to any one caller, but
+// that times out after flightTimeout. A caller stops waiting when its ctx is done; the call itself
+// carries on for the other callers, and is canceled once every caller has stopped waiting.
+func (group *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	group.mu.Lock()
+	if group.calls == nil {
+		group.calls = make(map[string]*flightCall)
+	}
+	call, ok := group.calls[key]
+	if !ok {
+		callCtx, cancel := context.WithTimeout(context.Background(), flightTimeout)
+		call = &flightCall{done: make(chan struct{}), cancel: cancel}
+		group.calls[key] = call
+		go func() {
+			defer cancel()
+			value, err := fn(callCtx)
+
+			group.mu.Lock()
+			call.value, call.err = value, err
+			if group.calls[key] == call {
+				delete(group.calls, key)
+			}
+			group.mu.Unlock()
+			close(call.done)
+		}()
+	}
+	call.waiters++
+	group.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		group.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			// Nobody is waiting for the result, so later callers start a call of their own.
+			call.cancel()
+			if group.calls[key] == call {
+				delete(group.calls, key)
+			}
+		}
+		group.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,93 @@
+package force
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFlightGroupSharesCall(t *testing.T) {
+	var group flightGroup
+	var calls int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	results := make([]interface{}, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			value, err := group.do(context.Background(), "key", func(ctx context.Context) (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return "value", nil
+			})
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			results[i] = value
+		}(i)
+	}
+
+	// Give every caller time to join the call before it returns.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("Expected a single call, got %v", calls)
+	}
+	for _, value := range results {
+		if value != "value" {
+			t.Fatalf("Expected every caller to get the result, got %v", results)
+		}
+	}
+}
+
+func TestFlightGroupContextCanceled(t *testing.T) {
+	var group flightGroup
+	release := make(chan struct{})
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := group.do(ctx, "key", func(ctx context.Context) (interface{}, error) {
+		<-release
+		return nil, nil
+	})
+	if err != context.Canceled {
+		t.Fatalf("Expected the canceled context to stop waiting, got %v", err)
+	}
+}
+
+func TestFlightGroupCanceledWithoutWaiters(t *testing.T) {
+	var group flightGroup
+	canceled := make(chan struct{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := group.do(ctx, "key", func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		close(canceled)
+		return nil, ctx.Err()
+	})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Expected the caller to stop waiting, got %v", err)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the call to be canceled once no caller waits for it")
+	}
+
+	// A later caller starts a new call.
+	value, err := group.do(context.Background(), "key", func(ctx context.Context) (interface{}, error) {
+		return "value", nil
+	})
+	if value != "value" || err != nil {
+		t.Fatalf("Expected a new call, got %v %v", value, err)
+	}
+}
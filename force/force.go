@@ -4,122 +4,112 @@
 package force
 
 import (
+	"context"
+	"crypto/rsa"
 	"fmt"
-	"os"
-)
-
-const (
-	testVersion       = "v36.0"
-	testClientId      = "3MVG9A2kN3Bn17hs8MIaQx1voVGy662rXlC37svtmLmt6wO_iik8Hnk3DlcYjKRvzVNGWLFlGRH1ryHwS217h"
-	testClientSecret  = "4165772184959202901"
-	testUserName      = "go-force@jalali.net"
-	testPassword      = "golangrocks3"
-	testSecurityToken = "kAlicVmti9nWRKRiWG3Zvqtte"
-	testEnvironment   = "production"
+	"net/http"
 )
 
 func Create(version, clientId, clientSecret, userName, password, securityToken,
 	environment string) (*ForceApi, error) {
-	oauth := &forceOauth{
-		clientId:      clientId,
-		clientSecret:  clientSecret,
-		userName:      userName,
-		password:      password,
-		securityToken: securityToken,
-		environment:   environment,
-	}
-
-	forceApi := &ForceApi{
-		apiResources:           make(map[string]string),
-		apiSObjects:            make(map[string]*SObjectMetaData),
-		apiSObjectDescriptions: make(map[string]*SObjectDescription),
-		apiVersion:             version,
-		oauth:                  oauth,
-	}
-
-	// Init oauth
-	err := forceApi.oauth.Authenticate()
-	if err != nil {
-		return nil, err
+	loginUrl := loginUri
+	if environment == "sandbox" {
+		loginUrl = testLoginUri
 	}
 
-	// Init Api Resources
-	err = forceApi.getApiResources()
-	if err != nil {
-		return nil, err
-	}
-	err = forceApi.getApiSObjects()
-	if err != nil {
-		return nil, err
-	}
-
-	return forceApi, nil
+	return CreateWithOptions(
+		WithApiVersion(version),
+		WithLoginUrl(loginUrl),
+		WithPasswordCredentials(clientId, clientSecret, userName, password, securityToken),
+	)
 }
 
 func CreateWithAccessToken(version, clientId, accessToken, instanceUrl string) (*ForceApi, error) {
-	oauth := &forceOauth{
-		clientId:    clientId,
-		AccessToken: accessToken,
-		InstanceUrl: instanceUrl,
-	}
+	return CreateWithOptions(
+		WithApiVersion(version),
+		WithAccessToken(clientId, accessToken, instanceUrl),
+	)
+}
 
-	forceApi := &ForceApi{
-		apiResources:           make(map[string]string),
-		apiSObjects:            make(map[string]*SObjectMetaData),
-		apiSObjectDescriptions: make(map[string]*SObjectDescription),
-		apiVersion:             version,
-		oauth:                  oauth,
-	}
+// CreateWithJWT authenticates with the oauth JWT bearer flow, see WithJWTBearer.
+func CreateWithJWT(version, clientId, userName, audience string, privateKey *rsa.PrivateKey) (*ForceApi, error) {
+	return CreateWithOptions(
+		WithApiVersion(version),
+		WithJWTBearer(clientId, userName, audience, privateKey),
+	)
+}
 
-	// We need to check for oath correctness here, since we are not generating the token ourselves.
-	if err := forceApi.oauth.Validate(); err != nil {
-		return nil, err
-	}
+// CreateWithClientCredentials authenticates with the oauth client credentials flow, see
+// WithClientCredentials.
+func CreateWithClientCredentials(version, clientId, clientSecret, myDomainUrl string) (*ForceApi, error) {
+	return CreateWithOptions(
+		WithApiVersion(version),
+		WithClientCredentials(clientId, clientSecret, myDomainUrl),
+	)
+}
 
-	// Init Api Resources
-	err := forceApi.getApiResources()
-	if err != nil {
-		return nil, err
-	}
-	err = forceApi.getApiSObjects()
-	if err != nil {
-		return nil, err
-	}
+// CreateWithRefreshToken obtains an access token by redeeming refreshToken at instanceUrl, see
+// WithRefreshToken. Use CreateWithRefreshTokenAndSecret for connected apps that require the client
+// secret.
+func CreateWithRefreshToken(version, clientId, refreshToken, instanceUrl string) (*ForceApi, error) {
+	return CreateWithRefreshTokenAndSecret(version, clientId, "", refreshToken, instanceUrl)
+}
 
-	return forceApi, nil
+// CreateWithRefreshTokenAndSecret is like CreateWithRefreshToken, for connected apps that require
+// the client secret to redeem refresh tokens.
+func CreateWithRefreshTokenAndSecret(version, clientId, clientSecret, refreshToken, instanceUrl string) (*ForceApi, error) {
+	return CreateWithOptions(
+		WithApiVersion(version),
+		WithRefreshToken(clientId, clientSecret, refreshToken, instanceUrl),
+	)
 }
 
-func CreateWithRefreshToken(version, clientId, accessToken, instanceUrl string) (*ForceApi, error) {
-	oauth := &forceOauth{
-		clientId:    clientId,
-		AccessToken: accessToken,
-		InstanceUrl: instanceUrl,
-	}
+// CreateWithToken creates a ForceApi from a token obtained by WebServerFlow, see WithToken.
+func CreateWithToken(version, clientId, clientSecret string, token *Token) (*ForceApi, error) {
+	return CreateWithOptions(
+		WithApiVersion(version),
+		WithToken(clientId, clientSecret, token),
+	)
+}
 
-	forceApi := &ForceApi{
-		apiResources:           make(map[string]string),
-		apiSObjects:            make(map[string]*SObjectMetaData),
-		apiSObjectDescriptions: make(map[string]*SObjectDescription),
-		apiVersion:             version,
-		oauth:                  oauth,
+// CreateWithOptions creates a ForceApi configured by the given options. Credentials must be
+// supplied with one of WithPasswordCredentials, WithJWTBearer, WithClientCredentials,
+// WithRefreshToken, WithToken or WithAccessToken. The http client, login url, user agent and
+// api version are used for both data and oauth calls.
+func CreateWithOptions(options ...Option) (*ForceApi, error) {
+	forceApi := newForceApi(DefaultApiVersion, &forceOauth{})
+	for _, option := range options {
+		option(forceApi)
 	}
-
-	// obtain access token
-	if err := forceApi.RefreshToken(); err != nil {
-		return nil, err
+	if forceApi.optionErr != nil {
+		return nil, forceApi.optionErr
 	}
 
-	// We need to check for oath correctness here, since we are not generating the token ourselves.
-	if err := forceApi.oauth.Validate(); err != nil {
+	forceApi.oauth.client = forceApi.httpClient
+	forceApi.oauth.userAgent = forceApi.userAgent
+
+	ctx := context.Background()
+	if err := forceApi.oauth.loadToken(ctx); err != nil {
 		return nil, err
 	}
+	if len(forceApi.oauth.currentToken().AccessToken) == 0 {
+		// Init oauth
+		if err := forceApi.oauth.Authenticate(ctx); err != nil {
+			return nil, err
+		}
+	} else {
+		// We need to check for oath correctness here, since we are not generating the token ourselves.
+		if err := forceApi.oauth.Validate(); err != nil {
+			return nil, err
+		}
+	}
 
 	// Init Api Resources
-	err := forceApi.getApiResources()
+	err := forceApi.getApiResources(ctx)
 	if err != nil {
 		return nil, err
 	}
-	err = forceApi.getApiSObjects()
+	err = forceApi.getApiSObjects(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -127,15 +117,16 @@ func CreateWithRefreshToken(version, clientId, accessToken, instanceUrl string)
 	return forceApi, nil
 }
 
-// Used when running tests.
-func createTest() *ForceApi {
-	forceApi, err := Create(testVersion, testClientId, testClientSecret, testUserName, testPassword, testSecurityToken, testEnvironment)
-	if err != nil {
-		fmt.Printf("Unable to create ForceApi for test: %v", err)
-		os.Exit(1)
+func newForceApi(version string, oauth *forceOauth) *ForceApi {
+	return &ForceApi{
+		apiResources:           make(map[string]string),
+		apiSObjects:            make(map[string]*SObjectMetaData),
+		apiSObjectDescriptions: make(map[string]*SObjectDescription),
+		apiVersion:             version,
+		oauth:                  oauth,
+		httpClient:             http.DefaultClient,
+		userAgent:              userAgent,
 	}
-
-	return forceApi
 }
 
 type ForceApiLogger interface {
@@ -1,10 +1,58 @@
 package force
 
 import (
-	"github.com/nimajalali/go-force/sobjects"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
+
+	"github.com/nimajalali/go-force/forcetest"
+	"github.com/nimajalali/go-force/sobjects"
 )
 
+const (
+	testVersion       = "v36.0"
+	testClientId      = "3MVG9A2kN3Bn17hs8MIaQx1voVGy662rXlC37svtmLmt6wO_iik8Hnk3DlcYjKRvzVNGWLFlGRH1ryHwS217h"
+	testClientSecret  = "4165772184959202901"
+	testUserName      = "go-force@jalali.net"
+	testPassword      = "golangrocks3"
+	testSecurityToken = "kAlicVmti9nWRKRiWG3Zvqtte"
+	testEnvironment   = "production"
+)
+
+// testOrg is the fake org that createTest connects to.
+var testOrg *forcetest.Server
+
+func TestMain(m *testing.M) {
+	backend := forcetest.NewMemoryBackend()
+	backend.AddSObject("CustomObject__c", "a00", "Name", "Active__c", "Account__c")
+	backend.Seed("Account", forcetest.Record{"Id": AccountId, "Name": "Test Account"})
+	backend.Seed("CustomObject__c", forcetest.Record{"Id": CustomObjectId, "Name": "Test", "Active__c": true, "Account__c": AccountId})
+
+	testOrg = forcetest.NewServer(backend)
+	code := m.Run()
+	testOrg.Close()
+
+	os.Exit(code)
+}
+
+// Used when running tests.
+func createTest() *ForceApi {
+	forceApi, err := CreateWithOptions(
+		WithApiVersion(testVersion),
+		WithLoginUrl(testOrg.URL),
+		WithPasswordCredentials(testClientId, testClientSecret, testUserName, testPassword, testSecurityToken),
+	)
+	if err != nil {
+		fmt.Printf("Unable to create ForceApi for test: %v", err)
+		os.Exit(1)
+	}
+
+	return forceApi
+}
+
 func TestCreateWithAccessToken(t *testing.T) {
 
 	// Manually grab an OAuth token, so that we can pass it into CreateWithAccessToken
@@ -15,6 +63,7 @@ func TestCreateWithAccessToken(t *testing.T) {
 		password:      testPassword,
 		securityToken: testSecurityToken,
 		environment:   testEnvironment,
+		loginUrl:      testOrg.URL,
 	}
 
 	forceApi := &ForceApi{
@@ -25,7 +74,7 @@ func TestCreateWithAccessToken(t *testing.T) {
 		oauth:                  oauth,
 	}
 
-	err := forceApi.oauth.Authenticate()
+	err := forceApi.oauth.Authenticate(context.Background())
 	if err != nil {
 		t.Fatalf("Unable to authenticate: %#v", err)
 	}
@@ -48,3 +97,28 @@ func TestCreateWithAccessToken(t *testing.T) {
 		t.Fatalf("Failed to retrieve description of sobject: %v", err)
 	}
 }
+
+// createTestServer returns a ForceApi talking to a local server. The api resources and sobject
+// listing are served by the helper, every other request is passed to handler. Callers must close
+// the returned server.
+func createTestServer(t *testing.T, handler http.Handler) (*ForceApi, *httptest.Server) {
+	resources := fmt.Sprintf(resourcesUri, testVersion)
+	mux := http.NewServeMux()
+	mux.HandleFunc(resources, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"sobjects":"%[1]v/sobjects","query":"%[1]v/query","queryAll":"%[1]v/queryAll","limits":"%[1]v/limits"}`, resources)
+	})
+	mux.HandleFunc(resources+"/sobjects", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"maxBatchSize":200,"sobjects":[{"name":"Account","urls":{"sobject":"%[1]v/sobjects/Account","describe":"%[1]v/sobjects/Account/describe","rowTemplate":"%[1]v/sobjects/Account/{ID}"}}]}`, resources)
+	})
+	mux.Handle("/", handler)
+
+	server := httptest.NewServer(mux)
+
+	forceApi, err := CreateWithAccessToken(testVersion, testClientId, "test-access-token", server.URL)
+	if err != nil {
+		server.Close()
+		t.Fatalf("Unable to create ForceApi for test server: %v", err)
+	}
+
+	return forceApi, server
+}
@@ -0,0 +1,16 @@
+// Package forcemock provides mock implementations of the force.Querier, force.SObjectCRUD and
+// force.Describer interfaces, generated with moq. Set the Func field of each method the code under
+// test calls, then inspect the recorded calls:
+//
+//	querier := &forcemock.QuerierMock{
+//		QueryFunc: func(query string, out interface{}) error {
+//			return nil
+//		},
+//	}
+//	...
+//	if len(querier.QueryCalls()) != 1 {
+//		t.Fatal("Expected a query")
+//	}
+//
+// Regenerate the mocks with go generate in the force package.
+package forcemock
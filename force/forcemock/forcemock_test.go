@@ -0,0 +1,63 @@
+package forcemock
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nimajalali/go-force/force"
+	"github.com/nimajalali/go-force/sobjects"
+)
+
+// countAccounts is an example of code depending on force.Querier rather than force.ForceApi.
+func countAccounts(querier force.Querier) (float64, error) {
+	resp := &sobjects.QueryResponse[*sobjects.Account]{}
+	if err := querier.QueryContext(context.Background(), "SELECT Id FROM Account", resp); err != nil {
+		return 0, err
+	}
+
+	return resp.TotalSize, nil
+}
+
+func TestQuerierMock(t *testing.T) {
+	querier := &QuerierMock{
+		QueryContextFunc: func(ctx context.Context, query string, out interface{}) error {
+			out.(*sobjects.QueryResponse[*sobjects.Account]).TotalSize = 3
+			return nil
+		},
+	}
+
+	count, err := countAccounts(querier)
+	if err != nil || count != 3 {
+		t.Fatalf("Unexpected count %v: %v", count, err)
+	}
+
+	calls := querier.QueryContextCalls()
+	if len(calls) != 1 || calls[0].Query != "SELECT Id FROM Account" {
+		t.Fatalf("Unexpected calls: %+v", calls)
+	}
+}
+
+func TestSObjectCRUDMock(t *testing.T) {
+	crud := &SObjectCRUDMock{
+		InsertSObjectFunc: func(in force.SObject) (*force.SObjectResponse, error) {
+			return &force.SObjectResponse{Id: "001000000000001AAA", Success: true}, nil
+		},
+	}
+
+	account := &sobjects.Account{}
+	account.Name = "Acme"
+	resp, err := crud.InsertSObject(account)
+	if err != nil || resp.Id != "001000000000001AAA" {
+		t.Fatalf("Unexpected response %+v: %v", resp, err)
+	}
+	if calls := crud.InsertSObjectCalls(); len(calls) != 1 || calls[0].In.(*sobjects.Account).Name != "Acme" {
+		t.Fatalf("Unexpected calls: %+v", calls)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected a method without a Func to panic")
+		}
+	}()
+	crud.DeleteSObject("001000000000001AAA", &sobjects.Account{})
+}
@@ -0,0 +1,188 @@
+package force
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+const (
+	revokeUri     = "/services/oauth2/revoke"
+	userInfoUri   = "/services/oauth2/userinfo"
+	introspectUri = "/services/oauth2/introspect"
+)
+
+// Identity describes the user and org a session belongs to, as returned by the identity url.
+type Identity struct {
+	Id                   string            `force:"id"`
+	AssertedUser         bool              `force:"asserted_user"`
+	UserId               string            `force:"user_id"`
+	OrganizationId       string            `force:"organization_id"`
+	Username             string            `force:"username"`
+	NickName             string            `force:"nick_name"`
+	DisplayName          string            `force:"display_name"`
+	Email                string            `force:"email"`
+	EmailVerified        bool              `force:"email_verified"`
+	FirstName            string            `force:"first_name"`
+	LastName             string            `force:"last_name"`
+	Timezone             string            `force:"timezone"`
+	Photos               map[string]string `force:"photos"`
+	Status               *IdentityStatus   `force:"status"`
+	Urls                 map[string]string `force:"urls"`
+	Active               bool              `force:"active"`
+	UserType             string            `force:"user_type"`
+	Language             string            `force:"language"`
+	Locale               string            `force:"locale"`
+	UtcOffset            int64             `force:"utcOffset"`
+	LastModifiedDate     string            `force:"last_modified_date"`
+	IsLightningLoginUser bool              `force:"is_lightning_login_user"`
+}
+
+// IdentityStatus is the latest Chatter status of a user.
+type IdentityStatus struct {
+	CreatedDate string `force:"created_date"`
+	Body        string `force:"body"`
+}
+
+// UserInfo describes the user a session belongs to, as returned by the OpenID Connect userinfo
+// endpoint.
+type UserInfo struct {
+	Sub               string            `force:"sub"`
+	UserId            string            `force:"user_id"`
+	OrganizationId    string            `force:"organization_id"`
+	PreferredUsername string            `force:"preferred_username"`
+	Nickname          string            `force:"nickname"`
+	Name              string            `force:"name"`
+	Email             string            `force:"email"`
+	EmailVerified     bool              `force:"email_verified"`
+	GivenName         string            `force:"given_name"`
+	FamilyName        string            `force:"family_name"`
+	Zoneinfo          string            `force:"zoneinfo"`
+	Photos            map[string]string `force:"photos"`
+	Profile           string            `force:"profile"`
+	Picture           string            `force:"picture"`
+	Address           map[string]string `force:"address"`
+	Urls              map[string]string `force:"urls"`
+	Active            bool              `force:"active"`
+	UserType          string            `force:"user_type"`
+	Language          string            `force:"language"`
+	Locale            string            `force:"locale"`
+	UtcOffset         int64             `force:"utcOffset"`
+	UpdatedAt         string            `force:"updated_at"`
+}
+
+// TokenIntrospection describes a token, as returned by the introspection endpoint. Inactive
+// tokens, which have expired or been revoked, report nothing else.
+type TokenIntrospection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientId  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Nbf       int64  `json:"nbf,omitempty"`
+	Aud       string `json:"aud,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+}
+
+// Identity retrieves the user and org of the session from the identity url issued with the token.
+func (forceApi *ForceApi) Identity() (*Identity, error) {
+	return forceApi.IdentityContext(context.Background())
+}
+
+// IdentityContext is like Identity but carries a context.
+func (forceApi *ForceApi) IdentityContext(ctx context.Context) (*Identity, error) {
+	id := forceApi.oauth.currentToken().Id
+	if len(id) == 0 {
+		return nil, fmt.Errorf("No identity url was issued with the token")
+	}
+
+	identity := &Identity{}
+	if err := forceApi.GetContext(ctx, id, nil, identity); err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+// UserInfo retrieves the user of the session from the OpenID Connect userinfo endpoint.
+func (forceApi *ForceApi) UserInfo() (*UserInfo, error) {
+	return forceApi.UserInfoContext(context.Background())
+}
+
+// UserInfoContext is like UserInfo but carries a context.
+func (forceApi *ForceApi) UserInfoContext(ctx context.Context) (*UserInfo, error) {
+	userInfo := &UserInfo{}
+	if err := forceApi.GetContext(ctx, userInfoUri, nil, userInfo); err != nil {
+		return nil, err
+	}
+
+	return userInfo, nil
+}
+
+// Introspect asks the connected app whether token is active, and who it was issued to. An empty
+// token introspects the current access token. The client secret must be configured.
+func (forceApi *ForceApi) Introspect(token string) (*TokenIntrospection, error) {
+	return forceApi.IntrospectContext(context.Background(), token)
+}
+
+// IntrospectContext is like Introspect but carries a context.
+func (forceApi *ForceApi) IntrospectContext(ctx context.Context, token string) (*TokenIntrospection, error) {
+	oauth := forceApi.oauth
+	current := oauth.currentToken()
+	if len(token) == 0 {
+		token = current.AccessToken
+	}
+
+	payload := url.Values{
+		"token":           {token},
+		"token_type_hint": {"access_token"},
+		"client_id":       {oauth.clientId},
+		"client_secret":   {oauth.clientSecret},
+	}
+	if token == current.RefreshToken {
+		payload.Set("token_type_hint", "refresh_token")
+	}
+
+	introspection := &TokenIntrospection{}
+	if err := oauth.postForm(ctx, current.InstanceUrl+introspectUri, payload, introspection); err != nil {
+		return nil, err
+	}
+
+	return introspection, nil
+}
+
+// Revoke logs out by revoking the session. When a refresh token is held it's revoked, which also
+// revokes the access tokens issued from it. The ForceApi can't be used afterwards. The token is
+// also removed from the store given with WithTokenStore; a source given with WithTokenSource must
+// be cleared by the caller, although the revoked token isn't taken from it again.
+func (forceApi *ForceApi) Revoke() error {
+	return forceApi.RevokeContext(context.Background())
+}
+
+// RevokeContext is like Revoke but carries a context.
+func (forceApi *ForceApi) RevokeContext(ctx context.Context) error {
+	oauth := forceApi.oauth
+	current := oauth.currentToken()
+	token := current.RefreshToken
+	if len(token) == 0 {
+		token = current.AccessToken
+	}
+
+	if err := oauth.postForm(ctx, current.InstanceUrl+revokeUri, url.Values{"token": {token}}, nil); err != nil {
+		return err
+	}
+
+	// Keep the instance url so the reason requests fail afterwards is the missing token.
+	revoked := Token{InstanceUrl: current.InstanceUrl}
+	oauth.setToken(revoked)
+	if oauth.store != nil {
+		if err := oauth.store.SaveToken(ctx, &revoked); err != nil {
+			return fmt.Errorf("Unable to save token: %v", err)
+		}
+	}
+
+	return nil
+}
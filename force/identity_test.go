@@ -0,0 +1,113 @@
+package force
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestIdentityAndUserInfo(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-access-token" {
+			t.Errorf("Expected the access token on %v", r.URL.Path)
+		}
+		switch r.URL.Path {
+		case "/id/00DA/005A":
+			fmt.Fprint(w, `{"id":"https://login.salesforce.com/id/00DA/005A","user_id":"005A","organization_id":"00DA",`+
+				`"username":"user@example.com","display_name":"Example User","active":true,"utcOffset":-28800000,`+
+				`"status":{"created_date":null,"body":null},"urls":{"rest":"https://example.my.salesforce.com/services/data/v{version}/"}}`)
+		case userInfoUri:
+			fmt.Fprint(w, `{"sub":"https://login.salesforce.com/id/00DA/005A","preferred_username":"user@example.com","email_verified":true}`)
+		default:
+			t.Errorf("Unexpected request: %v %v", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	forceApi.oauth.Id = server.URL + "/id/00DA/005A"
+
+	identity, err := forceApi.Identity()
+	if err != nil {
+		t.Fatalf("Failed to get identity: %v", err)
+	}
+	if identity.UserId != "005A" || identity.OrganizationId != "00DA" || !identity.Active ||
+		identity.UtcOffset != -28800000 || identity.Urls["rest"] == "" {
+		t.Fatalf("Unexpected identity: %+v", identity)
+	}
+
+	// The access token isn't sent to other hosts.
+	if err := forceApi.Get("https://example.com/id/00DA/005A", nil, &Identity{}); err == nil {
+		t.Fatal("Expected an error requesting a url of another host")
+	}
+
+	userInfo, err := forceApi.UserInfo()
+	if err != nil {
+		t.Fatalf("Failed to get user info: %v", err)
+	}
+	if userInfo.PreferredUsername != "user@example.com" || !userInfo.EmailVerified {
+		t.Fatalf("Unexpected user info: %+v", userInfo)
+	}
+}
+
+func TestIntrospectAndRevoke(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case introspectUri:
+			if r.FormValue("token") != "test-access-token" || r.FormValue("client_id") != testClientId ||
+				r.FormValue("token_type_hint") != "access_token" {
+				t.Errorf("Unexpected introspection request: %v", r.Form)
+			}
+			fmt.Fprint(w, `{"active":true,"scope":"api","username":"user@example.com","exp":1700000000}`)
+		case revokeUri:
+			if r.FormValue("token") != "refresh" {
+				t.Errorf("Expected the refresh token to be revoked, got %v", r.Form)
+			}
+		default:
+			t.Errorf("Unexpected request: %v %v", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	introspection, err := forceApi.Introspect("")
+	if err != nil {
+		t.Fatalf("Failed to introspect token: %v", err)
+	}
+	if !introspection.Active || introspection.Username != "user@example.com" || introspection.Exp != 1700000000 {
+		t.Fatalf("Unexpected introspection: %+v", introspection)
+	}
+
+	store := NewMemoryTokenStore()
+	WithTokenStore(store)(forceApi)
+	forceApi.oauth.RefreshToken = "refresh"
+	if err := forceApi.Revoke(); err != nil {
+		t.Fatalf("Failed to revoke token: %v", err)
+	}
+	if forceApi.GetAccessToken() != "" {
+		t.Fatalf("Expected the token to be forgotten")
+	}
+	if token, _ := store.Token(context.Background()); token == nil || token.AccessToken != "" || token.RefreshToken != "" {
+		t.Fatalf("Expected the token to be removed from the store, got %+v", token)
+	}
+}
+
+func TestRevokeError(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":"unsupported_token_type","error_description":"this token type is not supported"}`)
+	}))
+	defer server.Close()
+
+	err := forceApi.Revoke()
+	apiError := &ApiError{}
+	if !errors.As(err, &apiError) || apiError.ErrorName != "unsupported_token_type" {
+		t.Fatalf("Expected an ApiError, got %#v", err)
+	}
+	requestError := &RequestError{}
+	if !errors.As(err, &requestError) || requestError.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected a RequestError, got %#v", err)
+	}
+}
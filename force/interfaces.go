@@ -0,0 +1,47 @@
+package force
+
+import (
+	"context"
+)
+
+//go:generate moq -out forcemock/querier.go -pkg forcemock . Querier
+//go:generate moq -out forcemock/sobject_crud.go -pkg forcemock . SObjectCRUD
+//go:generate moq -out forcemock/describer.go -pkg forcemock . Describer
+
+// Querier runs SOQL queries. It is satisfied by *ForceApi, so code depending on Querier rather than
+// ForceApi can be tested with forcemock.QuerierMock.
+type Querier interface {
+	Query(query string, out interface{}) error
+	QueryContext(ctx context.Context, query string, out interface{}) error
+	QueryAll(query string, out interface{}) error
+	QueryAllContext(ctx context.Context, query string, out interface{}) error
+	QueryNext(uri string, out interface{}) error
+	QueryNextContext(ctx context.Context, uri string, out interface{}) error
+}
+
+// SObjectCRUD creates, reads, updates and deletes sobjects. It is satisfied by *ForceApi and
+// forcemock.SObjectCRUDMock.
+type SObjectCRUD interface {
+	GetSObject(id string, fields []string, out SObject) error
+	GetSObjectContext(ctx context.Context, id string, fields []string, out SObject) error
+	InsertSObject(in SObject) (*SObjectResponse, error)
+	InsertSObjectContext(ctx context.Context, in SObject) (*SObjectResponse, error)
+	UpdateSObject(id string, in SObject) error
+	UpdateSObjectContext(ctx context.Context, id string, in SObject) error
+	UpsertSObjectByExternalId(id string, in SObject) (*SObjectResponse, error)
+	UpsertSObjectByExternalIdContext(ctx context.Context, id string, in SObject) (*SObjectResponse, error)
+	DeleteSObject(id string, in SObject) error
+	DeleteSObjectContext(ctx context.Context, id string, in SObject) error
+}
+
+// Describer describes sobjects. It is satisfied by *ForceApi and forcemock.DescriberMock.
+type Describer interface {
+	DescribeSObject(in SObject) (*SObjectDescription, error)
+	DescribeSObjectContext(ctx context.Context, in SObject) (*SObjectDescription, error)
+}
+
+var (
+	_ Querier     = (*ForceApi)(nil)
+	_ SObjectCRUD = (*ForceApi)(nil)
+	_ Describer   = (*ForceApi)(nil)
+)
@@ -0,0 +1,103 @@
+package force
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"time"
+)
+
+const (
+	jwtBearerGrantType = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+
+	// Salesforce accepts assertions that expire within 3 minutes.
+	jwtLifetime = 3 * time.Minute
+)
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+}
+
+type jwtClaims struct {
+	Issuer     string `json:"iss"`
+	Subject    string `json:"sub"`
+	Audience   string `json:"aud"`
+	Expiration int64  `json:"exp"`
+}
+
+// jwtAssertion returns a JWT, signed with RS256, asserting the identity of the user to the
+// connected app.
+func (oauth *forceOauth) jwtAssertion() (string, error) {
+	header, err := json.Marshal(&jwtHeader{Algorithm: "RS256"})
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(&jwtClaims{
+		Issuer:     oauth.clientId,
+		Subject:    oauth.userName,
+		Audience:   oauth.audienceOrDefault(),
+		Expiration: time.Now().Add(jwtLifetime).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	encoding := base64.RawURLEncoding
+	unsigned := encoding.EncodeToString(header) + "." + encoding.EncodeToString(claims)
+
+	hashed := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, oauth.privateKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", fmt.Errorf("Error signing jwt assertion: %v", err)
+	}
+
+	return unsigned + "." + encoding.EncodeToString(signature), nil
+}
+
+// audienceOrDefault returns the configured audience, falling back to the login server tokens are
+// requested from.
+func (oauth *forceOauth) audienceOrDefault() string {
+	if len(oauth.audience) > 0 {
+		return oauth.audience
+	}
+	if len(oauth.loginUrl) > 0 {
+		return oauth.loginUrl
+	}
+	if oauth.environment == "sandbox" {
+		return testLoginUri
+	}
+
+	return loginUri
+}
+
+// ParseRSAPrivateKey parses a PEM encoded RSA private key in PKCS #1 or PKCS #8 form, such as the
+// key of the certificate uploaded to a connected app.
+func ParseRSAPrivateKey(pemBytes []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, fmt.Errorf("Unable to parse private key: no PEM data found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse private key: %v", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("Unable to parse private key: expected an RSA key, got %T", key)
+	}
+
+	return rsaKey, nil
+}
@@ -0,0 +1,124 @@
+package force
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nimajalali/go-force/sobjects"
+)
+
+func TestCreateWithJWT(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Unable to generate key: %v", err)
+	}
+
+	var server *httptest.Server
+	tokens := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc(tokenUri, func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("grant_type") != jwtBearerGrantType {
+			t.Errorf("Unexpected grant type: %v", r.FormValue("grant_type"))
+		}
+
+		parts := strings.Split(r.FormValue("assertion"), ".")
+		if len(parts) != 3 {
+			t.Fatalf("Malformed assertion: %v", r.FormValue("assertion"))
+		}
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		hashed := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], signature); err != nil {
+			t.Errorf("Invalid assertion signature: %v", err)
+		}
+
+		claimBytes, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		claims := map[string]interface{}{}
+		json.Unmarshal(claimBytes, &claims)
+		if claims["iss"] != "consumer-key" || claims["sub"] != "user@example.com" || claims["aud"] != server.URL {
+			t.Errorf("Unexpected claims: %v", claims)
+		}
+
+		tokens++
+		fmt.Fprintf(w, `{"access_token":"token%v","instance_url":"%v","token_type":"Bearer"}`, tokens, server.URL)
+	})
+	resources := fmt.Sprintf(resourcesUri, testVersion)
+	mux.HandleFunc(resources, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"sobjects":"%v/sobjects"}`, resources)
+	})
+	mux.HandleFunc(resources+"/sobjects", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"sobjects":[]}`)
+	})
+	mux.HandleFunc(resources+"/sobjects/Account/001A", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `[{"message":"Session expired or invalid","errorCode":"INVALID_SESSION_ID"}]`)
+			return
+		}
+		fmt.Fprint(w, `{"Id":"001A","Name":"Acme"}`)
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	forceApi, err := CreateWithJWT(testVersion, "consumer-key", "user@example.com", server.URL, key)
+	if err != nil {
+		t.Fatalf("Unable to create ForceApi with jwt: %v", err)
+	}
+	if forceApi.GetAccessToken() != "token1" {
+		t.Fatalf("Unexpected access token: %v", forceApi.GetAccessToken())
+	}
+
+	account := &sobjects.Account{}
+	if err := forceApi.Get(resources+"/sobjects/Account/001A", nil, account); err != nil {
+		t.Fatalf("Expected the expired session to be renewed: %v", err)
+	}
+	if tokens != 2 || account.Name != "Acme" {
+		t.Fatalf("Expected a second assertion to be exchanged, got %v tokens and %+v", tokens, account)
+	}
+}
+
+func TestCreateWithJWTNilKey(t *testing.T) {
+	_, err := CreateWithOptions(WithJWTBearer("consumer-key", "user@example.com", "https://login.salesforce.com", nil))
+	if err == nil {
+		t.Fatal("Expected an error creating a ForceApi with a nil private key")
+	}
+}
+
+func TestParseRSAPrivateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Unable to generate key: %v", err)
+	}
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Unable to marshal key: %v", err)
+	}
+
+	for name, block := range map[string]*pem.Block{
+		"pkcs1": {Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)},
+		"pkcs8": {Type: "PRIVATE KEY", Bytes: pkcs8},
+	} {
+		parsed, err := ParseRSAPrivateKey(pem.EncodeToMemory(block))
+		if err != nil {
+			t.Fatalf("Unable to parse %v key: %v", name, err)
+		}
+		if !parsed.Equal(key) {
+			t.Fatalf("Parsed %v key does not match", name)
+		}
+	}
+
+	if _, err := ParseRSAPrivateKey([]byte("not a key")); err == nil {
+		t.Fatal("Expected an error parsing invalid PEM data")
+	}
+}
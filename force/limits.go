@@ -1,5 +1,22 @@
 package force
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	limitInfoHeader = "Sforce-Limit-Info"
+	apiUsageKey     = "api-usage"
+)
+
+// ErrApiUsageLimit is returned for requests refused by the ApiUsageThrottle.
+var ErrApiUsageLimit = errors.New("Request refused, the daily API request limit is nearly used up")
+
 type Limits map[string]Limit
 
 type Limit struct {
@@ -8,10 +25,145 @@ type Limit struct {
 }
 
 func (forceApi *ForceApi) GetLimits() (limits *Limits, err error) {
+	return forceApi.GetLimitsContext(context.Background())
+}
+
+// GetLimitsContext is like GetLimits but carries a context.
+func (forceApi *ForceApi) GetLimitsContext(ctx context.Context) (limits *Limits, err error) {
 	uri := forceApi.apiResources[limitsKey]
 
 	limits = &Limits{}
-	err = forceApi.Get(uri, nil, limits)
+	err = forceApi.GetContext(ctx, uri, nil, limits)
 
 	return
 }
+
+// ApiUsage is the number of API requests the org has made in the last 24 hours, out of its daily
+// limit, as reported by the Sforce-Limit-Info header of a response.
+type ApiUsage struct {
+	Used    int
+	Max     int
+	Updated time.Time
+}
+
+// Fraction returns the fraction of the daily limit that has been used.
+func (usage ApiUsage) Fraction() float64 {
+	if usage.Max <= 0 {
+		return 0
+	}
+
+	return float64(usage.Used) / float64(usage.Max)
+}
+
+// Remaining returns the number of requests left before the daily limit is reached.
+func (usage ApiUsage) Remaining() int {
+	return usage.Max - usage.Used
+}
+
+// ApiUsage returns the API usage reported with the latest response. It returns false if no
+// response has reported it yet.
+func (forceApi *ForceApi) ApiUsage() (ApiUsage, bool) {
+	forceApi.usageMu.Lock()
+	defer forceApi.usageMu.Unlock()
+
+	return forceApi.apiUsage, !forceApi.apiUsage.Updated.IsZero()
+}
+
+// ApiUsageThrottle slows down, then refuses, requests as the daily API request limit is used up,
+// leaving the rest of the limit for critical requests, see CriticalContext. Zero values disable
+// the corresponding behaviour.
+type ApiUsageThrottle struct {
+	// Fraction of the daily limit, such as 0.8, from which non-critical requests are delayed.
+	SlowDownAt float64
+	// Delay before each non-critical request once SlowDownAt is reached.
+	Delay time.Duration
+	// Fraction of the daily limit, such as 0.95, from which non-critical requests are refused
+	// with ErrApiUsageLimit.
+	RefuseAt float64
+}
+
+type criticalContextKey struct{}
+
+// CriticalContext marks the requests made with the returned context as critical, so they are
+// neither delayed nor refused by the ApiUsageThrottle.
+func CriticalContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, criticalContextKey{}, true)
+}
+
+func isCritical(ctx context.Context) bool {
+	critical, _ := ctx.Value(criticalContextKey{}).(bool)
+	return critical
+}
+
+// throttle delays or refuses a request according to the throttle and the latest API usage.
+func (forceApi *ForceApi) throttle(ctx context.Context) error {
+	throttle := forceApi.usageThrottle
+	if throttle == nil || isCritical(ctx) {
+		return nil
+	}
+
+	usage, ok := forceApi.ApiUsage()
+	if !ok {
+		return nil
+	}
+
+	fraction := usage.Fraction()
+	if throttle.RefuseAt > 0 && fraction >= throttle.RefuseAt {
+		return ErrApiUsageLimit
+	}
+	if throttle.SlowDownAt > 0 && fraction >= throttle.SlowDownAt && throttle.Delay > 0 {
+		timer := time.NewTimer(throttle.Delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// recordApiUsage stores the API usage reported by header, and calls the usage callback for every
+// threshold the usage has risen past since the previous response.
+func (forceApi *ForceApi) recordApiUsage(header http.Header) {
+	usage, ok := parseApiUsage(header.Get(limitInfoHeader))
+	if !ok {
+		return
+	}
+	usage.Updated = time.Now()
+
+	forceApi.usageMu.Lock()
+	previous := forceApi.apiUsage.Fraction()
+	forceApi.apiUsage = usage
+	forceApi.usageMu.Unlock()
+
+	if forceApi.usageCallback == nil {
+		return
+	}
+	for _, threshold := range forceApi.usageThresholds {
+		if previous < threshold && usage.Fraction() >= threshold {
+			forceApi.usageCallback(usage, threshold)
+		}
+	}
+}
+
+// parseApiUsage parses the api usage of a Sforce-Limit-Info header such as
+// "api-usage=18/15000, per-app-api-usage=17/250(appName=sample-app)".
+func parseApiUsage(limitInfo string) (ApiUsage, bool) {
+	for _, entry := range strings.Split(limitInfo, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || key != apiUsageKey {
+			continue
+		}
+
+		usage := ApiUsage{}
+		if _, err := fmt.Sscanf(value, "%d/%d", &usage.Used, &usage.Max); err != nil {
+			return ApiUsage{}, false
+		}
+		return usage, true
+	}
+
+	return ApiUsage{}, false
+}
@@ -1,7 +1,11 @@
 package force
 
 import (
+	"context"
+	"fmt"
+	"net/http"
 	"testing"
+	"time"
 )
 
 func TestLimits(t *testing.T) {
@@ -15,3 +19,71 @@ func TestLimits(t *testing.T) {
 
 	t.Log(limits)
 }
+
+func TestParseApiUsage(t *testing.T) {
+	usage, ok := parseApiUsage("per-app-api-usage=17/250(appName=sample-app), api-usage=18/15000")
+	if !ok || usage.Used != 18 || usage.Max != 15000 || usage.Remaining() != 14982 {
+		t.Fatalf("Unexpected api usage: %+v", usage)
+	}
+
+	for _, limitInfo := range []string{"", "api-usage=", "per-app-api-usage=17/250(appName=sample-app)"} {
+		if usage, ok := parseApiUsage(limitInfo); ok {
+			t.Fatalf("Expected no api usage in %q, got %+v", limitInfo, usage)
+		}
+	}
+}
+
+func TestApiUsage(t *testing.T) {
+	used := 0
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(limitInfoHeader, fmt.Sprintf("api-usage=%v/100", used))
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	var crossed []float64
+	WithApiUsageCallback([]float64{0.5, 0.9}, func(usage ApiUsage, threshold float64) {
+		crossed = append(crossed, threshold)
+	})(forceApi)
+	WithApiUsageThrottle(&ApiUsageThrottle{RefuseAt: 0.95})(forceApi)
+
+	for _, used = range []int{10, 60, 70, 95} {
+		if err := forceApi.Get("/limits", nil, nil); err != nil {
+			t.Fatalf("Unexpected error at %v%% usage: %v", used, err)
+		}
+	}
+
+	usage, ok := forceApi.ApiUsage()
+	if !ok || usage.Used != 95 || usage.Max != 100 || usage.Updated.IsZero() {
+		t.Fatalf("Unexpected api usage: %+v", usage)
+	}
+	if fmt.Sprint(crossed) != "[0.5 0.9]" {
+		t.Fatalf("Expected each threshold to be reported once, got %v", crossed)
+	}
+
+	if err := forceApi.Get("/limits", nil, nil); err != ErrApiUsageLimit {
+		t.Fatalf("Expected the request to be refused, got %v", err)
+	}
+	if err := forceApi.GetContext(CriticalContext(context.Background()), "/limits", nil, nil); err != nil {
+		t.Fatalf("Expected the critical request to be sent: %v", err)
+	}
+}
+
+func TestApiUsageThrottleDelay(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(limitInfoHeader, "api-usage=85/100")
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+	forceApi.usageThrottle = &ApiUsageThrottle{SlowDownAt: 0.8, Delay: time.Hour}
+
+	if err := forceApi.Get("/limits", nil, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := forceApi.GetContext(ctx, "/limits", nil, nil); err != context.DeadlineExceeded {
+		t.Fatalf("Expected the request to be delayed, got %v", err)
+	}
+}
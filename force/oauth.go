@@ -1,46 +1,97 @@
 package force
 
 import (
+	"context"
+	"crypto/rsa"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 )
 
 const (
-	grantType    = "password"
-	loginUri     = "https://login.salesforce.com/services/oauth2/token"
-	testLoginUri = "https://test.salesforce.com/services/oauth2/token"
+	grantType                  = "password"
+	clientCredentialsGrantType = "client_credentials"
+	refreshTokenGrantType      = "refresh_token"
+	loginUri                   = "https://login.salesforce.com"
+	testLoginUri               = "https://test.salesforce.com"
+	tokenUri                   = "/services/oauth2/token"
 
 	invalidSessionErrorCode = "INVALID_SESSION_ID"
 )
 
+// Token holds the credentials issued by the oauth token endpoint. RefreshToken is only issued by
+// flows that support refreshing, and is replaced when the connected app rotates refresh tokens.
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	InstanceUrl  string `json:"instance_url"`
+	Id           string `json:"id"`
+	IssuedAt     string `json:"issued_at"`
+	Signature    string `json:"signature"`
+	TokenType    string `json:"token_type,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// forceOauth holds the credentials of a ForceApi. The embedded Token is replaced as sessions are
+// renewed, so it must be read with currentToken and written with setToken.
 type forceOauth struct {
-	AccessToken string `json:"access_token"`
-	InstanceUrl string `json:"instance_url"`
-	Id          string `json:"id"`
-	IssuedAt    string `json:"issued_at"`
-	Signature   string `json:"signature"`
+	Token
+	mu      sync.RWMutex
+	flights flightGroup
 
 	clientId      string
 	clientSecret  string
-	refreshToken  string
 	userName      string
 	password      string
 	securityToken string
 	environment   string
+	loginUrl      string
+	flow          string
+	audience      string
+	privateKey    *rsa.PrivateKey
+
+	client        *http.Client
+	userAgent     string
+	tokenCallback func(token *Token)
+	source        TokenSource
+	store         TokenStore
+	// Access token last taken from source, guarded by mu.
+	sourceToken string
 }
 
 func (oauth *forceOauth) Validate() error {
-	if oauth == nil || len(oauth.InstanceUrl) == 0 || len(oauth.AccessToken) == 0 {
-		return fmt.Errorf("Invalid Force Oauth Object: %#v", oauth)
+	if oauth == nil {
+		return fmt.Errorf("Invalid Force Oauth Object: nil")
+	}
+
+	token := oauth.currentToken()
+	if len(token.InstanceUrl) == 0 || len(token.AccessToken) == 0 {
+		return fmt.Errorf("Invalid Force Oauth Object: missing instance url or access token")
 	}
 
 	return nil
 }
 
+// currentToken returns a copy of the held token.
+func (oauth *forceOauth) currentToken() Token {
+	oauth.mu.RLock()
+	defer oauth.mu.RUnlock()
+
+	return oauth.Token
+}
+
+// setToken replaces the held token.
+func (oauth *forceOauth) setToken(token Token) {
+	oauth.mu.Lock()
+	defer oauth.mu.Unlock()
+
+	oauth.Token = token
+}
+
 func (oauth *forceOauth) Expired(apiErrors ApiErrors) bool {
 	for _, err := range apiErrors {
 		if err.ErrorCode == invalidSessionErrorCode {
@@ -51,58 +102,256 @@ func (oauth *forceOauth) Expired(apiErrors ApiErrors) bool {
 	return false
 }
 
-func (oauth *forceOauth) Authenticate() error {
-	payload := url.Values{
-		"grant_type":    {grantType},
-		"client_id":     {oauth.clientId},
-		"client_secret": {oauth.clientSecret},
-		"username":      {oauth.userName},
-		"password":      {fmt.Sprintf("%v%v", oauth.password, oauth.securityToken)},
+// Authenticate requests a new access token. A held refresh token is used in preference to the
+// configured flow, so an expired session is refreshed rather than authenticated from scratch.
+func (oauth *forceOauth) Authenticate(ctx context.Context) error {
+	payload, err := oauth.grant()
+	if err != nil {
+		return err
+	}
+
+	return oauth.requestToken(ctx, payload)
+}
+
+// Refresh requests a new access token using the refresh token.
+func (oauth *forceOauth) Refresh(ctx context.Context) error {
+	if len(oauth.currentToken().RefreshToken) == 0 {
+		return fmt.Errorf("Unable to refresh access token: no refresh token")
+	}
+
+	return oauth.requestToken(ctx, oauth.refreshGrant())
+}
+
+// requestToken posts payload to the token endpoint and stores the issued token.
+func (oauth *forceOauth) requestToken(ctx context.Context, payload url.Values) error {
+	// Fields missing from the response, such as the refresh token of a refresh grant, are kept.
+	token := oauth.currentToken()
+	if err := oauth.postForm(ctx, oauth.tokenUrl(), payload, &token); err != nil {
+		return err
+	}
+	oauth.setToken(token)
+
+	if oauth.store != nil {
+		saved := token
+		if err := oauth.store.SaveToken(ctx, &saved); err != nil {
+			return fmt.Errorf("Unable to save token: %v", err)
+		}
 	}
 
-	// Build Uri
-	uri := loginUri
-	if oauth.environment == "sandbox" {
-		uri = testLoginUri
+	if oauth.tokenCallback != nil {
+		issued := token
+		oauth.tokenCallback(&issued)
 	}
 
+	return nil
+}
+
+// postForm posts payload, form encoded, to an oauth endpoint and unmarshals the json response
+// into out, which may be nil if no response is expected.
+func (oauth *forceOauth) postForm(ctx context.Context, uri string, payload url.Values, out interface{}) error {
 	// Build Body
 	body := strings.NewReader(payload.Encode())
 
 	// Build Request
-	req, err := http.NewRequest("POST", uri, body)
+	req, err := http.NewRequestWithContext(ctx, "POST", uri, body)
 	if err != nil {
-		return fmt.Errorf("Error creating authentication request: %v", err)
+		return fmt.Errorf("Error creating oauth request: %v", err)
 	}
 
 	// Add Headers
-	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("User-Agent", oauth.userAgentOrDefault())
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("Accept", responseType)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := oauth.httpClient().Do(req)
 	if err != nil {
-		return fmt.Errorf("Error sending authentication request: %v", err)
+		return fmt.Errorf("Error sending oauth request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBytes, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("Error reading authentication response bytes: %v", err)
+		return fmt.Errorf("Error reading oauth response bytes: %v", err)
 	}
 
 	// Attempt to parse response as a force.com api error
 	apiError := &ApiError{}
-	if err := json.Unmarshal(respBytes, apiError); err == nil {
-		// Check if api error is valid
-		if apiError.Validate() {
-			return apiError
+	if err := json.Unmarshal(respBytes, apiError); err != nil || !apiError.Validate() {
+		apiError = nil
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		requestError := newRequestError(resp, nil, respBytes)
+		if apiError != nil {
+			requestError.Err, requestError.Body = apiError, nil
 		}
+		return requestError
+	}
+	if apiError != nil {
+		return apiError
 	}
 
-	if err := json.Unmarshal(respBytes, oauth); err != nil {
-		return fmt.Errorf("Unable to unmarshal authentication response: %v", err)
+	if out == nil || len(respBytes) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(respBytes, out); err != nil {
+		return fmt.Errorf("Unable to unmarshal oauth response: %v", err)
 	}
 
 	return nil
 }
+
+// loadToken replaces the held token with the one supplied by the token source, if any, unless
+// the source still supplies the token last taken from it. A token renewed by the ForceApi, which
+// a plain TokenSource isn't given, is so kept until the source supplies a new one.
+func (oauth *forceOauth) loadToken(ctx context.Context) error {
+	if oauth.source == nil {
+		return nil
+	}
+
+	token, err := oauth.source.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("Error getting token: %v", err)
+	}
+	if token == nil || len(token.AccessToken) == 0 {
+		return nil
+	}
+
+	oauth.mu.Lock()
+	defer oauth.mu.Unlock()
+
+	if token.AccessToken != oauth.sourceToken {
+		oauth.sourceToken = token.AccessToken
+		oauth.Token = *token
+	}
+
+	return nil
+}
+
+// reauthenticate renews the session after expiredToken was rejected. When the token source
+// already supplies a different token, such as one renewed by another process, that token is used.
+// Otherwise a new token is requested, provided there are credentials to request it with.
+// Concurrent callers share a single renewal, and those whose expired token has already been
+// replaced return without one.
+func (oauth *forceOauth) reauthenticate(ctx context.Context, expiredToken string) error {
+	if oauth.currentToken().AccessToken != expiredToken {
+		return nil
+	}
+
+	// The renewal isn't tied to the context of whichever caller started it, so that one caller
+	// giving up doesn't fail the others waiting on it, but it's bounded by flightTimeout.
+	_, err := oauth.flights.do(ctx, "reauthenticate", func(renewCtx context.Context) (interface{}, error) {
+		if err := oauth.loadToken(renewCtx); err != nil {
+			return nil, err
+		}
+		if oauth.currentToken().AccessToken != expiredToken {
+			return nil, nil
+		}
+
+		if !oauth.canAuthenticate() {
+			return nil, fmt.Errorf("Session expired and no credentials are available to renew it")
+		}
+
+		return nil, oauth.Authenticate(renewCtx)
+	})
+
+	return err
+}
+
+// canAuthenticate reports whether a flow, or refresh token, is configured to request tokens with.
+func (oauth *forceOauth) canAuthenticate() bool {
+	return len(oauth.currentToken().RefreshToken) > 0 || len(oauth.flow) > 0 || len(oauth.userName) > 0
+}
+
+// grant returns the token request parameters of the configured oauth flow, the password flow
+// unless another was chosen.
+func (oauth *forceOauth) grant() (url.Values, error) {
+	if len(oauth.currentToken().RefreshToken) > 0 {
+		return oauth.refreshGrant(), nil
+	}
+
+	switch oauth.flow {
+	case jwtBearerGrantType:
+		assertion, err := oauth.jwtAssertion()
+		if err != nil {
+			return nil, err
+		}
+
+		return url.Values{
+			"grant_type": {jwtBearerGrantType},
+			"assertion":  {assertion},
+		}, nil
+
+	case clientCredentialsGrantType:
+		// The client credentials flow is only served by the My Domain of the org.
+		base := strings.TrimSuffix(oauth.loginUrl, "/")
+		if len(base) == 0 || base == loginUri || base == testLoginUri {
+			return nil, fmt.Errorf("The client credentials flow requires a My Domain login url, got %q", oauth.loginUrl)
+		}
+
+		return url.Values{
+			"grant_type":    {clientCredentialsGrantType},
+			"client_id":     {oauth.clientId},
+			"client_secret": {oauth.clientSecret},
+		}, nil
+	}
+
+	return url.Values{
+		"grant_type":    {grantType},
+		"client_id":     {oauth.clientId},
+		"client_secret": {oauth.clientSecret},
+		"username":      {oauth.userName},
+		"password":      {fmt.Sprintf("%v%v", oauth.password, oauth.securityToken)},
+	}, nil
+}
+
+func (oauth *forceOauth) refreshGrant() url.Values {
+	payload := url.Values{
+		"grant_type":    {refreshTokenGrantType},
+		"refresh_token": {oauth.currentToken().RefreshToken},
+		"client_id":     {oauth.clientId},
+	}
+	if len(oauth.clientSecret) > 0 {
+		payload.Set("client_secret", oauth.clientSecret)
+	}
+
+	return payload
+}
+
+// tokenUrl returns the token endpoint of the configured login url. Refresh tokens are otherwise
+// redeemed at the instance, which serves orgs in both production and sandboxes, and other flows
+// fall back to the production or sandbox login servers based on environment.
+func (oauth *forceOauth) tokenUrl() string {
+	base := oauth.loginUrl
+	if len(base) == 0 {
+		base = oauth.audience
+	}
+	if token := oauth.currentToken(); len(base) == 0 && len(token.RefreshToken) > 0 {
+		base = token.InstanceUrl
+	}
+	if len(base) == 0 {
+		base = loginUri
+		if oauth.environment == "sandbox" {
+			base = testLoginUri
+		}
+	}
+
+	return strings.TrimSuffix(base, "/") + tokenUri
+}
+
+func (oauth *forceOauth) httpClient() *http.Client {
+	if oauth.client == nil {
+		return http.DefaultClient
+	}
+
+	return oauth.client
+}
+
+func (oauth *forceOauth) userAgentOrDefault() string {
+	if len(oauth.userAgent) == 0 {
+		return userAgent
+	}
+
+	return oauth.userAgent
+}
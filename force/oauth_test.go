@@ -1,7 +1,14 @@
 package force
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestOauth(t *testing.T) {
@@ -11,3 +18,144 @@ func TestOauth(t *testing.T) {
 		t.Fatalf("Oauth object is invlaid: %#v", err)
 	}
 }
+
+func TestClientCredentials(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != tokenUri {
+			t.Errorf("Unexpected request: %v", r.URL.Path)
+		}
+		if r.FormValue("grant_type") != clientCredentialsGrantType || r.FormValue("client_id") != "id" ||
+			r.FormValue("client_secret") != "secret" || r.FormValue("username") != "" {
+			t.Errorf("Unexpected token request: %v", r.Form)
+		}
+		fmt.Fprintf(w, `{"access_token":"token","instance_url":"%v"}`, server.URL)
+	}))
+	defer server.Close()
+
+	oauth := &forceOauth{
+		clientId:     "id",
+		clientSecret: "secret",
+		loginUrl:     server.URL + "/",
+		flow:         clientCredentialsGrantType,
+	}
+	if err := oauth.Authenticate(context.Background()); err != nil {
+		t.Fatalf("Unable to authenticate: %v", err)
+	}
+	if oauth.AccessToken != "token" {
+		t.Fatalf("Unexpected access token: %v", oauth.AccessToken)
+	}
+}
+
+func TestClientCredentialsRequiresMyDomain(t *testing.T) {
+	for _, loginUrl := range []string{"", loginUri, testLoginUri + "/"} {
+		oauth := &forceOauth{clientId: "id", clientSecret: "secret", loginUrl: loginUrl, flow: clientCredentialsGrantType}
+		if err := oauth.Authenticate(context.Background()); err == nil {
+			t.Fatalf("Expected an error authenticating against %q", loginUrl)
+		}
+	}
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	var server *httptest.Server
+	var refreshTokens []string
+	mux := http.NewServeMux()
+	mux.HandleFunc(tokenUri, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/x-www-form-urlencoded" ||
+			r.FormValue("grant_type") != refreshTokenGrantType || r.FormValue("client_secret") != "secret" {
+			t.Errorf("Unexpected token request: %v", r.Form)
+		}
+		refreshTokens = append(refreshTokens, r.FormValue("refresh_token"))
+		n := len(refreshTokens)
+		fmt.Fprintf(w, `{"access_token":"access%v","refresh_token":"refresh%v","instance_url":"%v"}`, n, n, server.URL)
+	})
+	resources := fmt.Sprintf(resourcesUri, testVersion)
+	mux.HandleFunc(resources, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"sobjects":"%v/sobjects"}`, resources)
+	})
+	mux.HandleFunc(resources+"/sobjects", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"sobjects":[]}`)
+	})
+	mux.HandleFunc(resources+"/limits", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `[{"message":"Session expired or invalid","errorCode":"INVALID_SESSION_ID"}]`)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	var saved []*Token
+	forceApi, err := CreateWithOptions(
+		WithApiVersion(testVersion),
+		WithRefreshToken("id", "secret", "refresh0", server.URL),
+		WithTokenCallback(func(token *Token) {
+			saved = append(saved, token)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Unable to create ForceApi with a refresh token: %v", err)
+	}
+
+	if err := forceApi.Get(resources+"/limits", nil, &map[string]interface{}{}); err != nil {
+		t.Fatalf("Expected the expired session to be refreshed: %v", err)
+	}
+
+	if fmt.Sprint(refreshTokens) != "[refresh0 refresh1]" {
+		t.Fatalf("Expected the rotated refresh token to be used, got %v", refreshTokens)
+	}
+	if len(saved) != 2 || saved[1].AccessToken != "access2" || saved[1].RefreshToken != "refresh2" {
+		t.Fatalf("Expected every token to be passed to the callback, got %+v", saved)
+	}
+	if token := forceApi.GetToken(); token.AccessToken != "access2" || token.InstanceUrl != server.URL {
+		t.Fatalf("Unexpected token: %+v", token)
+	}
+}
+
+func TestConcurrentReauthentication(t *testing.T) {
+	var server *httptest.Server
+	var logins int32
+	mux := http.NewServeMux()
+	mux.HandleFunc(tokenUri, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&logins, 1)
+		// Hold the renewal so that every request finds the session expired while it's in flight.
+		time.Sleep(50 * time.Millisecond)
+		fmt.Fprintf(w, `{"access_token":"renewed","instance_url":"%v"}`, server.URL)
+	})
+	mux.HandleFunc("/limits", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer renewed" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `[{"message":"Session expired or invalid","errorCode":"INVALID_SESSION_ID"}]`)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	forceApi := newForceApi(testVersion, &forceOauth{
+		Token:    Token{AccessToken: "expired", RefreshToken: "refresh", InstanceUrl: server.URL},
+		clientId: "id",
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := forceApi.Get("/limits", nil, &map[string]interface{}{}); err != nil {
+				t.Errorf("Expected the expired session to be renewed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if logins != 1 {
+		t.Fatalf("Expected a single renewal, got %v", logins)
+	}
+	if token := forceApi.GetAccessToken(); token != "renewed" {
+		t.Fatalf("Unexpected access token: %v", token)
+	}
+}
@@ -0,0 +1,180 @@
+package force
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"net/http"
+)
+
+const (
+	DefaultApiVersion = "v58.0"
+)
+
+// Option configures a ForceApi created with CreateWithOptions.
+type Option func(*ForceApi)
+
+// WithHttpClient sets the http client used for data and oauth requests. Use it to configure
+// timeouts, proxies, client certificates or a custom http.RoundTripper.
+func WithHttpClient(client *http.Client) Option {
+	return func(forceApi *ForceApi) {
+		if client != nil {
+			forceApi.httpClient = client
+		}
+	}
+}
+
+// WithRetryPolicy retries requests that fail transiently, such as when a record is locked or the
+// server is unavailable, according to policy. Requests are not retried by default. The policy
+// also bounds how often a request reauthenticates, once by default.
+func WithRetryPolicy(policy *RetryPolicy) Option {
+	return func(forceApi *ForceApi) {
+		forceApi.retryPolicy = policy
+	}
+}
+
+// WithApiUsageCallback calls callback whenever the daily API usage, as reported with each
+// response, rises past one of thresholds, given as fractions of the limit such as 0.5 and 0.9.
+// It's called from the goroutine making the request, and must not block.
+func WithApiUsageCallback(thresholds []float64, callback func(usage ApiUsage, threshold float64)) Option {
+	return func(forceApi *ForceApi) {
+		forceApi.usageThresholds = thresholds
+		forceApi.usageCallback = callback
+	}
+}
+
+// WithApiUsageThrottle slows down, then refuses, non-critical requests as the daily API usage
+// nears the limit, see ApiUsageThrottle.
+func WithApiUsageThrottle(throttle *ApiUsageThrottle) Option {
+	return func(forceApi *ForceApi) {
+		forceApi.usageThrottle = throttle
+	}
+}
+
+// WithLoginUrl sets the base url that oauth tokens are requested from, such as
+// https://test.salesforce.com or a My Domain url. Defaults to https://login.salesforce.com.
+func WithLoginUrl(loginUrl string) Option {
+	return func(forceApi *ForceApi) {
+		forceApi.oauth.loginUrl = loginUrl
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(forceApi *ForceApi) {
+		forceApi.userAgent = userAgent
+	}
+}
+
+// WithApiVersion sets the version of the REST API, e.g. "v58.0". Defaults to DefaultApiVersion.
+func WithApiVersion(version string) Option {
+	return func(forceApi *ForceApi) {
+		forceApi.apiVersion = version
+	}
+}
+
+// WithPasswordCredentials authenticates using the username-password oauth flow.
+func WithPasswordCredentials(clientId, clientSecret, userName, password, securityToken string) Option {
+	return func(forceApi *ForceApi) {
+		forceApi.oauth.clientId = clientId
+		forceApi.oauth.clientSecret = clientSecret
+		forceApi.oauth.userName = userName
+		forceApi.oauth.password = password
+		forceApi.oauth.securityToken = securityToken
+	}
+}
+
+// WithAccessToken uses an access token that was obtained outside of go-force.
+func WithAccessToken(clientId, accessToken, instanceUrl string) Option {
+	return func(forceApi *ForceApi) {
+		forceApi.oauth.clientId = clientId
+		forceApi.oauth.AccessToken = accessToken
+		forceApi.oauth.InstanceUrl = instanceUrl
+	}
+}
+
+// WithJWTBearer authenticates using the oauth JWT bearer flow. An assertion for userName, signed
+// with privateKey, is exchanged for an access token by the connected app clientId; a new assertion
+// is signed whenever the session expires. audience is https://login.salesforce.com,
+// https://test.salesforce.com or a My Domain url, and is also where tokens are requested from
+// unless WithLoginUrl is given.
+func WithJWTBearer(clientId, userName, audience string, privateKey *rsa.PrivateKey) Option {
+	return func(forceApi *ForceApi) {
+		if privateKey == nil {
+			forceApi.optionErr = fmt.Errorf("Invalid private key: nil")
+			return
+		}
+		forceApi.oauth.clientId = clientId
+		forceApi.oauth.userName = userName
+		forceApi.oauth.audience = audience
+		forceApi.oauth.privateKey = privateKey
+		forceApi.oauth.flow = jwtBearerGrantType
+	}
+}
+
+// WithClientCredentials authenticates using the oauth client credentials flow, which runs as the
+// integration user of the connected app clientId. Tokens are requested from myDomainUrl, the My
+// Domain of the org such as https://example.my.salesforce.com, since the flow isn't available
+// from the shared login servers.
+func WithClientCredentials(clientId, clientSecret, myDomainUrl string) Option {
+	return func(forceApi *ForceApi) {
+		forceApi.oauth.clientId = clientId
+		forceApi.oauth.clientSecret = clientSecret
+		forceApi.oauth.loginUrl = myDomainUrl
+		forceApi.oauth.flow = clientCredentialsGrantType
+	}
+}
+
+// WithRefreshToken authenticates using the oauth refresh token flow. An access token is obtained
+// from refreshToken when the ForceApi is created and whenever the session expires. Tokens are
+// requested from instanceUrl unless WithLoginUrl is given. Combine with WithAccessToken to start
+// from a saved access token, and with WithTokenCallback to save rotated tokens.
+func WithRefreshToken(clientId, clientSecret, refreshToken, instanceUrl string) Option {
+	return func(forceApi *ForceApi) {
+		forceApi.oauth.clientId = clientId
+		forceApi.oauth.clientSecret = clientSecret
+		forceApi.oauth.RefreshToken = refreshToken
+		forceApi.oauth.InstanceUrl = instanceUrl
+	}
+}
+
+// WithTokenCallback sets a function called with every token issued to the ForceApi, whether on
+// creation or after the session expired, so new access tokens and rotated refresh tokens can be
+// persisted.
+func WithTokenCallback(callback func(token *Token)) Option {
+	return func(forceApi *ForceApi) {
+		forceApi.oauth.tokenCallback = callback
+	}
+}
+
+// WithToken uses a token obtained by WebServerFlow, or saved from an earlier session. The access
+// token is used until it expires, after which the refresh token, if any, is redeemed.
+func WithToken(clientId, clientSecret string, token *Token) Option {
+	return func(forceApi *ForceApi) {
+		if token == nil {
+			forceApi.optionErr = fmt.Errorf("Invalid token: nil")
+			return
+		}
+		forceApi.oauth.clientId = clientId
+		forceApi.oauth.clientSecret = clientSecret
+		forceApi.oauth.Token = *token
+	}
+}
+
+// WithTokenSource takes the token from source, which is consulted before each request. Tokens
+// obtained by the ForceApi itself, when the session expires, are not given to source; use
+// WithTokenStore to share them.
+func WithTokenSource(source TokenSource) Option {
+	return func(forceApi *ForceApi) {
+		forceApi.oauth.source = source
+	}
+}
+
+// WithTokenStore shares the session through store. A token found in the store is used instead of
+// authenticating, and every token the ForceApi is issued is saved to it, so processes sharing a
+// store authenticate once between them.
+func WithTokenStore(store TokenStore) Option {
+	return func(forceApi *ForceApi) {
+		forceApi.oauth.source = store
+		forceApi.oauth.store = store
+	}
+}
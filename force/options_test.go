@@ -0,0 +1,66 @@
+package force
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type recordingTransport struct {
+	paths      []string
+	userAgents []string
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.paths = append(t.paths, req.URL.Path)
+	t.userAgents = append(t.userAgents, req.Header.Get("User-Agent"))
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestCreateWithOptions(t *testing.T) {
+	const version = "v50.0"
+
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc(tokenUri, func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("grant_type") != grantType || r.FormValue("password") != "passtoken" {
+			t.Errorf("Unexpected token request: %v", r.Form)
+		}
+		fmt.Fprintf(w, `{"access_token":"token","instance_url":"%v"}`, server.URL)
+	})
+	mux.HandleFunc("/services/data/"+version, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"sobjects":"/services/data/%v/sobjects"}`, version)
+	})
+	mux.HandleFunc("/services/data/"+version+"/sobjects", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"sobjects":[]}`)
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	transport := &recordingTransport{}
+	forceApi, err := CreateWithOptions(
+		WithHttpClient(&http.Client{Transport: transport}),
+		WithLoginUrl(server.URL),
+		WithUserAgent("options-test"),
+		WithApiVersion(version),
+		WithPasswordCredentials("id", "secret", "user", "pass", "token"),
+	)
+	if err != nil {
+		t.Fatalf("Unable to create ForceApi with options: %v", err)
+	}
+
+	if forceApi.GetAccessToken() != "token" || forceApi.GetInstanceURL() != server.URL {
+		t.Fatalf("Unexpected oauth state: %+v", forceApi.oauth)
+	}
+
+	expected := []string{tokenUri, "/services/data/" + version, "/services/data/" + version + "/sobjects"}
+	if fmt.Sprint(transport.paths) != fmt.Sprint(expected) {
+		t.Fatalf("Expected requests %v through the custom client, got %v", expected, transport.paths)
+	}
+	for _, agent := range transport.userAgents {
+		if agent != "options-test" {
+			t.Fatalf("Expected custom user agent, got %q", agent)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package force
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"strings"
+
+	"github.com/nimajalali/go-force/forcejson"
 )
 
 const (
@@ -22,13 +25,18 @@ func BuildQuery(fields, table string, constraints []string) string {
 // Use the Query resource to execute a SOQL query that returns all the results in a single response,
 // or if needed, returns part of the results and an identifier used to retrieve the remaining results.
 func (forceApi *ForceApi) Query(query string, out interface{}) (err error) {
+	return forceApi.QueryContext(context.Background(), query, out)
+}
+
+// QueryContext is like Query but carries a context.
+func (forceApi *ForceApi) QueryContext(ctx context.Context, query string, out interface{}) (err error) {
 	uri := forceApi.apiResources[queryKey]
 
 	params := url.Values{
 		"q": {query},
 	}
 
-	err = forceApi.Get(uri, params, out)
+	err = forceApi.GetContext(ctx, uri, params, out)
 
 	return
 }
@@ -37,19 +45,187 @@ func (forceApi *ForceApi) Query(query string, out interface{}) (err error) {
 // been deleted because of a merge or delete. Use QueryAll rather than Query, because the Query resource
 // will automatically filter out items that have been deleted.
 func (forceApi *ForceApi) QueryAll(query string, out interface{}) (err error) {
+	return forceApi.QueryAllContext(context.Background(), query, out)
+}
+
+// QueryAllContext is like QueryAll but carries a context.
+func (forceApi *ForceApi) QueryAllContext(ctx context.Context, query string, out interface{}) (err error) {
 	uri := forceApi.apiResources[queryAllKey]
 
 	params := url.Values{
 		"q": {query},
 	}
 
-	err = forceApi.Get(uri, params, out)
+	err = forceApi.GetContext(ctx, uri, params, out)
 
 	return
 }
 
 func (forceApi *ForceApi) QueryNext(uri string, out interface{}) (err error) {
-	err = forceApi.Get(uri, nil, out)
+	return forceApi.QueryNextContext(context.Background(), uri, out)
+}
+
+// QueryNextContext is like QueryNext but carries a context.
+func (forceApi *ForceApi) QueryNextContext(ctx context.Context, uri string, out interface{}) (err error) {
+	err = forceApi.GetContext(ctx, uri, nil, out)
 
 	return
 }
+
+// QueryIter returns an iterator over the records matched by query. The iterator transparently
+// follows nextRecordsUrl until every record has been visited.
+func (forceApi *ForceApi) QueryIter(query string) *QueryIterator {
+	return forceApi.QueryIterContext(context.Background(), query)
+}
+
+// QueryIterContext is like QueryIter but carries a context used for every page request.
+func (forceApi *ForceApi) QueryIterContext(ctx context.Context, query string) *QueryIterator {
+	return newQueryIterator(ctx, forceApi, forceApi.apiResources[queryKey], query)
+}
+
+// QueryAllIter is like QueryIter but uses the QueryAll resource, so deleted and merged
+// records are included.
+func (forceApi *ForceApi) QueryAllIter(query string) *QueryIterator {
+	return forceApi.QueryAllIterContext(context.Background(), query)
+}
+
+// QueryAllIterContext is like QueryAllIter but carries a context used for every page request.
+func (forceApi *ForceApi) QueryAllIterContext(ctx context.Context, query string) *QueryIterator {
+	return newQueryIterator(ctx, forceApi, forceApi.apiResources[queryAllKey], query)
+}
+
+// QueryIterator yields the records of a query one at a time. Only one page of records is held
+// in memory. Typical usage:
+//
+//	iter := forceApi.QueryIter("SELECT Id, Name FROM Account")
+//	for iter.Next() {
+//		account := &sobjects.Account{}
+//		if err := iter.Decode(account); err != nil {
+//			...
+//		}
+//	}
+//	if err := iter.Err(); err != nil {
+//		...
+//	}
+type QueryIterator struct {
+	forceApi *ForceApi
+	ctx      context.Context
+
+	uri    string
+	params url.Values
+
+	page      *queryPage
+	index     int
+	totalSize int
+	err       error
+}
+
+type queryPage struct {
+	Done           bool                   `force:"done"`
+	TotalSize      float64                `force:"totalSize"`
+	NextRecordsUri string                 `force:"nextRecordsUrl"`
+	Records        []forcejson.RawMessage `force:"records"`
+}
+
+func newQueryIterator(ctx context.Context, forceApi *ForceApi, uri, query string) *QueryIterator {
+	return &QueryIterator{
+		forceApi: forceApi,
+		ctx:      ctx,
+		uri:      uri,
+		params:   url.Values{"q": {query}},
+		index:    -1,
+	}
+}
+
+// Next advances the iterator to the next record, fetching the next page of results when the
+// current one is exhausted. It returns false when there are no more records or an error occurred.
+func (iter *QueryIterator) Next() bool {
+	if iter.err != nil {
+		return false
+	}
+
+	iter.index++
+	for iter.page == nil || iter.index >= len(iter.page.Records) {
+		// The first page is requested with the query, the remaining ones through nextRecordsUrl.
+		if iter.page != nil {
+			if iter.page.Done || len(iter.page.NextRecordsUri) == 0 {
+				return false
+			}
+			iter.uri, iter.params = iter.page.NextRecordsUri, nil
+		}
+
+		page := &queryPage{}
+		if err := iter.forceApi.GetContext(iter.ctx, iter.uri, iter.params, page); err != nil {
+			iter.err = err
+			return false
+		}
+
+		iter.page = page
+		iter.index = 0
+		iter.totalSize = int(page.TotalSize)
+	}
+
+	return true
+}
+
+// Decode unmarshals the current record into out, which is typically a pointer to a struct
+// implementing SObject.
+func (iter *QueryIterator) Decode(out interface{}) error {
+	if iter.page == nil || iter.index < 0 || iter.index >= len(iter.page.Records) {
+		return fmt.Errorf("Decode called without a current record")
+	}
+
+	return forcejson.Unmarshal(iter.page.Records[iter.index], out)
+}
+
+// TotalSize returns the total number of records matched by the query, as reported by the first
+// page of results. It is zero until Next has been called.
+func (iter *QueryIterator) TotalSize() int {
+	return iter.totalSize
+}
+
+// Err returns the error, if any, that stopped the iteration.
+func (iter *QueryIterator) Err() error {
+	return iter.err
+}
+
+// Query executes query and returns every matching record decoded as T, following
+// nextRecordsUrl as needed. T is usually a pointer to a struct, e.g.
+//
+//	leads, err := force.Query[*sobjects.Lead](forceApi, "SELECT Id, Email FROM Lead")
+func Query[T SObject](forceApi *ForceApi, query string) ([]T, error) {
+	return QueryContext[T](context.Background(), forceApi, query)
+}
+
+// QueryContext is like Query but carries a context.
+func QueryContext[T SObject](ctx context.Context, forceApi *ForceApi, query string) ([]T, error) {
+	return collect[T](forceApi.QueryIterContext(ctx, query))
+}
+
+// QueryAll is like Query but uses the QueryAll resource, so deleted and merged records are
+// included.
+func QueryAll[T SObject](forceApi *ForceApi, query string) ([]T, error) {
+	return QueryAllContext[T](context.Background(), forceApi, query)
+}
+
+// QueryAllContext is like QueryAll but carries a context.
+func QueryAllContext[T SObject](ctx context.Context, forceApi *ForceApi, query string) ([]T, error) {
+	return collect[T](forceApi.QueryAllIterContext(ctx, query))
+}
+
+func collect[T SObject](iter *QueryIterator) ([]T, error) {
+	var records []T
+	for iter.Next() {
+		var record T
+		if err := iter.Decode(&record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
@@ -1,8 +1,12 @@
 package force
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/nimajalali/go-force/sobjects"
 )
@@ -11,10 +15,7 @@ const (
 	queryAll = "SELECT %v FROM Account WHERE Id = '%v'"
 )
 
-type AccountQueryResponse struct {
-	sobjects.BaseQuery
-	Records []sobjects.Account `json:"Records" force:"records"`
-}
+type AccountQueryResponse = sobjects.QueryResponse[sobjects.Account]
 
 func TestQuery(t *testing.T) {
 	forceApi := createTest()
@@ -60,3 +61,120 @@ func TestQueryAll(t *testing.T) {
 func TestQueryNext(t *testing.T) {
 	// TODO
 }
+
+func TestQueryContextDeadline(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	list := &AccountQueryResponse{}
+	err := forceApi.QueryContext(ctx, "SELECT Id FROM Account", list)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got: %v", err)
+	}
+}
+
+func TestQueryContextCanceledSkipsReauthentication(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		cancel()
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `[{"message":"Session expired or invalid","errorCode":"INVALID_SESSION_ID"}]`)
+	}))
+	defer server.Close()
+
+	list := &AccountQueryResponse{}
+	err := forceApi.QueryContext(ctx, "SELECT Id FROM Account", list)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context canceled, got: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("Expected a single request, got %v", calls)
+	}
+}
+
+func TestQueryIter(t *testing.T) {
+	next := fmt.Sprintf(resourcesUri, testVersion) + "/query/01gD0000002HU6KIAW-2000"
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case fmt.Sprintf(resourcesUri, testVersion) + "/query":
+			if r.URL.Query().Get("q") != "SELECT Name FROM Account" {
+				t.Errorf("Unexpected query: %v", r.URL.RawQuery)
+			}
+			fmt.Fprintf(w, `{"done":false,"totalSize":3,"nextRecordsUrl":"%v","records":[{"Name":"a"},{"Name":"b"}]}`, next)
+		case next:
+			fmt.Fprint(w, `{"done":true,"totalSize":3,"records":[{"Name":"c"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	iter := forceApi.QueryIter("SELECT Name FROM Account")
+	var names []string
+	for iter.Next() {
+		acc := &sobjects.Account{}
+		if err := iter.Decode(acc); err != nil {
+			t.Fatalf("Failed to decode record: %v", err)
+		}
+		names = append(names, acc.Name)
+	}
+	if err := iter.Err(); err != nil {
+		t.Fatalf("Failed to iterate query: %v", err)
+	}
+
+	if fmt.Sprint(names) != "[a b c]" {
+		t.Fatalf("Unexpected records: %v", names)
+	}
+	if iter.TotalSize() != 3 {
+		t.Fatalf("Unexpected total size: %v", iter.TotalSize())
+	}
+}
+
+func TestQueryIterError(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `[{"message":"unexpected token","errorCode":"MALFORMED_QUERY"}]`)
+	}))
+	defer server.Close()
+
+	iter := forceApi.QueryAllIter("SELECT FROM Account")
+	if iter.Next() {
+		t.Fatal("Expected no records from a failed query")
+	}
+	if apiErrors := (ApiErrors{}); !errors.As(iter.Err(), &apiErrors) {
+		t.Fatalf("Expected ApiErrors, got: %#v", iter.Err())
+	}
+}
+
+func TestGenericQuery(t *testing.T) {
+	next := fmt.Sprintf(resourcesUri, testVersion) + "/queryAll/01gD0000002HU6KIAW-2000"
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case fmt.Sprintf(resourcesUri, testVersion) + "/queryAll":
+			fmt.Fprintf(w, `{"done":false,"totalSize":2,"nextRecordsUrl":"%v","records":[{"Email":"a@example.com","IsDeleted":true}]}`, next)
+		case next:
+			fmt.Fprint(w, `{"done":true,"totalSize":2,"records":[{"Email":"b@example.com"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	leads, err := QueryAll[*sobjects.Lead](forceApi, "SELECT Email, IsDeleted FROM Lead")
+	if err != nil {
+		t.Fatalf("Failed to queryAll: %v", err)
+	}
+
+	if len(leads) != 2 || leads[0].Email != "a@example.com" || !leads[0].IsDeleted || leads[1].Email != "b@example.com" {
+		t.Fatalf("Unexpected leads: %+v", leads)
+	}
+}
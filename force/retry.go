@@ -0,0 +1,197 @@
+package force
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nimajalali/go-force/forcejson"
+)
+
+const (
+	serverUnavailableErrorCode = "SERVER_UNAVAILABLE"
+	unableToLockRowErrorCode   = "UNABLE_TO_LOCK_ROW"
+
+	defaultRetryInitialBackoff  = 500 * time.Millisecond
+	defaultRetryMaxBackoff      = 30 * time.Second
+	defaultMaxReauthentications = 1
+)
+
+// RetryPolicy controls how requests that fail transiently are retried, see WithRetryPolicy.
+//
+// A response carrying one of ErrorCodes means the request was not applied, so it's retried
+// whatever its method. Network errors and 5xx responses leave it unknown whether the request was
+// applied, so they're only retried for idempotent methods: GET, HEAD, PUT, DELETE and PATCH, which
+// the REST API uses for updates and upserts. POST, used to insert records among others, is only
+// retried in that case when RetryNonIdempotent is set.
+//
+// Zero values use the defaults.
+type RetryPolicy struct {
+	// Number of attempts made at a request, including the first. A value of one or less disables
+	// retries.
+	MaxAttempts int
+	// Delay before the first retry, doubled for every retry after it. Defaults to 500ms.
+	InitialBackoff time.Duration
+	// Upper bound of the delay between retries, including delays asked for by the server with
+	// Retry-After. Defaults to 30s.
+	MaxBackoff time.Duration
+	// Fraction, between 0 and 1, of each delay that is randomized so clients that failed together
+	// don't retry together.
+	Jitter float64
+	// Api error codes that are retried. Defaults to SERVER_UNAVAILABLE, UNABLE_TO_LOCK_ROW and
+	// REQUEST_LIMIT_EXCEEDED.
+	ErrorCodes []string
+	// Also retry POST requests after network errors and 5xx responses.
+	RetryNonIdempotent bool
+	// Number of times a request reauthenticates after its session expired. Defaults to one when
+	// nil; zero disables reauthentication.
+	MaxReauthentications *int
+}
+
+// DefaultRetryPolicy returns a policy making up to 4 attempts with jittered exponential backoff.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: defaultRetryInitialBackoff,
+		MaxBackoff:     defaultRetryMaxBackoff,
+		Jitter:         0.2,
+	}
+}
+
+// retryError reports whether a request that failed to get a response should be retried.
+func (policy *RetryPolicy) retryError(attempts int, method string) bool {
+	if policy == nil || attempts >= policy.MaxAttempts {
+		return false
+	}
+
+	return policy.RetryNonIdempotent || idempotent(method)
+}
+
+// retryResponse reports whether a request that got an error response should be retried.
+func (policy *RetryPolicy) retryResponse(attempts int, method string, statusCode int, respBytes []byte) bool {
+	if policy == nil || attempts >= policy.MaxAttempts {
+		return false
+	}
+
+	apiErrors := ApiErrors{}
+	if forcejson.Unmarshal(respBytes, &apiErrors) == nil {
+		for _, apiError := range apiErrors {
+			for _, errorCode := range policy.errorCodes() {
+				if apiError.ErrorCode == errorCode {
+					return true
+				}
+			}
+		}
+	}
+
+	return statusCode >= http.StatusInternalServerError && (policy.RetryNonIdempotent || idempotent(method))
+}
+
+// wait sleeps before the next retry, for as long as retryAfter if the server asked for a delay,
+// up to MaxBackoff.
+func (policy *RetryPolicy) wait(ctx context.Context, attempts int, retryAfter time.Duration) error {
+	delay := retryAfter
+	if delay <= 0 {
+		delay = policy.backoff(attempts)
+	} else if maxDelay := policy.maxBackoff(); delay > maxDelay {
+		delay = maxDelay
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// backoff returns the delay before the retry following the given number of attempts.
+func (policy *RetryPolicy) backoff(attempts int) time.Duration {
+	delay := policy.InitialBackoff
+	if delay <= 0 {
+		delay = defaultRetryInitialBackoff
+	}
+	maxDelay := policy.maxBackoff()
+
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	if policy.Jitter > 0 {
+		jitter := policy.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		delay -= time.Duration(rand.Float64() * jitter * float64(delay))
+	}
+
+	return delay
+}
+
+func (policy *RetryPolicy) maxBackoff() time.Duration {
+	if policy.MaxBackoff <= 0 {
+		return defaultRetryMaxBackoff
+	}
+
+	return policy.MaxBackoff
+}
+
+func (policy *RetryPolicy) errorCodes() []string {
+	if policy.ErrorCodes == nil {
+		return []string{serverUnavailableErrorCode, unableToLockRowErrorCode, requestLimitExceededErrorCode}
+	}
+
+	return policy.ErrorCodes
+}
+
+func (policy *RetryPolicy) maxReauthentications() int {
+	if policy == nil || policy.MaxReauthentications == nil {
+		return defaultMaxReauthentications
+	}
+
+	return *policy.MaxReauthentications
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "PUT", "DELETE", "PATCH", "OPTIONS":
+		return true
+	}
+
+	return false
+}
+
+// retryAfter returns the delay asked for by the Retry-After header of resp, in seconds, if any.
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+// rewind seeks body back to its start so it can be sent again. It reports false if that's not
+// possible.
+func rewind(body io.Reader) bool {
+	if body == nil {
+		return true
+	}
+
+	seeker, ok := body.(io.Seeker)
+	if !ok {
+		return false
+	}
+	_, err := seeker.Seek(0, io.SeekStart)
+
+	return err == nil
+}
@@ -0,0 +1,207 @@
+package force
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyRetriesErrorCodes(t *testing.T) {
+	var requests int
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `[{"message":"unable to obtain exclusive access to this record","errorCode":"UNABLE_TO_LOCK_ROW"}]`)
+			return
+		}
+		fmt.Fprint(w, `{"id":"001","success":true}`)
+	}))
+	defer server.Close()
+	forceApi.retryPolicy = &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	resp := &SObjectResponse{}
+	if err := forceApi.Post("/insert", nil, map[string]string{"Name": "Test"}, resp); err != nil {
+		t.Fatalf("Expected the locked row to be retried: %v", err)
+	}
+	if requests != 3 || resp.Id != "001" {
+		t.Fatalf("Unexpected response after %v requests: %+v", requests, resp)
+	}
+}
+
+func TestRetryPolicyMaxAttempts(t *testing.T) {
+	var requests int
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `[{"message":"Server unavailable","errorCode":"SERVER_UNAVAILABLE"}]`)
+	}))
+	defer server.Close()
+	forceApi.retryPolicy = &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	err := forceApi.Get("/unavailable", nil, &map[string]interface{}{})
+	if apiErrors := (ApiErrors{}); !errors.As(err, &apiErrors) || apiErrors[0].ErrorCode != serverUnavailableErrorCode {
+		t.Fatalf("Expected the last error to be returned, got %v", err)
+	}
+	if requests != 3 {
+		t.Fatalf("Expected 3 attempts, got %v", requests)
+	}
+}
+
+func TestRetryPolicyIdempotency(t *testing.T) {
+	var requests int
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	forceApi.retryPolicy = &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+
+	// The insert may have been applied, so it's not retried.
+	forceApi.Post("/insert", nil, map[string]string{"Name": "Test"}, nil)
+	if requests != 1 {
+		t.Fatalf("Expected the POST not to be retried, got %v requests", requests)
+	}
+
+	requests = 0
+	forceApi.Patch("/update", nil, map[string]string{"Name": "Test"}, nil)
+	if requests != 2 {
+		t.Fatalf("Expected the PATCH to be retried, got %v requests", requests)
+	}
+
+	requests = 0
+	forceApi.retryPolicy.RetryNonIdempotent = true
+	forceApi.Post("/insert", nil, map[string]string{"Name": "Test"}, nil)
+	if requests != 2 {
+		t.Fatalf("Expected the POST to be retried, got %v requests", requests)
+	}
+}
+
+func TestRetryPolicyNetworkError(t *testing.T) {
+	forceApi, server := createTestServer(t, http.NotFoundHandler())
+	server.Close()
+
+	var requests int
+	forceApi.httpClient = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requests++
+		return nil, fmt.Errorf("connection reset")
+	})}
+	forceApi.retryPolicy = &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	if err := forceApi.Get("/limits", nil, nil); err == nil {
+		t.Fatal("Expected the network error to be returned")
+	}
+	if requests != 3 {
+		t.Fatalf("Expected 3 attempts, got %v", requests)
+	}
+}
+
+func TestReauthenticationBounded(t *testing.T) {
+	var server *httptest.Server
+	var logins, requests int
+	mux := http.NewServeMux()
+	mux.HandleFunc(tokenUri, func(w http.ResponseWriter, r *http.Request) {
+		logins++
+		fmt.Fprintf(w, `{"access_token":"token%v","instance_url":"%v"}`, logins, server.URL)
+	})
+	mux.HandleFunc("/limits", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `[{"message":"Session expired or invalid","errorCode":"INVALID_SESSION_ID"}]`)
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	forceApi := newForceApi(testVersion, &forceOauth{
+		Token:    Token{AccessToken: "expired", RefreshToken: "refresh", InstanceUrl: server.URL},
+		clientId: "id",
+	})
+
+	err := forceApi.Get("/limits", nil, nil)
+	if apiErrors := (ApiErrors{}); !errors.As(err, &apiErrors) || !forceApi.oauth.Expired(apiErrors) {
+		t.Fatalf("Expected the session error to be returned, got %v", err)
+	}
+	if logins != 1 || requests != 2 {
+		t.Fatalf("Expected a single reauthentication, got %v logins and %v requests", logins, requests)
+	}
+
+	logins, requests = 0, 0
+	disabled := 0
+	forceApi.retryPolicy = &RetryPolicy{MaxReauthentications: &disabled}
+	if err := forceApi.Get("/limits", nil, nil); err == nil {
+		t.Fatal("Expected the session error to be returned")
+	}
+	if logins != 0 || requests != 1 {
+		t.Fatalf("Expected no reauthentication, got %v logins and %v requests", logins, requests)
+	}
+}
+
+func TestRetryPolicyRequestLimitExceeded(t *testing.T) {
+	var requests int
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `[{"message":"TotalRequests Limit exceeded.","errorCode":"REQUEST_LIMIT_EXCEEDED"}]`)
+	}))
+	defer server.Close()
+	forceApi.retryPolicy = &RetryPolicy{MaxAttempts: 3, MaxBackoff: time.Millisecond}
+
+	if err := forceApi.Get("/limits", nil, nil); !IsRateLimited(err) {
+		t.Fatalf("Expected the limit error to be returned, got %v", err)
+	}
+	if requests != 3 {
+		t.Fatalf("Expected the exceeded limit to be retried, got %v requests", requests)
+	}
+}
+
+func TestRetryPolicyRetryAfterBounded(t *testing.T) {
+	var requests int
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+	forceApi.retryPolicy = &RetryPolicy{MaxAttempts: 2, MaxBackoff: 10 * time.Millisecond}
+
+	start := time.Now()
+	if err := forceApi.Get("/limits", nil, nil); err != nil {
+		t.Fatalf("Expected the request to be retried: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected the Retry-After delay to be bounded by MaxBackoff, waited %v", elapsed)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	for attempts, expected := range []time.Duration{0, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if attempts == 0 {
+			continue
+		}
+		if delay := policy.backoff(attempts); delay != expected {
+			t.Fatalf("Expected a delay of %v after %v attempts, got %v", expected, attempts, delay)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if delay := policy.backoff(2); delay <= time.Second || delay > 2*time.Second {
+			t.Fatalf("Expected a jittered delay between 1s and 2s, got %v", delay)
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (fn roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return fn(r)
+}
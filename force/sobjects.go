@@ -2,6 +2,7 @@ package force
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/url"
 	"strings"
@@ -16,101 +17,158 @@ type SObject interface {
 // Response received from force.com API after insert of an sobject.
 type SObjectResponse struct {
 	Id      string    `force:"id,omitempty"`
-	Errors  ApiErrors `force:"error,omitempty"` //TODO: Not sure if ApiErrors is the right object
+	Errors  ApiErrors `force:"errors,omitempty"`
 	Success bool      `force:"success,omitempty"`
+	Created bool      `force:"created,omitempty"`
 }
 
 func (forceAPI *ForceApi) DescribeSObjects() (map[string]*SObjectMetaData, error) {
-	if err := forceAPI.getApiSObjects(); err != nil {
+	return forceAPI.DescribeSObjectsContext(context.Background())
+}
+
+// DescribeSObjectsContext is like DescribeSObjects but carries a context.
+func (forceAPI *ForceApi) DescribeSObjectsContext(ctx context.Context) (map[string]*SObjectMetaData, error) {
+	if err := forceAPI.getApiSObjects(ctx); err != nil {
 		return nil, err
 	}
 
-	return forceAPI.apiSObjects, nil
+	return forceAPI.sObjectMetaDataList(), nil
 }
 
 func (forceApi *ForceApi) DescribeSObject(in SObject) (resp *SObjectDescription, err error) {
+	return forceApi.DescribeSObjectContext(context.Background(), in)
+}
+
+// DescribeSObjectContext is like DescribeSObject but carries a context. Descriptions are cached,
+// and concurrent calls for an sobject that isn't cached yet share a single request.
+func (forceApi *ForceApi) DescribeSObjectContext(ctx context.Context, in SObject) (resp *SObjectDescription, err error) {
 	// Check cache
+	forceApi.metaDataMu.RLock()
 	resp, ok := forceApi.apiSObjectDescriptions[in.ApiName()]
-	if !ok {
-		// Attempt retrieval from api
-		sObjectMetaData, ok := forceApi.apiSObjects[in.ApiName()]
-		if !ok {
-			err = fmt.Errorf("Unable to find metadata for object: %v", in.ApiName())
-			return
-		}
+	forceApi.metaDataMu.RUnlock()
+	if ok {
+		return
+	}
+
+	// Attempt retrieval from api
+	desc, err := forceApi.describeFlights.do(ctx, in.ApiName(), func(ctx context.Context) (interface{}, error) {
+		return forceApi.describeSObject(ctx, in.ApiName())
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		uri := sObjectMetaData.URLs[sObjectDescribeKey]
+	return desc.(*SObjectDescription), nil
+}
 
-		resp = &SObjectDescription{}
-		err = forceApi.Get(uri, nil, resp)
-		if err != nil {
-			return
-		}
+// describeSObject retrieves the description of the named sobject and caches it. It's given the
+// context of the shared flight rather than that of the caller that started it, since other
+// callers may be waiting on it.
+func (forceApi *ForceApi) describeSObject(ctx context.Context, name string) (resp *SObjectDescription, err error) {
+	sObjectMetaData := forceApi.sObjectMetaData(name)
+	if sObjectMetaData == nil {
+		err = fmt.Errorf("Unable to find metadata for object: %v", name)
+		return
+	}
+
+	uri := sObjectMetaData.URLs[sObjectDescribeKey]
+
+	resp = &SObjectDescription{}
+	err = forceApi.GetContext(ctx, uri, nil, resp)
+	if err != nil {
+		return nil, err
+	}
 
-		// Create Comma Separated String of All Field Names.
-		// Used for SELECT * Queries.
-		length := len(resp.Fields)
-		if length > 0 {
-			var allFields bytes.Buffer
-			for index, field := range resp.Fields {
-				// Field type location cannot be directly retrieved from SQL Query.
-				if field.Type != "location" {
-					if index > 0 && index < length {
-						allFields.WriteString(", ")
-					}
-					allFields.WriteString(field.Name)
+	// Create Comma Separated String of All Field Names.
+	// Used for SELECT * Queries.
+	length := len(resp.Fields)
+	if length > 0 {
+		var allFields bytes.Buffer
+		for index, field := range resp.Fields {
+			// Field type location cannot be directly retrieved from SQL Query.
+			if field.Type != "location" {
+				if index > 0 && index < length {
+					allFields.WriteString(", ")
 				}
+				allFields.WriteString(field.Name)
 			}
-
-			resp.AllFields = allFields.String()
 		}
 
-		forceApi.apiSObjectDescriptions[in.ApiName()] = resp
+		resp.AllFields = allFields.String()
 	}
 
+	forceApi.metaDataMu.Lock()
+	forceApi.apiSObjectDescriptions[name] = resp
+	forceApi.metaDataMu.Unlock()
+
 	return
 }
 
 func (forceApi *ForceApi) GetSObject(id string, fields []string, out SObject) (err error) {
-	uri := strings.Replace(forceApi.apiSObjects[out.ApiName()].URLs[rowTemplateKey], idKey, id, 1)
+	return forceApi.GetSObjectContext(context.Background(), id, fields, out)
+}
+
+// GetSObjectContext is like GetSObject but carries a context.
+func (forceApi *ForceApi) GetSObjectContext(ctx context.Context, id string, fields []string, out SObject) (err error) {
+	uri := strings.Replace(forceApi.sObjectMetaData(out.ApiName()).URLs[rowTemplateKey], idKey, id, 1)
 
 	params := url.Values{}
 	if len(fields) > 0 {
 		params.Add("fields", strings.Join(fields, ","))
 	}
 
-	err = forceApi.Get(uri, params, out.(interface{}))
+	err = forceApi.GetContext(ctx, uri, params, out.(interface{}))
 
 	return
 }
 
 func (forceApi *ForceApi) InsertSObject(in SObject) (resp *SObjectResponse, err error) {
-	uri := forceApi.apiSObjects[in.ApiName()].URLs[sObjectKey]
+	return forceApi.InsertSObjectContext(context.Background(), in)
+}
+
+// InsertSObjectContext is like InsertSObject but carries a context.
+func (forceApi *ForceApi) InsertSObjectContext(ctx context.Context, in SObject) (resp *SObjectResponse, err error) {
+	uri := forceApi.sObjectMetaData(in.ApiName()).URLs[sObjectKey]
 
 	resp = &SObjectResponse{}
-	err = forceApi.Post(uri, nil, in.(interface{}), resp)
+	err = forceApi.PostContext(ctx, uri, nil, in.(interface{}), resp)
 
 	return
 }
 
 func (forceApi *ForceApi) UpdateSObject(id string, in SObject) (err error) {
-	uri := strings.Replace(forceApi.apiSObjects[in.ApiName()].URLs[rowTemplateKey], idKey, id, 1)
+	return forceApi.UpdateSObjectContext(context.Background(), id, in)
+}
+
+// UpdateSObjectContext is like UpdateSObject but carries a context.
+func (forceApi *ForceApi) UpdateSObjectContext(ctx context.Context, id string, in SObject) (err error) {
+	uri := strings.Replace(forceApi.sObjectMetaData(in.ApiName()).URLs[rowTemplateKey], idKey, id, 1)
 
-	err = forceApi.Patch(uri, nil, in.(interface{}), nil)
+	err = forceApi.PatchContext(ctx, uri, nil, in.(interface{}), nil)
 
 	return
 }
 
 func (forceApi *ForceApi) DeleteSObject(id string, in SObject) (err error) {
-	uri := strings.Replace(forceApi.apiSObjects[in.ApiName()].URLs[rowTemplateKey], idKey, id, 1)
+	return forceApi.DeleteSObjectContext(context.Background(), id, in)
+}
+
+// DeleteSObjectContext is like DeleteSObject but carries a context.
+func (forceApi *ForceApi) DeleteSObjectContext(ctx context.Context, id string, in SObject) (err error) {
+	uri := strings.Replace(forceApi.sObjectMetaData(in.ApiName()).URLs[rowTemplateKey], idKey, id, 1)
 
-	err = forceApi.Delete(uri, nil)
+	err = forceApi.DeleteContext(ctx, uri, nil)
 
 	return
 }
 
 func (forceApi *ForceApi) GetSObjectByExternalId(id string, fields []string, out SObject) (err error) {
-	uri := fmt.Sprintf("%v/%v/%v", forceApi.apiSObjects[out.ApiName()].URLs[sObjectKey],
+	return forceApi.GetSObjectByExternalIdContext(context.Background(), id, fields, out)
+}
+
+// GetSObjectByExternalIdContext is like GetSObjectByExternalId but carries a context.
+func (forceApi *ForceApi) GetSObjectByExternalIdContext(ctx context.Context, id string, fields []string, out SObject) (err error) {
+	uri := fmt.Sprintf("%v/%v/%v", forceApi.sObjectMetaData(out.ApiName()).URLs[sObjectKey],
 		out.ExternalIdApiName(), id)
 
 	params := url.Values{}
@@ -118,26 +176,36 @@ func (forceApi *ForceApi) GetSObjectByExternalId(id string, fields []string, out
 		params.Add("fields", strings.Join(fields, ","))
 	}
 
-	err = forceApi.Get(uri, params, out.(interface{}))
+	err = forceApi.GetContext(ctx, uri, params, out.(interface{}))
 
 	return
 }
 
 func (forceApi *ForceApi) UpsertSObjectByExternalId(id string, in SObject) (resp *SObjectResponse, err error) {
-	uri := fmt.Sprintf("%v/%v/%v", forceApi.apiSObjects[in.ApiName()].URLs[sObjectKey],
+	return forceApi.UpsertSObjectByExternalIdContext(context.Background(), id, in)
+}
+
+// UpsertSObjectByExternalIdContext is like UpsertSObjectByExternalId but carries a context.
+func (forceApi *ForceApi) UpsertSObjectByExternalIdContext(ctx context.Context, id string, in SObject) (resp *SObjectResponse, err error) {
+	uri := fmt.Sprintf("%v/%v/%v", forceApi.sObjectMetaData(in.ApiName()).URLs[sObjectKey],
 		in.ExternalIdApiName(), id)
 
 	resp = &SObjectResponse{}
-	err = forceApi.Patch(uri, nil, in.(interface{}), resp)
+	err = forceApi.PatchContext(ctx, uri, nil, in.(interface{}), resp)
 
 	return
 }
 
 func (forceApi *ForceApi) DeleteSObjectByExternalId(id string, in SObject) (err error) {
-	uri := fmt.Sprintf("%v/%v/%v", forceApi.apiSObjects[in.ApiName()].URLs[sObjectKey],
+	return forceApi.DeleteSObjectByExternalIdContext(context.Background(), id, in)
+}
+
+// DeleteSObjectByExternalIdContext is like DeleteSObjectByExternalId but carries a context.
+func (forceApi *ForceApi) DeleteSObjectByExternalIdContext(ctx context.Context, id string, in SObject) (err error) {
+	uri := fmt.Sprintf("%v/%v/%v", forceApi.sObjectMetaData(in.ApiName()).URLs[sObjectKey],
 		in.ExternalIdApiName(), id)
 
-	err = forceApi.Delete(uri, nil)
+	err = forceApi.DeleteContext(ctx, uri, nil)
 
 	return
 }
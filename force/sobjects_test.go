@@ -1,7 +1,11 @@
 package force
 
 import (
+	"fmt"
 	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -161,3 +165,44 @@ func randomString(l int) string {
 func randInt(min int, max int) int {
 	return min + rand.Intn(max-min)
 }
+
+func TestConcurrentDescribeSObject(t *testing.T) {
+	var describes int32
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != fmt.Sprintf(resourcesUri, testVersion)+"/sobjects/Account/describe" {
+			t.Errorf("Unexpected request: %v", r.URL.Path)
+		}
+		atomic.AddInt32(&describes, 1)
+		// Hold the describe so that every call finds it in flight.
+		time.Sleep(50 * time.Millisecond)
+		fmt.Fprint(w, `{"name":"Account","fields":[{"name":"Id","type":"id"},{"name":"Name","type":"string"}]}`)
+	}))
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			desc, err := forceApi.DescribeSObject(&sobjects.Account{})
+			if err != nil {
+				t.Errorf("Cannot retrieve SObject Description for Account SObject: %v", err)
+				return
+			}
+			if desc.AllFields != "Id, Name" {
+				t.Errorf("Unexpected fields: %v", desc.AllFields)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := forceApi.DescribeSObjects(); err != nil {
+				t.Errorf("Failed to retrieve SObjects: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if describes != 1 {
+		t.Fatalf("Expected a single describe request, got %v", describes)
+	}
+}
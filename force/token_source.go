@@ -0,0 +1,137 @@
+package force
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// TokenSource supplies the token a ForceApi uses. It's consulted before each request, so a token
+// renewed elsewhere, for example by another process sharing the session, is picked up without
+// authenticating again. Token returns nil when it has no token.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// TokenStore is a TokenSource that also persists the tokens a ForceApi is issued, letting several
+// ForceApi instances, processes or hosts share one session.
+type TokenStore interface {
+	TokenSource
+	SaveToken(ctx context.Context, token *Token) error
+}
+
+// MemoryTokenStore is a TokenStore holding the token in memory, shared by the ForceApi instances
+// of a process.
+type MemoryTokenStore struct {
+	mu    sync.Mutex
+	token *Token
+}
+
+// NewMemoryTokenStore returns an empty MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+// Token returns a copy of the stored token, or nil.
+func (store *MemoryTokenStore) Token(ctx context.Context) (*Token, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if store.token == nil {
+		return nil, nil
+	}
+	token := *store.token
+	return &token, nil
+}
+
+// SaveToken replaces the stored token.
+func (store *MemoryTokenStore) SaveToken(ctx context.Context, token *Token) error {
+	saved := *token
+
+	store.mu.Lock()
+	store.token = &saved
+	store.mu.Unlock()
+
+	return nil
+}
+
+// FileTokenStore is a TokenStore keeping the token as json in a file, shared by the processes that
+// can read it. The file holds credentials and is only readable by its owner. The token is only
+// read again once the file has changed.
+type FileTokenStore struct {
+	path string
+
+	mu      sync.Mutex
+	token   *Token
+	modTime time.Time
+	size    int64
+}
+
+// NewFileTokenStore returns a FileTokenStore keeping the token at path.
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+// Token returns a copy of the stored token, returning nil if the file doesn't exist.
+func (store *FileTokenStore) Token(ctx context.Context) (*Token, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	info, err := os.Stat(store.path)
+	if os.IsNotExist(err) {
+		store.token = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error reading token file: %v", err)
+	}
+
+	if store.token == nil || !info.ModTime().Equal(store.modTime) || info.Size() != store.size {
+		data, err := ioutil.ReadFile(store.path)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading token file: %v", err)
+		}
+
+		token := &Token{}
+		if err := json.Unmarshal(data, token); err != nil {
+			return nil, fmt.Errorf("Unable to unmarshal token file %v: %v", store.path, err)
+		}
+		store.token, store.modTime, store.size = token, info.ModTime(), info.Size()
+	}
+
+	token := *store.token
+	return &token, nil
+}
+
+// SaveToken writes the token to the file. The file is replaced atomically, so readers never see a
+// partly written token.
+func (store *FileTokenStore) SaveToken(ctx context.Context, token *Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("Error marshaling token: %v", err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(store.path), filepath.Base(store.path)+".*")
+	if err != nil {
+		return fmt.Errorf("Error writing token file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("Error writing token file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("Error writing token file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), store.path); err != nil {
+		return fmt.Errorf("Error writing token file: %v", err)
+	}
+
+	return nil
+}
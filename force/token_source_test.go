@@ -0,0 +1,177 @@
+package force
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTokenStoreSharesSession(t *testing.T) {
+	var server *httptest.Server
+	logins := 0
+	valid := ""
+	mux := http.NewServeMux()
+	mux.HandleFunc(tokenUri, func(w http.ResponseWriter, r *http.Request) {
+		logins++
+		valid = fmt.Sprintf("token%v", logins)
+		fmt.Fprintf(w, `{"access_token":"%v","instance_url":"%v"}`, valid, server.URL)
+	})
+	resources := fmt.Sprintf(resourcesUri, testVersion)
+	mux.HandleFunc(resources, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"sobjects":"%v/sobjects","limits":"%v/limits"}`, resources, resources)
+	})
+	mux.HandleFunc(resources+"/sobjects", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"sobjects":[]}`)
+	})
+	mux.HandleFunc(resources+"/limits", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `[{"message":"Session expired or invalid","errorCode":"INVALID_SESSION_ID"}]`)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	store := NewMemoryTokenStore()
+	create := func() *ForceApi {
+		forceApi, err := CreateWithOptions(
+			WithApiVersion(testVersion),
+			WithLoginUrl(server.URL),
+			WithPasswordCredentials("id", "secret", "user", "pass", "token"),
+			WithTokenStore(store),
+		)
+		if err != nil {
+			t.Fatalf("Unable to create ForceApi: %v", err)
+		}
+		return forceApi
+	}
+
+	first := create()
+	second := create()
+	if logins != 1 || second.GetAccessToken() != "token1" {
+		t.Fatalf("Expected the second ForceApi to reuse the stored token, got %v logins", logins)
+	}
+
+	// The first ForceApi renews the expired session, the second picks up the renewed token.
+	valid = "expired"
+	if _, err := first.GetLimits(); err != nil {
+		t.Fatalf("Expected the session to be renewed: %v", err)
+	}
+	if _, err := second.GetLimits(); err != nil {
+		t.Fatalf("Expected the renewed token to be shared: %v", err)
+	}
+	if logins != 2 || second.GetAccessToken() != "token2" {
+		t.Fatalf("Expected a single renewal, got %v logins and token %v", logins, second.GetAccessToken())
+	}
+}
+
+func TestTokenSourceWithoutCredentials(t *testing.T) {
+	forceApi, server := createTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `[{"message":"Session expired or invalid","errorCode":"INVALID_SESSION_ID"}]`)
+	}))
+	defer server.Close()
+
+	store := NewMemoryTokenStore()
+	store.SaveToken(context.Background(), &Token{AccessToken: "stale", InstanceUrl: server.URL})
+	WithTokenSource(store)(forceApi)
+
+	if _, err := forceApi.GetLimits(); err == nil {
+		t.Fatal("Expected an error renewing a session without credentials")
+	}
+	if forceApi.GetAccessToken() != "stale" {
+		t.Fatalf("Expected the token to come from the source, got %v", forceApi.GetAccessToken())
+	}
+}
+
+type staticTokenSource Token
+
+func (source *staticTokenSource) Token(ctx context.Context) (*Token, error) {
+	token := Token(*source)
+	return &token, nil
+}
+
+func TestTokenSourceKeepsRenewedToken(t *testing.T) {
+	var server *httptest.Server
+	logins := 0
+	valid := "source"
+	mux := http.NewServeMux()
+	mux.HandleFunc(tokenUri, func(w http.ResponseWriter, r *http.Request) {
+		logins++
+		valid = fmt.Sprintf("token%v", logins)
+		fmt.Fprintf(w, `{"access_token":"%v","instance_url":"%v"}`, valid, server.URL)
+	})
+	mux.HandleFunc("/limits", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `[{"message":"Session expired or invalid","errorCode":"INVALID_SESSION_ID"}]`)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	forceApi := newForceApi(testVersion, &forceOauth{})
+	WithPasswordCredentials("id", "secret", "user", "pass", "token")(forceApi)
+	WithLoginUrl(server.URL)(forceApi)
+	WithTokenSource(&staticTokenSource{AccessToken: "source", InstanceUrl: server.URL})(forceApi)
+
+	valid = "expired"
+	for i := 0; i < 3; i++ {
+		if err := forceApi.Get("/limits", nil, nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if logins != 1 || forceApi.GetAccessToken() != "token1" {
+		t.Fatalf("Expected the renewed token to be kept, got %v logins and token %v", logins, forceApi.GetAccessToken())
+	}
+}
+
+func TestFileTokenStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-force")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	store := NewFileTokenStore(filepath.Join(dir, "token.json"))
+	if token, err := store.Token(ctx); token != nil || err != nil {
+		t.Fatalf("Expected no token before saving, got %v %v", token, err)
+	}
+
+	saved := &Token{AccessToken: "access", RefreshToken: "refresh", InstanceUrl: "https://example.my.salesforce.com"}
+	if err := store.SaveToken(ctx, saved); err != nil {
+		t.Fatalf("Unable to save token: %v", err)
+	}
+
+	token, err := NewFileTokenStore(filepath.Join(dir, "token.json")).Token(ctx)
+	if err != nil {
+		t.Fatalf("Unable to read token: %v", err)
+	}
+	if *token != *saved {
+		t.Fatalf("Expected %+v, got %+v", saved, token)
+	}
+
+	// Tokens saved by other processes are picked up.
+	rotated := &Token{AccessToken: "rotated-access", InstanceUrl: saved.InstanceUrl}
+	if err := NewFileTokenStore(filepath.Join(dir, "token.json")).SaveToken(ctx, rotated); err != nil {
+		t.Fatalf("Unable to save token: %v", err)
+	}
+	if token, err := store.Token(ctx); err != nil || *token != *rotated {
+		t.Fatalf("Expected %+v, got %+v %v", rotated, token, err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "token.json"))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("Expected the token file to be private, got %v %v", info.Mode(), err)
+	}
+}
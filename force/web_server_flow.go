@@ -0,0 +1,153 @@
+package force
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	authorizeUri = "/services/oauth2/authorize"
+
+	authorizationCodeGrantType = "authorization_code"
+	codeChallengeMethod        = "S256"
+)
+
+// WebServerFlow runs the oauth web server flow, which lets users of an interactive app connect
+// their own Salesforce accounts. Send the user to AuthCodeUrl and serve CallbackHandler at
+// RedirectUrl; the resulting Token is passed to CreateWithToken.
+//
+//	verifier, _ := force.NewCodeVerifier()
+//	state, _ := force.NewCodeVerifier()
+//	http.Redirect(w, r, flow.AuthCodeUrl(state, force.CodeChallenge(verifier)), http.StatusFound)
+type WebServerFlow struct {
+	ClientId     string
+	ClientSecret string
+	// RedirectUrl is the callback url registered with the connected app.
+	RedirectUrl string
+	// LoginUrl is the login server or My Domain url users authorize at. Defaults to
+	// https://login.salesforce.com.
+	LoginUrl string
+	// Scopes requested, such as "api" and "refresh_token". The scopes of the connected app are
+	// used when empty.
+	Scopes []string
+	// HttpClient used to exchange codes. Defaults to http.DefaultClient.
+	HttpClient *http.Client
+}
+
+// NewCodeVerifier returns a random PKCE code verifier. The same kind of value serves as the state
+// of an authorization request.
+func NewCodeVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("Error generating code verifier: %v", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CodeChallenge returns the S256 PKCE code challenge of verifier.
+func CodeChallenge(verifier string) string {
+	hashed := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hashed[:])
+}
+
+// AuthCodeUrl returns the url a user is sent to in order to authorize the app. state is returned
+// to the callback unchanged and should be unguessable and tied to the user's session.
+// codeChallenge, the CodeChallenge of a verifier kept for the callback, enables PKCE when not
+// empty.
+func (flow *WebServerFlow) AuthCodeUrl(state, codeChallenge string) string {
+	params := url.Values{
+		"response_type": {"code"},
+		"client_id":     {flow.ClientId},
+		"redirect_uri":  {flow.RedirectUrl},
+	}
+	if len(state) > 0 {
+		params.Set("state", state)
+	}
+	if len(flow.Scopes) > 0 {
+		params.Set("scope", strings.Join(flow.Scopes, " "))
+	}
+	if len(codeChallenge) > 0 {
+		params.Set("code_challenge", codeChallenge)
+		params.Set("code_challenge_method", codeChallengeMethod)
+	}
+
+	return flow.loginUrl() + authorizeUri + "?" + params.Encode()
+}
+
+// Exchange trades the authorization code received by the callback for a token. codeVerifier is
+// the PKCE verifier whose challenge was sent to AuthCodeUrl, or empty if PKCE wasn't used.
+func (flow *WebServerFlow) Exchange(code, codeVerifier string) (*Token, error) {
+	return flow.ExchangeContext(context.Background(), code, codeVerifier)
+}
+
+// ExchangeContext is like Exchange but carries a context.
+func (flow *WebServerFlow) ExchangeContext(ctx context.Context, code, codeVerifier string) (*Token, error) {
+	payload := url.Values{
+		"grant_type":   {authorizationCodeGrantType},
+		"code":         {code},
+		"client_id":    {flow.ClientId},
+		"redirect_uri": {flow.RedirectUrl},
+	}
+	if len(flow.ClientSecret) > 0 {
+		payload.Set("client_secret", flow.ClientSecret)
+	}
+	if len(codeVerifier) > 0 {
+		payload.Set("code_verifier", codeVerifier)
+	}
+
+	oauth := &forceOauth{
+		clientId:     flow.ClientId,
+		clientSecret: flow.ClientSecret,
+		loginUrl:     flow.loginUrl(),
+		client:       flow.HttpClient,
+	}
+	if err := oauth.requestToken(ctx, payload); err != nil {
+		return nil, err
+	}
+
+	return &oauth.Token, nil
+}
+
+// CallbackHandler returns the handler to serve at RedirectUrl. codeVerifier looks up the PKCE
+// verifier kept for the state of the request, returning "" if PKCE wasn't used, and reports false
+// for a state it doesn't know, which is rejected. done is called with the token, or with the error
+// that prevented the authorization, and writes the response to the user.
+func (flow *WebServerFlow) CallbackHandler(codeVerifier func(state string) (string, bool),
+	done func(w http.ResponseWriter, r *http.Request, token *Token, err error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		verifier, ok := codeVerifier(query.Get("state"))
+		if !ok {
+			done(w, r, nil, fmt.Errorf("Unknown oauth state %q", query.Get("state")))
+			return
+		}
+
+		// The user denied access, or the authorization request was invalid.
+		if len(query.Get("error")) > 0 {
+			done(w, r, nil, &ApiError{
+				ErrorName:        query.Get("error"),
+				ErrorDescription: query.Get("error_description"),
+			})
+			return
+		}
+
+		token, err := flow.ExchangeContext(r.Context(), query.Get("code"), verifier)
+		done(w, r, token, err)
+	})
+}
+
+func (flow *WebServerFlow) loginUrl() string {
+	if len(flow.LoginUrl) == 0 {
+		return loginUri
+	}
+
+	return strings.TrimSuffix(flow.LoginUrl, "/")
+}
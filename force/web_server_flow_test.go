@@ -0,0 +1,105 @@
+package force
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestWebServerFlowAuthCodeUrl(t *testing.T) {
+	flow := &WebServerFlow{
+		ClientId:    "id",
+		RedirectUrl: "https://app.example.com/callback",
+		LoginUrl:    testLoginUri + "/",
+		Scopes:      []string{"api", "refresh_token"},
+	}
+
+	authUrl, err := url.Parse(flow.AuthCodeUrl("xyz", CodeChallenge("verifier")))
+	if err != nil {
+		t.Fatalf("Unable to parse auth code url: %v", err)
+	}
+
+	if authUrl.Scheme+"://"+authUrl.Host+authUrl.Path != testLoginUri+authorizeUri {
+		t.Fatalf("Unexpected auth code url: %v", authUrl)
+	}
+	query := authUrl.Query()
+	if query.Get("response_type") != "code" || query.Get("client_id") != "id" || query.Get("state") != "xyz" ||
+		query.Get("redirect_uri") != flow.RedirectUrl || query.Get("scope") != "api refresh_token" ||
+		query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("Unexpected auth code parameters: %v", query)
+	}
+	// The S256 challenge of "verifier", from RFC 7636.
+	if query.Get("code_challenge") != "iMnq5o6zALKXGivsnlom_0F5_WYda32GHkxlV7mq7hQ" {
+		t.Fatalf("Unexpected code challenge: %v", query.Get("code_challenge"))
+	}
+}
+
+func TestWebServerFlowCallback(t *testing.T) {
+	var login *httptest.Server
+	login = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != tokenUri || r.FormValue("grant_type") != authorizationCodeGrantType ||
+			r.FormValue("code") != "abc" || r.FormValue("code_verifier") != "verifier" ||
+			r.FormValue("redirect_uri") != "https://app.example.com/callback" {
+			t.Errorf("Unexpected token request: %v %v", r.URL.Path, r.Form)
+		}
+		fmt.Fprintf(w, `{"access_token":"access","refresh_token":"refresh","instance_url":"%v","scope":"api refresh_token"}`, login.URL)
+	}))
+	defer login.Close()
+
+	flow := &WebServerFlow{
+		ClientId:     "id",
+		ClientSecret: "secret",
+		RedirectUrl:  "https://app.example.com/callback",
+		LoginUrl:     login.URL,
+	}
+
+	var token *Token
+	var callbackErr error
+	handler := flow.CallbackHandler(func(state string) (string, bool) {
+		return "verifier", state == "xyz"
+	}, func(w http.ResponseWriter, r *http.Request, t *Token, err error) {
+		token, callbackErr = t, err
+	})
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/callback?code=abc&state=xyz", nil))
+	if callbackErr != nil {
+		t.Fatalf("Unexpected callback error: %v", callbackErr)
+	}
+	if token.AccessToken != "access" || token.RefreshToken != "refresh" || token.InstanceUrl != login.URL {
+		t.Fatalf("Unexpected token: %+v", token)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/callback?code=abc&state=forged", nil))
+	if callbackErr == nil || !strings.Contains(callbackErr.Error(), "forged") {
+		t.Fatalf("Expected an unknown state to be rejected, got %v", callbackErr)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/callback?error=access_denied&state=xyz", nil))
+	if apiError, ok := callbackErr.(*ApiError); !ok || apiError.ErrorName != "access_denied" {
+		t.Fatalf("Expected the authorization error, got %#v", callbackErr)
+	}
+}
+
+func TestCreateWithToken(t *testing.T) {
+	_, server := createTestServer(t, http.NotFoundHandler())
+	defer server.Close()
+
+	forceApi, err := CreateWithToken(testVersion, "id", "secret", &Token{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		InstanceUrl:  server.URL,
+	})
+	if err != nil {
+		t.Fatalf("Unable to create ForceApi from token: %v", err)
+	}
+	if token := forceApi.GetToken(); token.RefreshToken != "refresh" || token.AccessToken != "access" {
+		t.Fatalf("Unexpected token: %+v", token)
+	}
+
+	if _, err := CreateWithToken(testVersion, "id", "secret", nil); err == nil {
+		t.Fatal("Expected an error creating a ForceApi from a nil token")
+	}
+}
@@ -0,0 +1,136 @@
+package forcetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Error codes reported by the api.
+const (
+	NotFoundErrorCode        = "NOT_FOUND"
+	EntityIsDeletedErrorCode = "ENTITY_IS_DELETED"
+	InvalidTypeErrorCode     = "INVALID_TYPE"
+	InvalidFieldErrorCode    = "INVALID_FIELD"
+	MalformedQueryErrorCode  = "MALFORMED_QUERY"
+	MultipleChoicesErrorCode = "MULTIPLE_CHOICES"
+	InvalidSessionErrorCode  = "INVALID_SESSION_ID"
+	UnknownErrorCode         = "UNKNOWN_EXCEPTION"
+
+	RequiredFieldMissingErrorCode        = "REQUIRED_FIELD_MISSING"
+	InvalidFieldForInsertUpdateErrorCode = "INVALID_FIELD_FOR_INSERT_UPDATE"
+	InvalidCrossReferenceKeyErrorCode    = "INVALID_CROSS_REFERENCE_KEY"
+	InvalidTypeForOperationErrorCode     = "INVALID_TYPE_FOR_OPERATION"
+	MalformedIdErrorCode                 = "MALFORMED_ID"
+)
+
+// Record holds the fields of a record keyed by field name, with values as decoded by encoding/json.
+type Record map[string]interface{}
+
+// Get returns the value of field, whose name is matched case insensitively as in SOQL.
+func (record Record) Get(field string) (interface{}, bool) {
+	if value, ok := record[field]; ok {
+		return value, true
+	}
+	for name, value := range record {
+		if strings.EqualFold(name, field) {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+// SObjectInfo describes an sobject in the sobject listing.
+type SObjectInfo struct {
+	Name      string
+	Label     string
+	KeyPrefix string
+	Custom    bool
+}
+
+// Backend holds the data served by a Server. Errors returned as an *Error are reported to the
+// client as such, other errors as an UNKNOWN_EXCEPTION.
+type Backend interface {
+	// SObjects lists the sobjects held by the backend.
+	SObjects() []*SObjectInfo
+	// Describe returns the json describe response of the named sobject.
+	Describe(name string) (json.RawMessage, error)
+	// Get returns the record with id.
+	Get(name, id string) (Record, error)
+	// GetByExternalId returns the record whose field is value.
+	GetByExternalId(name, field, value string) (Record, error)
+	// Insert creates a record with the given fields and returns its id.
+	Insert(name string, fields Record) (string, error)
+	// Update sets the given fields of the record with id.
+	Update(name, id string, fields Record) error
+	// Upsert updates the record whose field is value, or creates one if there is none.
+	Upsert(name, field, value string, fields Record) (id string, created bool, err error)
+	// Delete deletes the record with id.
+	Delete(name, id string) error
+	// Query returns the records matched by a SOQL query, including deleted records if all is set.
+	// Every record has an attributes field holding its type.
+	Query(query string, all bool) ([]Record, error)
+}
+
+// Error is an api error reported by a Backend.
+type Error struct {
+	StatusCode int
+	ErrorCode  string
+	Message    string
+	Fields     []string
+}
+
+// Errorf returns an Error with a formatted message.
+func Errorf(statusCode int, errorCode, format string, a ...interface{}) *Error {
+	return &Error{StatusCode: statusCode, ErrorCode: errorCode, Message: fmt.Sprintf(format, a...)}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: %v", e.ErrorCode, e.Message)
+}
+
+// NotFound returns the Error reported for a record or resource that doesn't exist.
+func NotFound() *Error {
+	return Errorf(http.StatusNotFound, NotFoundErrorCode, "The requested resource does not exist")
+}
+
+const idAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ012345"
+
+// NewId returns the n-th 18 character id of the sobject with keyPrefix, such as
+// "001000000000001AAA" for the first Account.
+func NewId(keyPrefix string, n int) string {
+	id := fmt.Sprintf("%v%0*d", keyPrefix, 15-len(keyPrefix), n)
+	if len(id) != 15 {
+		return id
+	}
+
+	return id + idSuffix(id)
+}
+
+// idSuffix returns the three characters that make a 15 character id case insensitive: each
+// encodes which of five characters of the id are upper case.
+func idSuffix(id string) string {
+	suffix := make([]byte, 3)
+	for i := range suffix {
+		flags := 0
+		for j := 0; j < 5; j++ {
+			if c := id[i*5+j]; c >= 'A' && c <= 'Z' {
+				flags |= 1 << j
+			}
+		}
+		suffix[i] = idAlphabet[flags]
+	}
+
+	return string(suffix)
+}
+
+// idKey returns the 15 character, case sensitive, form of id, under which records are stored.
+func idKey(id string) string {
+	if len(id) == 18 {
+		return id[:15]
+	}
+
+	return id
+}
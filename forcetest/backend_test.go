@@ -0,0 +1,25 @@
+package forcetest
+
+import (
+	"testing"
+)
+
+func TestNewId(t *testing.T) {
+	if id := NewId("001", 1); id != "001000000000001AAA" {
+		t.Fatalf("Unexpected id: %v", id)
+	}
+	// The suffix encodes the upper case characters of each block of five.
+	if id := NewId("a0B", 1); id != "a0B000000000001EAA" {
+		t.Fatalf("Unexpected id: %v", id)
+	}
+}
+
+func TestRecordGet(t *testing.T) {
+	record := Record{"Name": "Acme"}
+	if value, ok := record.Get("name"); !ok || value != "Acme" {
+		t.Fatalf("Expected field names to match case insensitively, got %v", value)
+	}
+	if _, ok := record.Get("Phone"); ok {
+		t.Fatal("Expected a missing field not to be found")
+	}
+}
@@ -0,0 +1,318 @@
+package forcetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secrets in recorded cassettes.
+const Redacted = "REDACTED"
+
+// Form and json fields holding credentials, which are redacted from cassettes.
+var secretFields = []string{
+	"password", "client_secret", "refresh_token", "access_token", "assertion", "code", "code_verifier",
+	"token", "signature", "id_token",
+}
+
+// Headers holding credentials, which aren't recorded.
+var secretHeaders = []string{"Authorization", "X-Sfdc-Session", "Cookie", "Set-Cookie"}
+
+// Mode is the mode of a Recorder.
+type Mode int
+
+const (
+	// ModeReplay serves requests from the cassette, without a network.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the org and records them in the cassette.
+	ModeRecord
+)
+
+// Cassette holds recorded requests and their responses.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  *RecordedRequest  `json:"request"`
+	Response *RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	Url    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that records the requests of integration tests against a real
+// org to a cassette file, and replays them, so the tests can run deterministically without the
+// org, e.g. in CI. Use it for both data and oauth requests with force.WithHttpClient:
+//
+//	recorder, err := forcetest.NewRecorder("testdata/accounts.json", forcetest.ModeReplay, nil)
+//	...
+//	defer recorder.Save()
+//	forceApi, err := force.CreateWithOptions(force.WithHttpClient(recorder.Client()), ...)
+//
+// Access tokens, passwords, security tokens and other credentials are redacted from the cassette.
+// Requests are replayed in the order they were recorded, matched on method, path and query
+// parameters, with the whitespace of SOQL queries normalized. Request bodies aren't matched.
+type Recorder struct {
+	mode      Mode
+	path      string
+	transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette *Cassette
+	replayed []bool
+	secrets  []string
+}
+
+// NewRecorder returns a Recorder of the cassette at path. In ModeReplay the cassette is loaded from
+// path, in ModeRecord requests are sent with transport, or http.DefaultTransport if it's nil.
+func NewRecorder(path string, mode Mode, transport http.RoundTripper) (*Recorder, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	recorder := &Recorder{
+		mode:      mode,
+		path:      path,
+		transport: transport,
+		cassette:  &Cassette{},
+	}
+
+	if mode == ModeReplay {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read cassette: %v", err)
+		}
+		if err := json.Unmarshal(data, recorder.cassette); err != nil {
+			return nil, fmt.Errorf("Unable to unmarshal cassette %v: %v", path, err)
+		}
+		recorder.replayed = make([]bool, len(recorder.cassette.Interactions))
+	}
+
+	return recorder, nil
+}
+
+// Client returns an http client that sends its requests through the recorder.
+func (recorder *Recorder) Client() *http.Client {
+	return &http.Client{Transport: recorder}
+}
+
+func (recorder *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if recorder.mode == ModeReplay {
+		return recorder.replay(req)
+	}
+
+	return recorder.record(req)
+}
+
+// Save writes the recorded cassette to its path. It does nothing in ModeReplay.
+func (recorder *Recorder) Save() error {
+	if recorder.mode == ModeReplay {
+		return nil
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(recorder.cassette); err != nil {
+		return fmt.Errorf("Unable to marshal cassette: %v", err)
+	}
+	// Tokens may show up anywhere, such as in urls and error messages.
+	data := buffer.Bytes()
+	for _, secret := range recorder.secrets {
+		data = bytes.ReplaceAll(data, []byte(secret), []byte(Redacted))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(recorder.path), 0755); err != nil {
+		return fmt.Errorf("Unable to save cassette: %v", err)
+	}
+	if err := ioutil.WriteFile(recorder.path, data, 0644); err != nil {
+		return fmt.Errorf("Unable to save cassette: %v", err)
+	}
+
+	return nil
+}
+
+func (recorder *Recorder) replay(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	key := matchKey(req.Method, req.URL)
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	for i, interaction := range recorder.cassette.Interactions {
+		if recorder.replayed[i] {
+			continue
+		}
+		recordedUrl, err := url.Parse(interaction.Request.Url)
+		if err != nil || matchKey(interaction.Request.Method, recordedUrl) != key {
+			continue
+		}
+		recorder.replayed[i] = true
+
+		recorded := interaction.Response
+		header := recorded.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+			StatusCode:    recorded.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(strings.NewReader(recorded.Body)),
+			ContentLength: int64(len(recorded.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("No recorded interaction matches %v", key)
+}
+
+func (recorder *Recorder) record(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, fmt.Errorf("Unable to read request body: %v", err)
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := recorder.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read response body: %v", err)
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	for _, name := range []string{"Authorization", "X-Sfdc-Session"} {
+		if value := req.Header.Get(name); len(value) > 0 {
+			recorder.addSecret(strings.TrimPrefix(value, "Bearer "))
+		}
+	}
+
+	recorder.cassette.Interactions = append(recorder.cassette.Interactions, &Interaction{
+		Request: &RecordedRequest{
+			Method: req.Method,
+			Url:    req.URL.String(),
+			Header: recorder.scrubHeader(req.Header),
+			Body:   recorder.scrubBody(req.Header.Get("Content-Type"), reqBody),
+		},
+		Response: &RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     recorder.scrubHeader(resp.Header),
+			Body:       recorder.scrubBody(resp.Header.Get("Content-Type"), respBody),
+		},
+	})
+
+	return resp, nil
+}
+
+func (recorder *Recorder) scrubHeader(header http.Header) http.Header {
+	scrubbed := header.Clone()
+	for _, name := range secretHeaders {
+		scrubbed.Del(name)
+	}
+
+	return scrubbed
+}
+
+// scrubBody redacts the credentials of form encoded and json bodies, and remembers them so they
+// can be redacted wherever else they appear.
+func (recorder *Recorder) scrubBody(contentType string, body []byte) string {
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return string(body)
+		}
+		for _, field := range secretFields {
+			if value := form.Get(field); len(value) > 0 {
+				recorder.addSecret(value)
+				form.Set(field, Redacted)
+			}
+		}
+		return form.Encode()
+	}
+
+	fields := map[string]interface{}{}
+	if json.Unmarshal(body, &fields) == nil {
+		for _, field := range secretFields {
+			if value, ok := fields[field].(string); ok && len(value) > 0 {
+				recorder.addSecret(value)
+			}
+		}
+	}
+
+	return string(body)
+}
+
+func (recorder *Recorder) addSecret(secret string) {
+	// Short values, such as an empty code, would redact unrelated text.
+	if len(secret) < 8 {
+		return
+	}
+	for _, known := range recorder.secrets {
+		if known == secret {
+			return
+		}
+	}
+	recorder.secrets = append(recorder.secrets, secret)
+	// Longer secrets first, so a secret containing another is redacted whole.
+	sort.Slice(recorder.secrets, func(i, j int) bool { return len(recorder.secrets[i]) > len(recorder.secrets[j]) })
+}
+
+// matchKey returns the method, path and normalized query parameters of a request.
+func matchKey(method string, u *url.URL) string {
+	query := u.Query()
+	for name, values := range query {
+		for i, value := range values {
+			// SOQL queries are matched regardless of whitespace.
+			values[i] = strings.Join(strings.Fields(value), " ")
+		}
+		query[name] = values
+	}
+
+	key := method + " " + u.Path
+	if len(query) > 0 {
+		key += "?" + query.Encode()
+	}
+
+	return key
+}
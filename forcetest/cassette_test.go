@@ -0,0 +1,107 @@
+package forcetest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	server.Backend.(*MemoryBackend).Seed("Account", Record{"Name": "Acme"})
+
+	path := filepath.Join(t.TempDir(), "cassettes", "accounts.json")
+	recorder, err := NewRecorder(path, ModeRecord, nil)
+	if err != nil {
+		t.Fatalf("Unable to create recorder: %v", err)
+	}
+
+	// Log in, then query.
+	token := map[string]string{}
+	resp, err := recorder.Client().PostForm(server.URL+tokenUri, url.Values{
+		"grant_type":    {"password"},
+		"username":      {"user@example.com"},
+		"password":      {"password1SecurityToken"},
+		"client_secret": {"client-secret"},
+	})
+	if err != nil {
+		t.Fatalf("Unable to request token: %v", err)
+	}
+	json.NewDecoder(resp.Body).Decode(&token)
+	resp.Body.Close()
+	accessToken := token["access_token"]
+
+	query := func(client *http.Client, soql string) (int, string, error) {
+		req, _ := http.NewRequest("GET", server.URL+testData+"/query?"+url.Values{"q": {soql}}.Encode(), nil)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, "", err
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(body), nil
+	}
+	status, recordedBody, err := query(recorder.Client(), "SELECT Name FROM Account")
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Unable to query: %v %v", status, err)
+	}
+
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Unable to save cassette: %v", err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unable to read cassette: %v", err)
+	}
+	for _, secret := range []string{accessToken, "password1SecurityToken", "client-secret"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("Expected %q to be redacted from the cassette:\n%s", secret, data)
+		}
+	}
+	if !strings.Contains(string(data), "username=user%40example.com") {
+		t.Fatalf("Expected the username to be recorded:\n%s", data)
+	}
+
+	// Replay without the server, matching the query regardless of whitespace.
+	server.Close()
+	replayer, err := NewRecorder(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("Unable to load cassette: %v", err)
+	}
+	resp, err = replayer.Client().PostForm("https://login.example.com"+tokenUri, url.Values{"grant_type": {"password"}})
+	if err != nil {
+		t.Fatalf("Unable to replay token request: %v", err)
+	}
+	json.NewDecoder(resp.Body).Decode(&token)
+	resp.Body.Close()
+	if token["access_token"] != Redacted {
+		t.Fatalf("Expected a redacted access token, got %v", token)
+	}
+
+	status, body, err := query(replayer.Client(), "SELECT  Name\n FROM Account")
+	if err != nil || status != http.StatusOK || body != recordedBody {
+		t.Fatalf("Unexpected replayed response %v %v: %v", status, err, body)
+	}
+
+	// Every interaction is replayed once.
+	if _, _, err := query(replayer.Client(), "SELECT Name FROM Account"); err == nil {
+		t.Fatal("Expected an error replaying an unrecorded request")
+	}
+}
+
+func TestMatchKey(t *testing.T) {
+	a, _ := url.Parse("https://na1.salesforce.com/services/data/v58.0/query?q=SELECT+Id+FROM+Account&b=1")
+	b, _ := url.Parse("https://other.my.salesforce.com/services/data/v58.0/query?b=1&q=SELECT%20Id%0A%20FROM%20Account")
+	if matchKey("GET", a) != matchKey("GET", b) {
+		t.Fatalf("Expected %v to match %v", matchKey("GET", a), matchKey("GET", b))
+	}
+	if matchKey("GET", a) == matchKey("POST", a) {
+		t.Fatal("Expected methods to be matched")
+	}
+}
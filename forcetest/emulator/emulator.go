@@ -0,0 +1,669 @@
+// Package emulator provides an in-memory emulation of a Salesforce org, to test business logic
+// end to end against force.ForceApi without an org.
+//
+// Unlike forcetest.MemoryBackend, the Emulator is built from sobject describes, such as describe
+// responses saved from an org, and behaves like the org would: it enforces the required,
+// createable and updateable rules of the described fields, assigns 18 character ids with the key
+// prefix of each sobject, and evaluates a useful subset of SOQL, including parent relationship
+// fields. Serve it with forcetest.NewServer:
+//
+//	org := emulator.New()
+//	if err := org.Load("testdata/describe/*.json"); err != nil {
+//		...
+//	}
+//	server := forcetest.NewServer(org)
+//	defer server.Close()
+//
+//	forceApi, err := force.CreateWithAccessToken(version, clientId, server.AccessToken(), server.URL)
+package emulator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nimajalali/go-force/force"
+	"github.com/nimajalali/go-force/forcetest"
+)
+
+const timeFormat = "2006-01-02T15:04:05.000-0700"
+
+// Fields every sobject has, which are added to descriptions that don't describe them.
+var systemFields = []*force.SObjectField{
+	{Name: "Id", Label: "Record ID", Type: "id", Length: 18, Filterable: true, Sortable: true, Groupable: true, IdLookup: true},
+	{Name: "IsDeleted", Label: "Deleted", Type: "boolean", Filterable: true, Groupable: true, DefaultedOnCreate: true},
+	{Name: "CreatedDate", Label: "Created Date", Type: "datetime", Filterable: true, Sortable: true, DefaultedOnCreate: true},
+	{Name: "LastModifiedDate", Label: "Last Modified Date", Type: "datetime", Filterable: true, Sortable: true, DefaultedOnCreate: true},
+	{Name: "SystemModstamp", Label: "System Modstamp", Type: "datetime", Filterable: true, Sortable: true, DefaultedOnCreate: true},
+}
+
+// Emulator is a forcetest.Backend emulating an org with the sobjects it's given descriptions of.
+// It's safe for concurrent use.
+type Emulator struct {
+	mu       sync.Mutex
+	sObjects map[string]*sObject
+	lastId   int
+}
+
+type sObject struct {
+	description   *force.SObjectDescription
+	fields        map[string]*force.SObjectField
+	relationships map[string]*force.SObjectField
+	records       map[string]forcetest.Record
+	// Ids in order of insertion, which unordered queries return records in.
+	ids []string
+}
+
+// New returns an Emulator without sobjects.
+func New() *Emulator {
+	return &Emulator{sObjects: make(map[string]*sObject)}
+}
+
+// AddSObject adds the sobject described by description, replacing any sobject of the same name.
+// The description isn't modified.
+func (emulator *Emulator) AddSObject(description *force.SObjectDescription) {
+	copied := *description
+	copied.Fields = append([]*force.SObjectField{}, description.Fields...)
+
+	sObject := &sObject{
+		description:   &copied,
+		fields:        make(map[string]*force.SObjectField),
+		relationships: make(map[string]*force.SObjectField),
+		records:       make(map[string]forcetest.Record),
+	}
+	for _, field := range copied.Fields {
+		sObject.fields[strings.ToLower(field.Name)] = field
+		if field.Type == "reference" && len(field.RelationshipName) > 0 {
+			sObject.relationships[strings.ToLower(field.RelationshipName)] = field
+		}
+	}
+	for _, field := range systemFields {
+		if sObject.fields[strings.ToLower(field.Name)] == nil {
+			field := *field
+			copied.Fields = append(copied.Fields, &field)
+			sObject.fields[strings.ToLower(field.Name)] = &field
+		}
+	}
+
+	emulator.mu.Lock()
+	defer emulator.mu.Unlock()
+
+	emulator.sObjects[strings.ToLower(copied.Name)] = sObject
+}
+
+// Load adds the sobjects described by the json files matching pattern, in the format of the
+// describe resource, such as testdata/describe/*.json.
+func (emulator *Emulator) Load(pattern string) error {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("Unable to load describes: %v", err)
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("Unable to load describes: no files match %v", pattern)
+	}
+
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("Unable to load describe: %v", err)
+		}
+
+		// Describes hold values that don't fit SObjectDescription, such as boolean default
+		// values, which are skipped while the rest of the describe is unmarshaled.
+		description := &force.SObjectDescription{}
+		var typeError *json.UnmarshalTypeError
+		if err := json.Unmarshal(data, description); err != nil && !errors.As(err, &typeError) {
+			return fmt.Errorf("Unable to unmarshal describe %v: %v", path, err)
+		}
+		if len(description.Name) == 0 {
+			return fmt.Errorf("Unable to load describe %v: no sobject name", path)
+		}
+
+		emulator.AddSObject(description)
+	}
+
+	return nil
+}
+
+// Seed adds records without enforcing the field rules, to set up data that can't be created
+// through the api, such as values of read only fields. Records without an Id are given one, and
+// system fields are set if they're missing. It returns the ids of the records, and panics if the
+// sobject or a field doesn't exist.
+func (emulator *Emulator) Seed(name string, records ...forcetest.Record) []string {
+	emulator.mu.Lock()
+	defer emulator.mu.Unlock()
+
+	sObject := emulator.sObjects[strings.ToLower(name)]
+	if sObject == nil {
+		panic("emulator: unknown sobject " + name)
+	}
+
+	ids := make([]string, len(records))
+	for i, fields := range records {
+		record := sObject.newRecord()
+		for name, value := range fields {
+			field := sObject.field(name)
+			if field == nil {
+				panic(fmt.Sprintf("emulator: unknown field %v.%v", sObject.description.Name, name))
+			}
+			record[field.Name] = value
+		}
+
+		id, _ := record["Id"].(string)
+		if len(id) == 0 {
+			id = emulator.newId(sObject)
+			record["Id"] = id
+		}
+		sObject.put(id, record)
+		ids[i] = id
+	}
+
+	return ids
+}
+
+func (emulator *Emulator) SObjects() []*forcetest.SObjectInfo {
+	emulator.mu.Lock()
+	defer emulator.mu.Unlock()
+
+	list := make([]*forcetest.SObjectInfo, 0, len(emulator.sObjects))
+	for _, sObject := range emulator.sObjects {
+		list = append(list, &forcetest.SObjectInfo{
+			Name:      sObject.description.Name,
+			Label:     sObject.description.Label,
+			KeyPrefix: sObject.description.KeyPrefix,
+			Custom:    sObject.description.Custom,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list
+}
+
+func (emulator *Emulator) Describe(name string) (json.RawMessage, error) {
+	emulator.mu.Lock()
+	defer emulator.mu.Unlock()
+
+	sObject, err := emulator.sObject(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(sObject.description)
+}
+
+func (emulator *Emulator) Get(name, id string) (forcetest.Record, error) {
+	emulator.mu.Lock()
+	defer emulator.mu.Unlock()
+
+	sObject, err := emulator.sObject(name)
+	if err != nil {
+		return nil, err
+	}
+	record, err := sObject.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return sObject.result(record), nil
+}
+
+func (emulator *Emulator) GetByExternalId(name, field, value string) (forcetest.Record, error) {
+	emulator.mu.Lock()
+	defer emulator.mu.Unlock()
+
+	sObject, err := emulator.sObject(name)
+	if err != nil {
+		return nil, err
+	}
+	record, err := sObject.getByExternalId(field, value)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, forcetest.NotFound()
+	}
+
+	return sObject.result(record), nil
+}
+
+func (emulator *Emulator) Insert(name string, fields forcetest.Record) (string, error) {
+	emulator.mu.Lock()
+	defer emulator.mu.Unlock()
+
+	sObject, err := emulator.sObject(name)
+	if err != nil {
+		return "", err
+	}
+
+	return emulator.insert(sObject, fields)
+}
+
+func (emulator *Emulator) Update(name, id string, fields forcetest.Record) error {
+	emulator.mu.Lock()
+	defer emulator.mu.Unlock()
+
+	sObject, err := emulator.sObject(name)
+	if err != nil {
+		return err
+	}
+	record, err := sObject.get(id)
+	if err != nil {
+		return err
+	}
+
+	return emulator.update(sObject, record, fields)
+}
+
+func (emulator *Emulator) Upsert(name, field, value string, fields forcetest.Record) (string, bool, error) {
+	emulator.mu.Lock()
+	defer emulator.mu.Unlock()
+
+	sObject, err := emulator.sObject(name)
+	if err != nil {
+		return "", false, err
+	}
+	record, err := sObject.getByExternalId(field, value)
+	if err != nil {
+		return "", false, err
+	}
+	if record != nil {
+		return record["Id"].(string), false, emulator.update(sObject, record, fields)
+	}
+
+	externalId := sObject.field(field)
+	if externalId.Type == "id" {
+		return "", false, forcetest.NotFound()
+	}
+	created := forcetest.Record{}
+	for name, value := range fields {
+		created[name] = value
+	}
+	created[externalId.Name] = value
+
+	id, err := emulator.insert(sObject, created)
+
+	return id, err == nil, err
+}
+
+func (emulator *Emulator) Delete(name, id string) error {
+	emulator.mu.Lock()
+	defer emulator.mu.Unlock()
+
+	sObject, err := emulator.sObject(name)
+	if err != nil {
+		return err
+	}
+	if !sObject.description.Deletable {
+		return unsupported(sObject, "delete")
+	}
+	record, err := sObject.get(id)
+	if err != nil {
+		return err
+	}
+
+	// Deleted records stay in the recycle bin, where queryAll finds them.
+	record["IsDeleted"] = true
+	touch(record)
+
+	return nil
+}
+
+func (emulator *Emulator) Query(soql string, all bool) ([]forcetest.Record, error) {
+	query, err := parseEmulatorQuery(soql)
+	if err != nil {
+		return nil, err
+	}
+
+	emulator.mu.Lock()
+	defer emulator.mu.Unlock()
+
+	sObject := emulator.sObjects[strings.ToLower(query.sObject)]
+	if sObject == nil || !sObject.description.Queryable {
+		return nil, forcetest.Errorf(http.StatusBadRequest, forcetest.InvalidTypeErrorCode, "sObject type '%v' is not supported.", query.sObject)
+	}
+
+	scope := &scope{emulator: emulator, sObject: sObject}
+	for _, field := range query.fields {
+		if err := scope.resolve(field); err != nil {
+			return nil, err
+		}
+	}
+	if query.where != nil {
+		if err := query.where.resolve(scope); err != nil {
+			return nil, err
+		}
+	}
+	for _, ordering := range query.orderBy {
+		if err := scope.resolve(ordering.field); err != nil {
+			return nil, err
+		}
+	}
+
+	matched := []forcetest.Record{}
+	for _, id := range sObject.ids {
+		record := sObject.records[id]
+		if deleted, _ := record["IsDeleted"].(bool); deleted && !all {
+			continue
+		}
+		if query.where == nil || query.where.matches(scope, record) {
+			matched = append(matched, record)
+		}
+	}
+	scope.sort(matched, query.orderBy)
+
+	if query.offset >= len(matched) {
+		matched = nil
+	} else {
+		matched = matched[query.offset:]
+	}
+	if query.limit >= 0 && len(matched) > query.limit {
+		matched = matched[:query.limit]
+	}
+
+	records := make([]forcetest.Record, len(matched))
+	for i, record := range matched {
+		records[i] = forcetest.Record{"attributes": map[string]interface{}{"type": sObject.description.Name}}
+		for _, field := range query.fields {
+			scope.selectField(records[i], record, field)
+		}
+	}
+
+	return records, nil
+}
+
+func (emulator *Emulator) sObject(name string) (*sObject, error) {
+	sObject := emulator.sObjects[strings.ToLower(name)]
+	if sObject == nil {
+		return nil, forcetest.NotFound()
+	}
+
+	return sObject, nil
+}
+
+func (emulator *Emulator) insert(sObject *sObject, fields forcetest.Record) (string, error) {
+	if !sObject.description.Createable {
+		return "", unsupported(sObject, "insert")
+	}
+	if err := emulator.validate(sObject, fields, true); err != nil {
+		return "", err
+	}
+
+	record := sObject.newRecord()
+	for name, value := range fields {
+		if name != "attributes" {
+			record[sObject.field(name).Name] = value
+		}
+	}
+
+	var missing []string
+	for _, field := range sObject.description.Fields {
+		if field.Createable && !field.Nillable && !field.DefaultedOnCreate && record[field.Name] == nil {
+			missing = append(missing, field.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", &forcetest.Error{
+			StatusCode: http.StatusBadRequest,
+			ErrorCode:  forcetest.RequiredFieldMissingErrorCode,
+			Message:    fmt.Sprintf("Required fields are missing: [%v]", strings.Join(missing, ", ")),
+			Fields:     missing,
+		}
+	}
+
+	id := emulator.newId(sObject)
+	record["Id"] = id
+	sObject.put(id, record)
+
+	return id, nil
+}
+
+func (emulator *Emulator) update(sObject *sObject, record, fields forcetest.Record) error {
+	if !sObject.description.Updateable {
+		return unsupported(sObject, "update")
+	}
+	if err := emulator.validate(sObject, fields, false); err != nil {
+		return err
+	}
+
+	var missing []string
+	for name, value := range fields {
+		if field := sObject.field(name); field != nil && value == nil && !field.Nillable {
+			missing = append(missing, field.Name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return &forcetest.Error{
+			StatusCode: http.StatusBadRequest,
+			ErrorCode:  forcetest.RequiredFieldMissingErrorCode,
+			Message:    fmt.Sprintf("Required fields are missing: [%v]", strings.Join(missing, ", ")),
+			Fields:     missing,
+		}
+	}
+
+	for name, value := range fields {
+		if name != "attributes" {
+			record[sObject.field(name).Name] = value
+		}
+	}
+	touch(record)
+
+	return nil
+}
+
+// validate checks that fields exist and may be set by an insert or an update, and that references
+// are ids of existing records.
+func (emulator *Emulator) validate(sObject *sObject, fields forcetest.Record, insert bool) error {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		if name != "attributes" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var readOnly []string
+	for _, name := range names {
+		field := sObject.field(name)
+		if field == nil {
+			return forcetest.Errorf(http.StatusBadRequest, forcetest.InvalidFieldErrorCode,
+				"No such column '%v' on sobject of type %v", name, sObject.description.Name)
+		}
+		if (insert && !field.Createable) || (!insert && !field.Updateable) {
+			readOnly = append(readOnly, field.Name)
+		}
+	}
+	if len(readOnly) > 0 {
+		return &forcetest.Error{
+			StatusCode: http.StatusBadRequest,
+			ErrorCode:  forcetest.InvalidFieldForInsertUpdateErrorCode,
+			Message: fmt.Sprintf("Unable to create/update fields: %v. Please check the security settings of this field and verify that it is read/write for your profile or permission set.",
+				strings.Join(readOnly, ", ")),
+			Fields: readOnly,
+		}
+	}
+
+	for _, name := range names {
+		field := sObject.field(name)
+		if field.Type != "reference" || fields[name] == nil {
+			continue
+		}
+		id, ok := fields[name].(string)
+		if !ok || (len(id) != 15 && len(id) != 18) {
+			return &forcetest.Error{
+				StatusCode: http.StatusBadRequest,
+				ErrorCode:  forcetest.MalformedIdErrorCode,
+				Message:    fmt.Sprintf("%v: id value of incorrect type: %v", field.Label, fields[name]),
+				Fields:     []string{field.Name},
+			}
+		}
+		// References to sobjects the emulator doesn't hold can't be checked.
+		if emulator.parentSObject(field) == nil {
+			continue
+		}
+		if _, parent := emulator.parent(forcetest.Record{field.Name: id}, field); parent == nil {
+			return &forcetest.Error{
+				StatusCode: http.StatusBadRequest,
+				ErrorCode:  forcetest.InvalidCrossReferenceKeyErrorCode,
+				Message:    "invalid cross reference id",
+				Fields:     []string{field.Name},
+			}
+		}
+	}
+
+	return nil
+}
+
+// parentSObject returns the first sobject held by the emulator that a reference field refers to.
+func (emulator *Emulator) parentSObject(field *force.SObjectField) *sObject {
+	for _, name := range field.ReferenceTo {
+		if sObject := emulator.sObjects[strings.ToLower(name)]; sObject != nil {
+			return sObject
+		}
+	}
+
+	return nil
+}
+
+// parent returns the record referred to by a reference field of record, and its sobject, or nil if
+// there is none. Polymorphic references are resolved by the key prefix of the id.
+func (emulator *Emulator) parent(record forcetest.Record, field *force.SObjectField) (*sObject, forcetest.Record) {
+	id, _ := record[field.Name].(string)
+	if len(id) == 0 {
+		return nil, nil
+	}
+
+	for _, name := range field.ReferenceTo {
+		sObject := emulator.sObjects[strings.ToLower(name)]
+		if sObject == nil || !strings.HasPrefix(id, sObject.description.KeyPrefix) {
+			continue
+		}
+		if parent, err := sObject.get(id); err == nil {
+			return sObject, parent
+		}
+	}
+
+	return nil, nil
+}
+
+func (emulator *Emulator) newId(sObject *sObject) string {
+	emulator.lastId++
+	return forcetest.NewId(sObject.description.KeyPrefix, emulator.lastId)
+}
+
+func (sObject *sObject) field(name string) *force.SObjectField {
+	return sObject.fields[strings.ToLower(name)]
+}
+
+// newRecord returns a record with every described field, set to its default value.
+func (sObject *sObject) newRecord() forcetest.Record {
+	now := time.Now().UTC().Format(timeFormat)
+
+	record := forcetest.Record{}
+	for _, field := range sObject.description.Fields {
+		switch field.Type {
+		case "boolean":
+			record[field.Name] = field.DefaultValue == "true"
+		case "datetime":
+			if field.DefaultedOnCreate && !field.Createable {
+				record[field.Name] = now
+				continue
+			}
+			record[field.Name] = nil
+		default:
+			record[field.Name] = nil
+		}
+	}
+
+	return record
+}
+
+func (sObject *sObject) put(id string, record forcetest.Record) {
+	key := idKey(id)
+	if _, ok := sObject.records[key]; !ok {
+		sObject.ids = append(sObject.ids, key)
+	}
+	sObject.records[key] = record
+}
+
+func (sObject *sObject) get(id string) (forcetest.Record, error) {
+	record := sObject.records[idKey(id)]
+	if record == nil {
+		return nil, forcetest.NotFound()
+	}
+	if deleted, _ := record["IsDeleted"].(bool); deleted {
+		return nil, forcetest.Errorf(http.StatusNotFound, forcetest.EntityIsDeletedErrorCode, "entity is deleted")
+	}
+
+	return record, nil
+}
+
+// getByExternalId returns the record whose external id field is value, or nil if there is none.
+func (sObject *sObject) getByExternalId(name, value string) (forcetest.Record, error) {
+	field := sObject.field(name)
+	if field == nil || !(field.ExternalId || field.IdLookup) {
+		return nil, forcetest.Errorf(http.StatusNotFound, forcetest.NotFoundErrorCode,
+			"Provided external ID field does not exist or is not accessible: %v", name)
+	}
+
+	var found forcetest.Record
+	for _, id := range sObject.ids {
+		record := sObject.records[id]
+		if deleted, _ := record["IsDeleted"].(bool); deleted {
+			continue
+		}
+		if record[field.Name] == nil {
+			continue
+		}
+		recordValue, ok := record[field.Name].(string)
+		if !ok {
+			recordValue = fmt.Sprint(record[field.Name])
+		}
+		if recordValue == value || (!field.CaseSensitive && equal(field, recordValue, value)) {
+			if found != nil {
+				return nil, forcetest.Errorf(http.StatusMultipleChoices, forcetest.MultipleChoicesErrorCode,
+					"More than one record found for %v %v", field.Name, value)
+			}
+			found = record
+		}
+	}
+
+	return found, nil
+}
+
+// result returns a copy of record, as returned by the api.
+func (sObject *sObject) result(record forcetest.Record) forcetest.Record {
+	result := forcetest.Record{"attributes": map[string]interface{}{"type": sObject.description.Name}}
+	for name, value := range record {
+		result[name] = value
+	}
+
+	return result
+}
+
+func unsupported(sObject *sObject, operation string) *forcetest.Error {
+	return forcetest.Errorf(http.StatusBadRequest, forcetest.InvalidTypeForOperationErrorCode,
+		"entity type %v does not support %v", sObject.description.Name, operation)
+}
+
+func touch(record forcetest.Record) {
+	now := time.Now().UTC().Format(timeFormat)
+	record["LastModifiedDate"] = now
+	record["SystemModstamp"] = now
+}
+
+// idKey returns the 15 character, case sensitive, form of id, under which records are stored.
+func idKey(id string) string {
+	if len(id) == 18 {
+		return id[:15]
+	}
+
+	return id
+}
@@ -0,0 +1,230 @@
+package emulator
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/nimajalali/go-force/force"
+	"github.com/nimajalali/go-force/forcetest"
+	"github.com/nimajalali/go-force/sobjects"
+)
+
+const testVersion = "v58.0"
+
+type account struct {
+	sobjects.BaseSObject
+	Type          string  `force:",omitempty"`
+	AnnualRevenue float64 `force:",omitempty"`
+	ExternalId    string  `force:"External_Id__c,omitempty"`
+	Active        bool    `force:"Active__c,omitempty"`
+}
+
+func (account) ApiName() string {
+	return "Account"
+}
+
+func (account) ExternalIdApiName() string {
+	return "External_Id__c"
+}
+
+type contact struct {
+	sobjects.BaseSObject
+	AccountId string   `force:",omitempty"`
+	FirstName string   `force:",omitempty"`
+	LastName  string   `force:",omitempty"`
+	Account   *account `force:",omitempty"`
+}
+
+func (contact) ApiName() string {
+	return "Contact"
+}
+
+func newTestEmulator(t *testing.T) *Emulator {
+	emulator := New()
+	if err := emulator.Load("testdata/describe/*.json"); err != nil {
+		t.Fatalf("Unable to load describes: %v", err)
+	}
+
+	return emulator
+}
+
+func newTestForceApi(t *testing.T, emulator *Emulator) *force.ForceApi {
+	server := forcetest.NewServer(emulator)
+	t.Cleanup(server.Close)
+
+	forceApi, err := force.CreateWithAccessToken(testVersion, "client-id", server.AccessToken(), server.URL)
+	if err != nil {
+		t.Fatalf("Unable to create ForceApi: %v", err)
+	}
+
+	return forceApi
+}
+
+// requireErrorCode fails the test unless err is a request error with the given code.
+func requireErrorCode(t *testing.T, err error, code string) {
+	t.Helper()
+
+	var requestError *force.RequestError
+	if !errors.As(err, &requestError) || len(requestError.Errors) == 0 || requestError.Errors[0].ErrorCode != code {
+		t.Fatalf("Expected a %v error, got %v", code, err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	emulator := newTestEmulator(t)
+
+	sObjects := emulator.SObjects()
+	if len(sObjects) != 2 || sObjects[0].Name != "Account" || sObjects[0].KeyPrefix != "001" || sObjects[1].Name != "Contact" {
+		t.Fatalf("Unexpected sobjects: %+v", sObjects)
+	}
+
+	if err := emulator.Load("testdata/missing/*.json"); err == nil {
+		t.Fatal("Expected an error loading missing describes")
+	}
+}
+
+func TestInsert(t *testing.T) {
+	forceApi := newTestForceApi(t, newTestEmulator(t))
+
+	// Name is required.
+	_, err := forceApi.InsertSObject(&account{Type: "Customer"})
+	requireErrorCode(t, err, forcetest.RequiredFieldMissingErrorCode)
+	if !strings.Contains(err.Error(), "Name") {
+		t.Fatalf("Expected the missing field to be reported, got %v", err)
+	}
+
+	in := &account{Type: "Customer", AnnualRevenue: 1000}
+	in.Name = "Acme"
+	resp, err := forceApi.InsertSObject(in)
+	if err != nil {
+		t.Fatalf("Unable to insert account: %v", err)
+	}
+	if len(resp.Id) != 18 || !strings.HasPrefix(resp.Id, "001") {
+		t.Fatalf("Unexpected id: %v", resp.Id)
+	}
+
+	out := &account{}
+	if err := forceApi.GetSObject(resp.Id, nil, out); err != nil {
+		t.Fatalf("Unable to get account: %v", err)
+	}
+	if out.Name != "Acme" || out.AnnualRevenue != 1000 || out.Active || out.CreatedDate == nil {
+		t.Fatalf("Unexpected account: %+v", out)
+	}
+
+	// Read only fields can't be set.
+	readOnly := &contact{LastName: "Smith"}
+	readOnly.Name = "John Smith"
+	_, err = forceApi.InsertSObject(readOnly)
+	requireErrorCode(t, err, forcetest.InvalidFieldForInsertUpdateErrorCode)
+
+	// References must be to existing records.
+	_, err = forceApi.InsertSObject(&contact{LastName: "Smith", AccountId: forcetest.NewId("001", 999)})
+	requireErrorCode(t, err, forcetest.InvalidCrossReferenceKeyErrorCode)
+	_, err = forceApi.InsertSObject(&contact{LastName: "Smith", AccountId: "001"})
+	requireErrorCode(t, err, forcetest.MalformedIdErrorCode)
+
+	created, err := forceApi.InsertSObject(&contact{LastName: "Smith", AccountId: resp.Id})
+	if err != nil || !strings.HasPrefix(created.Id, "003") {
+		t.Fatalf("Unable to insert contact %+v: %v", created, err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	emulator := newTestEmulator(t)
+	ids := emulator.Seed("Account", forcetest.Record{"Name": "Acme"})
+	forceApi := newTestForceApi(t, emulator)
+
+	update := &account{Type: "Partner"}
+	update.Name = "Acme Corp"
+	if err := forceApi.UpdateSObject(ids[0], update); err != nil {
+		t.Fatalf("Unable to update account: %v", err)
+	}
+	out := &account{}
+	forceApi.GetSObject(ids[0], nil, out)
+	if out.Name != "Acme Corp" || out.Type != "Partner" {
+		t.Fatalf("Unexpected account: %+v", out)
+	}
+
+	err := emulator.Update("Account", ids[0], forcetest.Record{"CreatedDate": "2020-01-01T00:00:00.000+0000"})
+	apiError := &forcetest.Error{}
+	if !errors.As(err, &apiError) || apiError.ErrorCode != forcetest.InvalidFieldForInsertUpdateErrorCode ||
+		apiError.Fields[0] != "CreatedDate" {
+		t.Fatalf("Expected read only fields not to be updated, got %v", err)
+	}
+	err = emulator.Update("Account", ids[0], forcetest.Record{"Name": nil})
+	if !errors.As(err, &apiError) || apiError.ErrorCode != forcetest.RequiredFieldMissingErrorCode {
+		t.Fatalf("Expected required fields not to be cleared, got %v", err)
+	}
+	err = emulator.Update("Account", ids[0], forcetest.Record{"Widgets__c": 1})
+	if !errors.As(err, &apiError) || apiError.ErrorCode != forcetest.InvalidFieldErrorCode {
+		t.Fatalf("Expected unknown fields to be rejected, got %v", err)
+	}
+}
+
+func TestUpsertAndDelete(t *testing.T) {
+	forceApi := newTestForceApi(t, newTestEmulator(t))
+
+	in := &account{Type: "Customer"}
+	in.Name = "Acme"
+	resp, err := forceApi.UpsertSObjectByExternalId("ACME-1", in)
+	if err != nil || !resp.Created {
+		t.Fatalf("Expected the account to be created, got %+v: %v", resp, err)
+	}
+	in.Name = "Acme Corp"
+	updated, err := forceApi.UpsertSObjectByExternalId("acme-1", in)
+	if err != nil || updated.Created || updated.Id != resp.Id {
+		t.Fatalf("Expected the account to be updated, got %+v: %v", updated, err)
+	}
+
+	out := &account{}
+	if err := forceApi.GetSObjectByExternalId("ACME-1", nil, out); err != nil || out.Name != "Acme Corp" || out.ExternalId != "ACME-1" {
+		t.Fatalf("Unexpected account %+v: %v", out, err)
+	}
+
+	if err := forceApi.DeleteSObject(resp.Id, out); err != nil {
+		t.Fatalf("Unable to delete account: %v", err)
+	}
+	err = forceApi.GetSObject(resp.Id, nil, out)
+	requireErrorCode(t, err, forcetest.EntityIsDeletedErrorCode)
+
+	deleted := &sobjects.QueryResponse[*account]{}
+	if err := forceApi.QueryAll("SELECT Id, IsDeleted FROM Account", deleted); err != nil {
+		t.Fatalf("Unable to query deleted accounts: %v", err)
+	}
+	if len(deleted.Records) != 1 || !deleted.Records[0].IsDeleted {
+		t.Fatalf("Expected queryAll to find the deleted account, got %+v", deleted.Records)
+	}
+}
+
+func TestQueryRelationships(t *testing.T) {
+	emulator := newTestEmulator(t)
+	accountIds := emulator.Seed("Account", forcetest.Record{"Name": "Acme", "Type": "Customer"})
+	emulator.Seed("Contact",
+		forcetest.Record{"FirstName": "Jane", "LastName": "Doe", "AccountId": accountIds[0]},
+		forcetest.Record{"FirstName": "John", "LastName": "Roe"},
+	)
+	forceApi := newTestForceApi(t, emulator)
+
+	contacts := &sobjects.QueryResponse[*contact]{}
+	err := forceApi.Query("SELECT FirstName, Account.Name, Account.Type FROM Contact ORDER BY FirstName", contacts)
+	if err != nil {
+		t.Fatalf("Unable to query contacts: %v", err)
+	}
+	if len(contacts.Records) != 2 {
+		t.Fatalf("Unexpected contacts: %+v", contacts.Records)
+	}
+	if jane := contacts.Records[0]; jane.FirstName != "Jane" || jane.Account == nil || jane.Account.Name != "Acme" || jane.Account.Type != "Customer" {
+		t.Fatalf("Unexpected contact: %+v", jane)
+	}
+	if john := contacts.Records[1]; john.FirstName != "John" || john.Account != nil {
+		t.Fatalf("Unexpected contact: %+v", john)
+	}
+
+	_, err = emulator.Query("SELECT Owner.Name FROM Contact", false)
+	apiError := &forcetest.Error{}
+	if !errors.As(err, &apiError) || apiError.StatusCode != http.StatusBadRequest || apiError.ErrorCode != forcetest.InvalidFieldErrorCode {
+		t.Fatalf("Expected an unknown relationship to be rejected, got %v", err)
+	}
+}
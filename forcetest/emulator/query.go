@@ -0,0 +1,532 @@
+package emulator
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nimajalali/go-force/force"
+	"github.com/nimajalali/go-force/forcetest"
+	"github.com/nimajalali/go-force/soql"
+)
+
+// emulatorQuery is a query in the subset of SOQL understood by the Emulator:
+//
+//	SELECT field, ... FROM sobject
+//	[WHERE condition]
+//	[ORDER BY field [ASC|DESC] [NULLS FIRST|LAST], ...]
+//	[LIMIT n] [OFFSET n]
+//
+// Fields may be parent relationship fields, such as Account.Owner.Name. Conditions compare a field
+// with =, !=, <>, <, <=, >, >=, LIKE, IN or NOT IN, and are combined with AND, OR, NOT and
+// parentheses. Values are quoted strings, numbers, dates, datetimes, true, false and null.
+type emulatorQuery struct {
+	fields  []*fieldRef
+	sObject string
+	where   expression
+	orderBy []*ordering
+	limit   int
+	offset  int
+}
+
+// fieldRef is a field of the queried sobject, or of a parent reached through relationships.
+type fieldRef struct {
+	path string
+
+	// Resolved against the queried sobject.
+	relationships []*force.SObjectField
+	field         *force.SObjectField
+}
+
+type ordering struct {
+	field      *fieldRef
+	descending bool
+	nullsLast  bool
+}
+
+// scope resolves and evaluates the fields of a query of an sobject. The emulator must be locked.
+type scope struct {
+	emulator *Emulator
+	sObject  *sObject
+}
+
+type expression interface {
+	resolve(scope *scope) error
+	matches(scope *scope, record forcetest.Record) bool
+}
+
+type logical struct {
+	and      bool
+	operands []expression
+}
+
+func (logical *logical) resolve(scope *scope) error {
+	for _, operand := range logical.operands {
+		if err := operand.resolve(scope); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (logical *logical) matches(scope *scope, record forcetest.Record) bool {
+	for _, operand := range logical.operands {
+		if operand.matches(scope, record) != logical.and {
+			return !logical.and
+		}
+	}
+
+	return logical.and
+}
+
+type not struct {
+	operand expression
+}
+
+func (not *not) resolve(scope *scope) error {
+	return not.operand.resolve(scope)
+}
+
+func (not *not) matches(scope *scope, record forcetest.Record) bool {
+	return !not.operand.matches(scope, record)
+}
+
+type condition struct {
+	field    *fieldRef
+	operator string
+	values   []interface{}
+	pattern  *regexp.Regexp
+}
+
+func (condition *condition) resolve(scope *scope) (err error) {
+	if err = scope.resolve(condition.field); err != nil {
+		return
+	}
+	if condition.operator == "LIKE" {
+		pattern, _ := condition.values[0].(string)
+		condition.pattern = likePattern(pattern)
+	}
+
+	return
+}
+
+func (condition *condition) matches(scope *scope, record forcetest.Record) bool {
+	value := scope.value(record, condition.field)
+	field := condition.field.field
+
+	switch condition.operator {
+	case "=":
+		return equal(field, value, condition.values[0])
+	case "!=":
+		return !equal(field, value, condition.values[0])
+	case "<", "<=", ">", ">=":
+		c, ok := compare(field, value, condition.values[0])
+		if !ok {
+			return false
+		}
+		switch condition.operator {
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		}
+		return c >= 0
+	case "LIKE":
+		value, ok := value.(string)
+		return ok && condition.pattern.MatchString(value)
+	case "IN", "NOT IN":
+		for _, literal := range condition.values {
+			if equal(field, value, literal) {
+				return condition.operator == "IN"
+			}
+		}
+		return condition.operator == "NOT IN"
+	}
+
+	return false
+}
+
+// resolve resolves a field path, such as Account.Parent.Name, against the queried sobject.
+func (scope *scope) resolve(ref *fieldRef) error {
+	current := scope.sObject
+	names := strings.Split(ref.path, ".")
+	for _, name := range names[:len(names)-1] {
+		relationship := current.relationships[strings.ToLower(name)]
+		var parent *sObject
+		if relationship != nil {
+			parent = scope.emulator.parentSObject(relationship)
+		}
+		if parent == nil {
+			return forcetest.Errorf(http.StatusBadRequest, forcetest.InvalidFieldErrorCode,
+				"Didn't understand relationship '%v' in field path. If you are attempting to use a custom relationship, be sure to append the '__r' after the custom relationship name.", name)
+		}
+		ref.relationships = append(ref.relationships, relationship)
+		current = parent
+	}
+
+	ref.field = current.field(names[len(names)-1])
+	if ref.field == nil {
+		return forcetest.Errorf(http.StatusBadRequest, forcetest.InvalidFieldErrorCode,
+			"No such column '%v' on entity '%v'.", names[len(names)-1], current.description.Name)
+	}
+
+	return nil
+}
+
+// value returns the value of a field of record, or nil if a parent along its path isn't set.
+func (scope *scope) value(record forcetest.Record, ref *fieldRef) interface{} {
+	for _, relationship := range ref.relationships {
+		if _, record = scope.emulator.parent(record, relationship); record == nil {
+			return nil
+		}
+	}
+
+	return record[ref.field.Name]
+}
+
+// selectField sets a field of record in result, nesting parent fields in records named by their
+// relationship as the api does.
+func (scope *scope) selectField(result, record forcetest.Record, ref *fieldRef) {
+	for _, relationship := range ref.relationships {
+		var parentSObject *sObject
+		if parentSObject, record = scope.emulator.parent(record, relationship); record == nil {
+			if _, ok := result[relationship.RelationshipName]; !ok {
+				result[relationship.RelationshipName] = nil
+			}
+			return
+		}
+
+		nested, ok := result[relationship.RelationshipName].(forcetest.Record)
+		if !ok {
+			nested = forcetest.Record{"attributes": map[string]interface{}{"type": parentSObject.description.Name}}
+			result[relationship.RelationshipName] = nested
+		}
+		result = nested
+	}
+
+	result[ref.field.Name] = record[ref.field.Name]
+}
+
+// sort sorts records by the fields of an ORDER BY clause. Nulls are first in ascending order and last
+// in descending order, unless NULLS FIRST or NULLS LAST says otherwise.
+func (scope *scope) sort(records []forcetest.Record, orderBy []*ordering) {
+	sort.SliceStable(records, func(i, j int) bool {
+		for _, ordering := range orderBy {
+			a, b := scope.value(records[i], ordering.field), scope.value(records[j], ordering.field)
+			switch {
+			case a == nil && b == nil:
+				continue
+			case a == nil:
+				return !ordering.nullsLast
+			case b == nil:
+				return ordering.nullsLast
+			}
+
+			c, _ := compare(ordering.field.field, a, b)
+			if c == 0 {
+				continue
+			}
+			return (c < 0) != ordering.descending
+		}
+
+		return false
+	})
+}
+
+// equal reports whether a field value equals a literal. Text is compared case insensitively as in
+// SOQL, while ids are compared case sensitively, in their 15 or 18 character forms.
+func equal(field *force.SObjectField, value, literal interface{}) bool {
+	if value == nil || literal == nil {
+		return value == nil && literal == nil
+	}
+	c, ok := compare(field, value, literal)
+
+	return ok && c == 0
+}
+
+// compare compares a field value with a literal or another value of the field. It reports false
+// if they can't be compared, such as a string with a number.
+func compare(field *force.SObjectField, a, b interface{}) (int, bool) {
+	if a == nil || b == nil {
+		return 0, false
+	}
+
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		switch {
+		case !ok:
+			return 0, false
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	switch x := a.(type) {
+	case bool:
+		y, ok := b.(bool)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x == y:
+			return 0, true
+		case y:
+			return -1, true
+		}
+		return 1, true
+
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		switch field.Type {
+		case "id", "reference":
+			return strings.Compare(idKey(x), idKey(y)), true
+		case "datetime":
+			if x, err := parseDateTime(x); err == nil {
+				if y, err := parseDateTime(y); err == nil {
+					switch {
+					case x.Before(y):
+						return -1, true
+					case x.After(y):
+						return 1, true
+					}
+					return 0, true
+				}
+			}
+		}
+		return strings.Compare(strings.ToLower(x), strings.ToLower(y)), true
+	}
+
+	return 0, false
+}
+
+func number(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case float64:
+		return value, true
+	case float32:
+		return float64(value), true
+	case int:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	}
+
+	return 0, false
+}
+
+// Layouts of datetimes in records and queries.
+var dateTimeLayouts = []string{
+	"2006-01-02T15:04:05.000-0700",
+	"2006-01-02T15:04:05-0700",
+	time.RFC3339Nano,
+	"2006-01-02",
+}
+
+func parseDateTime(value string) (t time.Time, err error) {
+	for _, layout := range dateTimeLayouts {
+		if t, err = time.Parse(layout, value); err == nil {
+			return
+		}
+	}
+
+	return
+}
+
+// likePattern returns a case insensitive regexp matching the SOQL LIKE pattern, in which % matches
+// any characters and _ a single character, unless they're escaped with a backslash.
+func likePattern(pattern string) *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString("(?is)^")
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped && (r == '%' || r == '_' || r == '\\'):
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		case escaped:
+			expr.WriteString(regexp.QuoteMeta(`\` + string(r)))
+		case r == '\\':
+			escaped = true
+			continue
+		case r == '%':
+			expr.WriteString(".*")
+		case r == '_':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+		escaped = false
+	}
+	if escaped {
+		expr.WriteString(regexp.QuoteMeta(`\`))
+	}
+	expr.WriteString("$")
+
+	return regexp.MustCompile(expr.String())
+}
+
+// unescapeWildcards unescapes the LIKE wildcards and backslashes of strings compared other than by
+// LIKE, in which they stand for themselves.
+var unescapeWildcards = strings.NewReplacer(`\%`, "%", `\_`, "_", `\\`, `\`)
+
+func parseEmulatorQuery(s string) (*emulatorQuery, error) {
+	parsed, err := soql.Parse(s)
+	syntaxError := &soql.SyntaxError{}
+	if errors.As(err, &syntaxError) {
+		return nil, forcetest.Errorf(http.StatusBadRequest, forcetest.MalformedQueryErrorCode, "%v", syntaxError)
+	} else if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(parsed.From.Alias) > 0:
+		return nil, unsupportedSoql("sobject aliases")
+	case len(parsed.From.Scope) > 0:
+		return nil, unsupportedSoql("USING SCOPE")
+	case parsed.With != nil:
+		return nil, unsupportedSoql("WITH")
+	case parsed.GroupBy != nil:
+		return nil, unsupportedSoql("GROUP BY")
+	case len(parsed.For) > 0:
+		return nil, unsupportedSoql("FOR " + parsed.For)
+	case len(parsed.Update) > 0:
+		return nil, unsupportedSoql("UPDATE " + parsed.Update)
+	}
+
+	query := &emulatorQuery{sObject: parsed.From.SObject, limit: -1}
+	for _, item := range parsed.Select {
+		field, ok := item.(*soql.Field)
+		if !ok || len(field.Alias) > 0 {
+			return nil, unsupportedSoql(item.String())
+		}
+		query.fields = append(query.fields, &fieldRef{path: field.Name})
+	}
+
+	if parsed.Where != nil {
+		if query.where, err = emulatorExpression(parsed.Where); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, item := range parsed.OrderBy {
+		field, ok := item.Expr.(*soql.Field)
+		if !ok {
+			return nil, unsupportedSoql(item.Expr.String())
+		}
+		query.orderBy = append(query.orderBy, &ordering{
+			field:      &fieldRef{path: field.Name},
+			descending: item.Descending,
+			nullsLast:  item.Nulls == "LAST" || (item.Descending && item.Nulls != "FIRST"),
+		})
+	}
+
+	if parsed.Limit != nil {
+		query.limit = *parsed.Limit
+	}
+	if parsed.Offset != nil {
+		query.offset = *parsed.Offset
+	}
+
+	return query, nil
+}
+
+// emulatorExpression returns the expression evaluating a condition of a query.
+func emulatorExpression(where soql.Condition) (expression, error) {
+	switch where := where.(type) {
+	case *soql.Logical:
+		logical := &logical{and: where.Operator == "AND"}
+		for _, condition := range where.Conditions {
+			operand, err := emulatorExpression(condition)
+			if err != nil {
+				return nil, err
+			}
+			logical.operands = append(logical.operands, operand)
+		}
+		return logical, nil
+
+	case *soql.Not:
+		operand, err := emulatorExpression(where.Condition)
+		if err != nil {
+			return nil, err
+		}
+		return &not{operand: operand}, nil
+
+	case *soql.Comparison:
+		field, ok := where.Left.(*soql.Field)
+		if !ok {
+			return nil, unsupportedSoql(where.Left.String())
+		}
+		condition := &condition{field: &fieldRef{path: field.Name}, operator: where.Operator}
+
+		switch where.Operator {
+		case "IN", "NOT IN":
+			list, ok := where.Right.(*soql.List)
+			if !ok {
+				return nil, unsupportedSoql(where.Right.String())
+			}
+			for _, expr := range list.Values {
+				value, err := literalValue(expr)
+				if err != nil {
+					return nil, err
+				}
+				condition.values = append(condition.values, value)
+			}
+
+		case "LIKE":
+			pattern, ok := where.Right.(*soql.StringLiteral)
+			if !ok {
+				return nil, forcetest.Errorf(http.StatusBadRequest, forcetest.MalformedQueryErrorCode,
+					"LIKE requires a string, got %v", where.Right)
+			}
+			condition.values = []interface{}{pattern.Value}
+
+		case "INCLUDES", "EXCLUDES":
+			return nil, unsupportedSoql(where.Operator)
+
+		default:
+			value, err := literalValue(where.Right)
+			if err != nil {
+				return nil, err
+			}
+			condition.values = []interface{}{value}
+		}
+		return condition, nil
+	}
+
+	return nil, unsupportedSoql(where.String())
+}
+
+// literalValue returns the value of a string, number, boolean, null or date literal, as it's held
+// in a record: dates are strings and numbers are float64.
+func literalValue(expr soql.Expr) (interface{}, error) {
+	switch literal := expr.(type) {
+	case *soql.StringLiteral:
+		return unescapeWildcards.Replace(literal.Value), nil
+	case *soql.NumberLiteral:
+		return strconv.ParseFloat(literal.Value, 64)
+	case *soql.BooleanLiteral:
+		return literal.Value, nil
+	case *soql.NullLiteral:
+		return nil, nil
+	case *soql.DateLiteral:
+		return literal.Value, nil
+	}
+
+	return nil, unsupportedSoql(expr.String())
+}
+
+func unsupportedSoql(feature string) *forcetest.Error {
+	return forcetest.Errorf(http.StatusBadRequest, forcetest.MalformedQueryErrorCode,
+		"The emulator doesn't support %v", feature)
+}
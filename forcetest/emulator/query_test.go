@@ -0,0 +1,141 @@
+package emulator
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/nimajalali/go-force/forcetest"
+)
+
+func TestQuery(t *testing.T) {
+	emulator := newTestEmulator(t)
+	parentIds := emulator.Seed("Account", forcetest.Record{"Name": "Globex Holdings", "Industry": "Finance"})
+	emulator.Seed("Account",
+		forcetest.Record{"Name": "Acme", "Type": "Customer", "AnnualRevenue": 500.0, "NumberOfEmployees": 10, "CreatedDate": "2024-01-01T00:00:00.000+0000"},
+		forcetest.Record{"Name": "Initech", "Type": "Prospect", "AnnualRevenue": 1500.0, "CreatedDate": "2024-03-01T00:00:00.000+0000"},
+		forcetest.Record{"Name": "Globex", "Type": "Customer", "ParentId": parentIds[0], "CreatedDate": "2024-02-01T09:30:00.000+0000"},
+		forcetest.Record{"Name": "Umbrella", "Type": "Partner", "AnnualRevenue": 2500.0, "Active__c": true, "CreatedDate": "2024-04-01T00:00:00.000+0000"},
+	)
+
+	tests := []struct {
+		soql  string
+		names string
+	}{
+		{"SELECT Name FROM Account WHERE Type = 'customer'", "[Acme Globex]"},
+		{"SELECT Name FROM Account WHERE Type != 'Customer'", "[Globex Holdings Initech Umbrella]"},
+		{"SELECT Name FROM Account WHERE Type = 'Customer' OR Type = 'Partner'", "[Acme Globex Umbrella]"},
+		{"SELECT Name FROM Account WHERE Type = 'Customer' AND AnnualRevenue > 100", "[Acme]"},
+		{"SELECT Name FROM Account WHERE (Type = 'Customer' OR Type = 'Partner') AND AnnualRevenue >= 500", "[Acme Umbrella]"},
+		{"SELECT Name FROM Account WHERE NOT Type = 'Customer' AND Type != null", "[Initech Umbrella]"},
+		{"SELECT Name FROM Account WHERE Type IN ('Prospect', 'Partner')", "[Initech Umbrella]"},
+		{"SELECT Name FROM Account WHERE Type NOT IN ('Prospect', 'Partner')", "[Globex Holdings Acme Globex]"},
+		{"SELECT Name FROM Account WHERE Name LIKE 'glo%'", "[Globex Holdings Globex]"},
+		{"SELECT Name FROM Account WHERE Name LIKE 'Acm_'", "[Acme]"},
+		{"SELECT Name FROM Account WHERE AnnualRevenue = null", "[Globex Holdings Globex]"},
+		{"SELECT Name FROM Account WHERE Active__c = true", "[Umbrella]"},
+		{"SELECT Name FROM Account WHERE NumberOfEmployees = 10", "[Acme]"},
+		{"SELECT Name FROM Account WHERE CreatedDate > 2024-02-01T00:00:00Z AND CreatedDate < 2024-04-01", "[Initech Globex]"},
+		{"SELECT Name FROM Account WHERE Parent.Industry = 'Finance'", "[Globex]"},
+		{"SELECT Name FROM Account WHERE Id = '" + parentIds[0][:15] + "'", "[Globex Holdings]"},
+		{"SELECT Name FROM Account ORDER BY Name", "[Acme Globex Globex Holdings Initech Umbrella]"},
+		{"SELECT Name FROM Account ORDER BY AnnualRevenue DESC", "[Umbrella Initech Acme Globex Holdings Globex]"},
+		{"SELECT Name FROM Account ORDER BY AnnualRevenue", "[Globex Holdings Globex Acme Initech Umbrella]"},
+		{"SELECT Name FROM Account ORDER BY AnnualRevenue ASC NULLS LAST, Name DESC", "[Acme Initech Umbrella Globex Holdings Globex]"},
+		{"SELECT Name FROM Account ORDER BY Type, Name DESC", "[Globex Holdings Globex Acme Umbrella Initech]"},
+		{"SELECT Name FROM Account ORDER BY Name LIMIT 2", "[Acme Globex]"},
+		{"SELECT Name FROM Account ORDER BY Name LIMIT 2 OFFSET 3", "[Initech Umbrella]"},
+		{"SELECT Name FROM Account OFFSET 10", "[]"},
+	}
+	for _, test := range tests {
+		records, err := emulator.Query(test.soql, false)
+		if err != nil {
+			t.Errorf("Unable to query %q: %v", test.soql, err)
+			continue
+		}
+		names := []string{}
+		for _, record := range records {
+			names = append(names, record["Name"].(string))
+		}
+		if fmt.Sprint(names) != test.names {
+			t.Errorf("Unexpected results of %q: %v, expected %v", test.soql, names, test.names)
+		}
+	}
+}
+
+func TestQueryParentFields(t *testing.T) {
+	emulator := newTestEmulator(t)
+	parentIds := emulator.Seed("Account", forcetest.Record{"Name": "Globex Holdings"})
+	accountIds := emulator.Seed("Account", forcetest.Record{"Name": "Globex", "ParentId": parentIds[0]})
+	emulator.Seed("Contact", forcetest.Record{"LastName": "Doe", "AccountId": accountIds[0]})
+
+	records, err := emulator.Query("select lastname, account.name, account.parent.name, account.parent.id from contact", false)
+	if err != nil {
+		t.Fatalf("Unable to query: %v", err)
+	}
+	account, _ := records[0]["Account"].(forcetest.Record)
+	parent, _ := account["Parent"].(forcetest.Record)
+	if records[0]["LastName"] != "Doe" || account["Name"] != "Globex" || parent["Name"] != "Globex Holdings" ||
+		parent["Id"] != parentIds[0] {
+		t.Fatalf("Unexpected record: %v", records[0])
+	}
+	if attributes := parent["attributes"].(map[string]interface{}); attributes["type"] != "Account" {
+		t.Fatalf("Unexpected attributes: %v", attributes)
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	emulator := newTestEmulator(t)
+
+	tests := []struct {
+		soql      string
+		errorCode string
+	}{
+		{"SELECT Name FROM Widget__c", forcetest.InvalidTypeErrorCode},
+		{"SELECT Widgets__c FROM Account", forcetest.InvalidFieldErrorCode},
+		{"SELECT Name FROM Account WHERE Widgets__c = 1", forcetest.InvalidFieldErrorCode},
+		{"SELECT Name FROM Account ORDER BY Owner.Name", forcetest.InvalidFieldErrorCode},
+		{"SELECT Name Account", forcetest.MalformedQueryErrorCode},
+		{"SELECT Name FROM Account WHERE Name = 'Acme", forcetest.MalformedQueryErrorCode},
+		{"SELECT Name FROM Account WHERE Name IN 'Acme'", forcetest.MalformedQueryErrorCode},
+		{"SELECT Name FROM Account WHERE (Name = 'Acme'", forcetest.MalformedQueryErrorCode},
+		{"SELECT Name FROM Account LIMIT -1", forcetest.MalformedQueryErrorCode},
+		{"SELECT Name FROM Account GROUP BY Name", forcetest.MalformedQueryErrorCode},
+		{"SELECT COUNT() FROM Account", forcetest.MalformedQueryErrorCode},
+		{"SELECT Name FROM Account a", forcetest.MalformedQueryErrorCode},
+		{"SELECT Name FROM Account WHERE Name INCLUDES ('Acme')", forcetest.MalformedQueryErrorCode},
+		{"SELECT Name FROM Account WHERE Id IN (SELECT AccountId FROM Contact)", forcetest.MalformedQueryErrorCode},
+		{"SELECT Name FROM Account WHERE CreatedDate = TODAY", forcetest.MalformedQueryErrorCode},
+		{"SELECT Name FROM Account WHERE Name LIKE 1", forcetest.MalformedQueryErrorCode},
+	}
+	for _, test := range tests {
+		_, err := emulator.Query(test.soql, false)
+		apiError := &forcetest.Error{}
+		if !errors.As(err, &apiError) || apiError.ErrorCode != test.errorCode {
+			t.Errorf("Expected %q to fail with %v, got %v", test.soql, test.errorCode, err)
+		}
+	}
+}
+
+func TestLikePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		matches bool
+	}{
+		{"ac%", "ACME", true},
+		{"a_me", "Acme", true},
+		{"100\\%", "100%", true},
+		{"100\\%", "1000", false},
+		{"a\\_c", "a_c", true},
+		{"a\\_c", "abc", false},
+		{"C:\\\\dir", "c:\\dir", true},
+		{"a\\\\%", "a\\bc", true},
+		{"a\\\\%", "a%", false},
+	}
+	for _, test := range tests {
+		if likePattern(test.pattern).MatchString(test.value) != test.matches {
+			t.Errorf("Expected %q LIKE %q to be %v", test.value, test.pattern, test.matches)
+		}
+	}
+}
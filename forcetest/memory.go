@@ -0,0 +1,411 @@
+package forcetest
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const timeFormat = "2006-01-02T15:04:05.000-0700"
+
+// Fields every sobject has.
+var systemFields = []string{"Id", "IsDeleted", "CreatedDate", "LastModifiedDate", "SystemModstamp"}
+
+// MemoryBackend is a Backend holding records in memory. Field values aren't validated against the
+// described fields, and queries are limited to a subset of SOQL: field selection, equality
+// conditions joined by AND, and LIMIT. It's safe for concurrent use.
+type MemoryBackend struct {
+	mu       sync.Mutex
+	sObjects map[string]*memorySObject
+	lastId   int
+}
+
+type memorySObject struct {
+	info    *SObjectInfo
+	fields  []string
+	records map[string]Record
+	// Ids in order of insertion, which queries return records in.
+	ids []string
+}
+
+// NewMemoryBackend returns a MemoryBackend holding the standard Account, Contact, Lead,
+// Opportunity and User sobjects, without records.
+func NewMemoryBackend() *MemoryBackend {
+	backend := &MemoryBackend{sObjects: make(map[string]*memorySObject)}
+	backend.AddSObject("Account", "001", "Name", "AccountNumber", "Type", "Industry", "Phone", "Website",
+		"BillingStreet", "BillingCity", "BillingState", "BillingPostalCode", "BillingCountry")
+	backend.AddSObject("Contact", "003", "Name", "FirstName", "LastName", "Email", "Phone", "AccountId")
+	backend.AddSObject("Lead", "00Q", "Name", "FirstName", "LastName", "Email", "Company", "Status")
+	backend.AddSObject("Opportunity", "006", "Name", "AccountId", "Amount", "CloseDate", "StageName")
+	backend.AddSObject("User", "005", "Name", "FirstName", "LastName", "Email", "Username", "IsActive")
+
+	return backend
+}
+
+// AddSObject adds an sobject with the given fields, which are described as strings, in addition to
+// the system fields such as Id. Custom sobjects are those whose name ends in __c.
+func (backend *MemoryBackend) AddSObject(name, keyPrefix string, fields ...string) {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	backend.sObjects[strings.ToLower(name)] = &memorySObject{
+		info: &SObjectInfo{
+			Name:      name,
+			Label:     name,
+			KeyPrefix: keyPrefix,
+			Custom:    strings.HasSuffix(name, "__c"),
+		},
+		fields:  append(append([]string{}, systemFields...), fields...),
+		records: make(map[string]Record),
+	}
+}
+
+// Seed adds records as they are, without setting system fields. Records without an Id are given
+// one. It returns the ids of the records, and panics if the sobject doesn't exist.
+func (backend *MemoryBackend) Seed(name string, records ...Record) []string {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	sObject := backend.sObjects[strings.ToLower(name)]
+	if sObject == nil {
+		panic("forcetest: unknown sobject " + name)
+	}
+
+	ids := make([]string, len(records))
+	for i, fields := range records {
+		record := copyRecord(fields)
+		id, _ := record["Id"].(string)
+		if len(id) == 0 {
+			id = backend.newId(sObject)
+			record["Id"] = id
+		}
+		if _, ok := record["IsDeleted"]; !ok {
+			record["IsDeleted"] = false
+		}
+		sObject.put(id, record)
+		ids[i] = id
+	}
+
+	return ids
+}
+
+func (backend *MemoryBackend) SObjects() []*SObjectInfo {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	list := make([]*SObjectInfo, 0, len(backend.sObjects))
+	for _, sObject := range backend.sObjects {
+		info := *sObject.info
+		list = append(list, &info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list
+}
+
+func (backend *MemoryBackend) Describe(name string) (json.RawMessage, error) {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	sObject, err := backend.sObject(name)
+	if err != nil {
+		return nil, err
+	}
+
+	type field struct {
+		Name  string `json:"name"`
+		Label string `json:"label"`
+		Type  string `json:"type"`
+	}
+	description := struct {
+		Name       string  `json:"name"`
+		Label      string  `json:"label"`
+		KeyPrefix  string  `json:"keyPrefix"`
+		Custom     bool    `json:"custom"`
+		Createable bool    `json:"createable"`
+		Updateable bool    `json:"updateable"`
+		Deletable  bool    `json:"deletable"`
+		Queryable  bool    `json:"queryable"`
+		Fields     []field `json:"fields"`
+	}{
+		Name:       sObject.info.Name,
+		Label:      sObject.info.Label,
+		KeyPrefix:  sObject.info.KeyPrefix,
+		Custom:     sObject.info.Custom,
+		Createable: true,
+		Updateable: true,
+		Deletable:  true,
+		Queryable:  true,
+	}
+	for _, name := range sObject.fields {
+		fieldType := "string"
+		switch name {
+		case "Id":
+			fieldType = "id"
+		case "IsDeleted":
+			fieldType = "boolean"
+		case "CreatedDate", "LastModifiedDate", "SystemModstamp":
+			fieldType = "datetime"
+		}
+		description.Fields = append(description.Fields, field{Name: name, Label: name, Type: fieldType})
+	}
+
+	return json.Marshal(description)
+}
+
+func (backend *MemoryBackend) Get(name, id string) (Record, error) {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	sObject, err := backend.sObject(name)
+	if err != nil {
+		return nil, err
+	}
+	record, err := sObject.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return sObject.result(record), nil
+}
+
+func (backend *MemoryBackend) GetByExternalId(name, field, value string) (Record, error) {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	sObject, err := backend.sObject(name)
+	if err != nil {
+		return nil, err
+	}
+	record, err := sObject.getByExternalId(field, value)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, NotFound()
+	}
+
+	return sObject.result(record), nil
+}
+
+func (backend *MemoryBackend) Insert(name string, fields Record) (string, error) {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	sObject, err := backend.sObject(name)
+	if err != nil {
+		return "", err
+	}
+
+	return backend.insert(sObject, fields), nil
+}
+
+func (backend *MemoryBackend) Update(name, id string, fields Record) error {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	sObject, err := backend.sObject(name)
+	if err != nil {
+		return err
+	}
+	record, err := sObject.get(id)
+	if err != nil {
+		return err
+	}
+	update(record, fields)
+
+	return nil
+}
+
+func (backend *MemoryBackend) Upsert(name, field, value string, fields Record) (string, bool, error) {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	sObject, err := backend.sObject(name)
+	if err != nil {
+		return "", false, err
+	}
+	record, err := sObject.getByExternalId(field, value)
+	if err != nil {
+		return "", false, err
+	}
+	if record != nil {
+		update(record, fields)
+		return record["Id"].(string), false, nil
+	}
+
+	fields = copyRecord(fields)
+	fields[field] = value
+
+	return backend.insert(sObject, fields), true, nil
+}
+
+func (backend *MemoryBackend) Delete(name, id string) error {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	sObject, err := backend.sObject(name)
+	if err != nil {
+		return err
+	}
+	record, err := sObject.get(id)
+	if err != nil {
+		return err
+	}
+
+	// Deleted records stay in the recycle bin, where queryAll finds them.
+	update(record, Record{"IsDeleted": true})
+
+	return nil
+}
+
+func (backend *MemoryBackend) Query(soql string, all bool) ([]Record, error) {
+	query, err := parseMemoryQuery(soql)
+	if err != nil {
+		return nil, err
+	}
+
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	sObject, err := backend.sObject(query.sObject)
+	if err != nil {
+		return nil, Errorf(http.StatusBadRequest, InvalidTypeErrorCode, "sObject type '%v' is not supported.", query.sObject)
+	}
+
+	records := []Record{}
+	for _, id := range sObject.ids {
+		record := sObject.records[id]
+		if deleted, _ := record["IsDeleted"].(bool); deleted && !all {
+			continue
+		}
+		if !query.matches(record) {
+			continue
+		}
+		if query.limit >= 0 && len(records) == query.limit {
+			break
+		}
+
+		result := Record{"attributes": map[string]interface{}{"type": sObject.info.Name}}
+		for _, field := range query.fields {
+			value, _ := record.Get(field)
+			result[sObject.fieldName(field)] = value
+		}
+		records = append(records, result)
+	}
+
+	return records, nil
+}
+
+func (backend *MemoryBackend) sObject(name string) (*memorySObject, error) {
+	sObject := backend.sObjects[strings.ToLower(name)]
+	if sObject == nil {
+		return nil, NotFound()
+	}
+
+	return sObject, nil
+}
+
+func (backend *MemoryBackend) insert(sObject *memorySObject, fields Record) string {
+	id := backend.newId(sObject)
+	now := time.Now().UTC().Format(timeFormat)
+
+	record := copyRecord(fields)
+	record["Id"] = id
+	record["IsDeleted"] = false
+	record["CreatedDate"] = now
+	record["LastModifiedDate"] = now
+	record["SystemModstamp"] = now
+	sObject.put(id, record)
+
+	return id
+}
+
+func (backend *MemoryBackend) newId(sObject *memorySObject) string {
+	backend.lastId++
+	return NewId(sObject.info.KeyPrefix, backend.lastId)
+}
+
+func (sObject *memorySObject) put(id string, record Record) {
+	key := idKey(id)
+	if _, ok := sObject.records[key]; !ok {
+		sObject.ids = append(sObject.ids, key)
+	}
+	sObject.records[key] = record
+}
+
+func (sObject *memorySObject) get(id string) (Record, error) {
+	record := sObject.records[idKey(id)]
+	if record == nil {
+		return nil, NotFound()
+	}
+	if deleted, _ := record["IsDeleted"].(bool); deleted {
+		return nil, Errorf(http.StatusNotFound, EntityIsDeletedErrorCode, "entity is deleted")
+	}
+
+	return record, nil
+}
+
+// getByExternalId returns the record whose field is value, or nil if there is none.
+func (sObject *memorySObject) getByExternalId(field, value string) (Record, error) {
+	var found Record
+	for _, id := range sObject.ids {
+		record := sObject.records[id]
+		if deleted, _ := record["IsDeleted"].(bool); deleted {
+			continue
+		}
+		if recordValue, _ := record.Get(field); equal(recordValue, value) {
+			if found != nil {
+				return nil, Errorf(http.StatusMultipleChoices, MultipleChoicesErrorCode, "More than one record found for %v %v", field, value)
+			}
+			found = record
+		}
+	}
+
+	return found, nil
+}
+
+// result returns a copy of record, as returned by the api.
+func (sObject *memorySObject) result(record Record) Record {
+	result := copyRecord(record)
+	result["attributes"] = map[string]interface{}{"type": sObject.info.Name}
+
+	return result
+}
+
+// fieldName returns the described name of field, or field itself if it isn't described.
+func (sObject *memorySObject) fieldName(field string) string {
+	for _, name := range sObject.fields {
+		if strings.EqualFold(name, field) {
+			return name
+		}
+	}
+
+	return field
+}
+
+func update(record, fields Record) {
+	for name, value := range fields {
+		if name == "attributes" || name == "Id" {
+			continue
+		}
+		record[name] = value
+	}
+	now := time.Now().UTC().Format(timeFormat)
+	record["LastModifiedDate"] = now
+	record["SystemModstamp"] = now
+}
+
+func copyRecord(fields Record) Record {
+	record := make(Record, len(fields))
+	for name, value := range fields {
+		if name != "attributes" {
+			record[name] = value
+		}
+	}
+
+	return record
+}
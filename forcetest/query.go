@@ -0,0 +1,167 @@
+package forcetest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nimajalali/go-force/soql"
+)
+
+// memoryQuery is a query in the subset of SOQL understood by MemoryBackend:
+//
+//	SELECT field, ... FROM sobject [WHERE field op value [AND field op value]...] [LIMIT n]
+//
+// where op is =, != or <>, and value is a quoted string, a number, true, false or null.
+type memoryQuery struct {
+	fields     []string
+	sObject    string
+	conditions []*condition
+	limit      int
+}
+
+type condition struct {
+	field string
+	not   bool
+	value interface{}
+}
+
+// matches reports whether record satisfies every condition of the query.
+func (query *memoryQuery) matches(record Record) bool {
+	for _, condition := range query.conditions {
+		value, _ := record.Get(condition.field)
+		if equal(value, condition.value) == condition.not {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equal(value, literal interface{}) bool {
+	switch literal := literal.(type) {
+	case float64:
+		switch value := value.(type) {
+		case float64:
+			return value == literal
+		case int:
+			return float64(value) == literal
+		}
+		return false
+	case string:
+		// Ids match in their 15 and 18 character forms.
+		if value, ok := value.(string); ok {
+			return value == literal || (len(value)+len(literal) == 15+18 && idKey(value) == idKey(literal))
+		}
+		return false
+	}
+
+	return value == literal
+}
+
+func parseMemoryQuery(s string) (*memoryQuery, error) {
+	parsed, err := parseQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.With != nil || parsed.GroupBy != nil || len(parsed.OrderBy) > 0 || parsed.Offset != nil ||
+		len(parsed.For) > 0 || len(parsed.Update) > 0 || len(parsed.From.Alias) > 0 || len(parsed.From.Scope) > 0 {
+		return nil, unsupportedQuery()
+	}
+
+	query := &memoryQuery{sObject: parsed.From.SObject, limit: -1}
+	for _, item := range parsed.Select {
+		field, ok := item.(*soql.Field)
+		if !ok || len(field.Alias) > 0 {
+			return nil, unsupportedQuery()
+		}
+		query.fields = append(query.fields, field.Name)
+	}
+
+	if parsed.Where != nil {
+		conditions := []soql.Condition{parsed.Where}
+		if logical, ok := parsed.Where.(*soql.Logical); ok && logical.Operator == "AND" {
+			conditions = logical.Conditions
+		}
+		for _, where := range conditions {
+			condition, err := memoryCondition(where)
+			if err != nil {
+				return nil, err
+			}
+			query.conditions = append(query.conditions, condition)
+		}
+	}
+
+	if parsed.Limit != nil {
+		query.limit = *parsed.Limit
+	}
+
+	return query, nil
+}
+
+func memoryCondition(where soql.Condition) (*condition, error) {
+	comparison, ok := where.(*soql.Comparison)
+	if !ok {
+		return nil, unsupportedQuery()
+	}
+	field, ok := comparison.Left.(*soql.Field)
+	if !ok {
+		return nil, unsupportedQuery()
+	}
+
+	condition := &condition{field: field.Name}
+	switch comparison.Operator {
+	case "=":
+	case "!=":
+		condition.not = true
+	default:
+		return nil, Errorf(http.StatusBadRequest, MalformedQueryErrorCode,
+			"Unsupported operator %q, forcetest only supports = and !=", comparison.Operator)
+	}
+
+	var err error
+	if condition.value, err = literalValue(comparison.Right); err != nil {
+		return nil, err
+	}
+
+	return condition, nil
+}
+
+// parseQuery parses soql, returning a MALFORMED_QUERY error if it isn't valid.
+func parseQuery(s string) (*soql.Query, error) {
+	query, err := soql.Parse(s)
+	syntaxError := &soql.SyntaxError{}
+	if errors.As(err, &syntaxError) {
+		return nil, Errorf(http.StatusBadRequest, MalformedQueryErrorCode, "%v", syntaxError)
+	}
+
+	return query, err
+}
+
+// unescapeString unescapes the LIKE wildcards and backslashes the soql package keeps escaped in
+// strings, which stand for themselves in the = and != comparisons of a memoryQuery.
+var unescapeString = strings.NewReplacer(`\%`, "%", `\_`, "_", `\\`, `\`)
+
+// literalValue returns the value of a string, number, boolean, null or date literal of a query,
+// as it's held in a Record: dates are strings and numbers are float64.
+func literalValue(expr soql.Expr) (interface{}, error) {
+	switch literal := expr.(type) {
+	case *soql.StringLiteral:
+		return unescapeString.Replace(literal.Value), nil
+	case *soql.NumberLiteral:
+		return strconv.ParseFloat(literal.Value, 64)
+	case *soql.BooleanLiteral:
+		return literal.Value, nil
+	case *soql.NullLiteral:
+		return nil, nil
+	case *soql.DateLiteral:
+		return literal.Value, nil
+	}
+
+	return nil, Errorf(http.StatusBadRequest, MalformedQueryErrorCode, "Unsupported value %v", expr)
+}
+
+func unsupportedQuery() *Error {
+	return Errorf(http.StatusBadRequest, MalformedQueryErrorCode, "forcetest only supports a subset of SOQL")
+}
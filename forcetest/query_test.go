@@ -0,0 +1,60 @@
+package forcetest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMemoryQuery(t *testing.T) {
+	query, err := parseMemoryQuery(`select Id, Name FROM Account WHERE Name = 'O\'Brien' and Active__c != true AND Amount <> 10.5 AND Phone = null AND Description = 'C:\\dir 100\%' LIMIT 5`)
+	if err != nil {
+		t.Fatalf("Unable to parse query: %v", err)
+	}
+
+	expected := &memoryQuery{
+		fields:  []string{"Id", "Name"},
+		sObject: "Account",
+		conditions: []*condition{
+			{field: "Name", value: "O'Brien"},
+			{field: "Active__c", not: true, value: true},
+			{field: "Amount", not: true, value: 10.5},
+			{field: "Phone", value: nil},
+			{field: "Description", value: `C:\dir 100%`},
+		},
+		limit: 5,
+	}
+	if !reflect.DeepEqual(query, expected) {
+		t.Fatalf("Unexpected query: %+v", query)
+	}
+}
+
+func TestParseMemoryQueryErrors(t *testing.T) {
+	for _, soql := range []string{
+		"",
+		"SELECT FROM Account",
+		"SELECT Id Account",
+		"SELECT Id FROM Account WHERE Name LIKE 'A%'",
+		"SELECT Id FROM Account WHERE Name = 'A' OR Name = 'B'",
+		"SELECT Id FROM Account ORDER BY Name",
+		"SELECT Id FROM Account LIMIT x",
+	} {
+		_, err := parseMemoryQuery(soql)
+		if apiError, ok := err.(*Error); !ok || apiError.ErrorCode != MalformedQueryErrorCode {
+			t.Fatalf("Expected a MALFORMED_QUERY error for %q, got %v", soql, err)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	query, err := parseMemoryQuery("SELECT Id FROM Account WHERE Id = '001000000000001' AND NumberOfEmployees = 10")
+	if err != nil {
+		t.Fatalf("Unable to parse query: %v", err)
+	}
+
+	if !query.matches(Record{"Id": "001000000000001AAA", "NumberOfEmployees": 10.0}) {
+		t.Fatal("Expected the 18 character id to match")
+	}
+	if query.matches(Record{"Id": "001000000000001AAA", "NumberOfEmployees": 11.0}) {
+		t.Fatal("Expected a different number not to match")
+	}
+}
@@ -0,0 +1,526 @@
+// Package forcetest provides a fake force.com REST API server for tests, so that code using
+// go-force can be tested without a network or an org.
+//
+// The server issues access tokens from its oauth token endpoint, for any credentials, and serves
+// the api resources, sobject listing and describes, record CRUD by id and by external id, and
+// queries with paginated results, backed by a Backend:
+//
+//	server := forcetest.NewServer(nil)
+//	defer server.Close()
+//
+//	forceApi, err := force.CreateWithAccessToken(version, clientId, server.AccessToken(), server.URL)
+package forcetest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	tokenUri    = "/services/oauth2/token"
+	identityUri = "/id/"
+
+	// OrgId and UserId identify the org and user of the sessions the server issues.
+	OrgId  = "00D000000000001AAA"
+	UserId = "005000000000001AAA"
+
+	defaultBatchSize = 2000
+	defaultApiLimit  = 15000
+)
+
+// dataUri matches the path of a data resource, capturing the api version and the resource.
+var dataUri = regexp.MustCompile(`^/services/data/(v\d+\.\d+)(/.*)?$`)
+
+// Server is a fake force.com REST API server. It's safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	// Backend holds the records served.
+	Backend Backend
+	// Number of records in each page of query results. Defaults to 2000.
+	BatchSize int
+	// Daily API request limit, reported in the Sforce-Limit-Info header and by the limits
+	// resource. Defaults to 15000.
+	ApiLimit int
+
+	mu          sync.Mutex
+	accessToken string
+	tokens      int
+	requests    int
+	locators    map[string]*queryCursor
+	lastCursor  int
+}
+
+type queryCursor struct {
+	records   []Record
+	totalSize int
+}
+
+// NewServer starts a Server serving the records of backend, or of a new MemoryBackend if backend
+// is nil. The caller must Close it.
+func NewServer(backend Backend) *Server {
+	if backend == nil {
+		backend = NewMemoryBackend()
+	}
+
+	server := &Server{
+		Backend:  backend,
+		locators: make(map[string]*queryCursor),
+	}
+	server.accessToken = server.newAccessToken()
+	server.Server = httptest.NewServer(server)
+
+	return server
+}
+
+// AccessToken returns the access token of the current session.
+func (server *Server) AccessToken() string {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	return server.accessToken
+}
+
+// ExpireSession expires the current session, so that requests are rejected with
+// INVALID_SESSION_ID until a new access token is requested.
+func (server *Server) ExpireSession() {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.accessToken = ""
+}
+
+// Requests returns the number of data requests served.
+func (server *Server) Requests() int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	return server.requests
+}
+
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == tokenUri {
+		server.serveToken(w, r)
+		return
+	}
+
+	if !server.authorized(r) {
+		writeError(w, Errorf(http.StatusUnauthorized, InvalidSessionErrorCode, "Session expired or invalid"))
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, identityUri) {
+		server.serveIdentity(w, r)
+		return
+	}
+
+	match := dataUri.FindStringSubmatch(r.URL.Path)
+	if match == nil {
+		writeError(w, NotFound())
+		return
+	}
+
+	server.mu.Lock()
+	server.requests++
+	w.Header().Set("Sforce-Limit-Info", fmt.Sprintf("api-usage=%v/%v", server.requests, server.apiLimit()))
+	server.mu.Unlock()
+
+	version, path := match[1], strings.Split(strings.Trim(match[2], "/"), "/")
+	switch path[0] {
+	case "":
+		server.serveResources(w, r, version)
+	case "sobjects":
+		server.serveSObjects(w, r, version, path[1:])
+	case "query", "queryAll":
+		server.serveQuery(w, r, version, path)
+	case "limits":
+		server.serveLimits(w, r)
+	default:
+		writeError(w, NotFound())
+	}
+}
+
+func (server *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		writeError(w, methodNotAllowed(r, "POST"))
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+
+	server.mu.Lock()
+	server.accessToken = server.newAccessToken()
+	token := map[string]string{
+		"access_token": server.accessToken,
+		"instance_url": server.URL,
+		"id":           server.URL + identityUri + OrgId + "/" + UserId,
+		"token_type":   "Bearer",
+		"issued_at":    strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10),
+		"signature":    "forcetest",
+	}
+	server.mu.Unlock()
+
+	// Only the web server flow issues refresh tokens, refreshing keeps the refresh token.
+	if r.PostForm.Get("grant_type") == "authorization_code" {
+		token["refresh_token"] = "forcetest-refresh-token"
+	}
+
+	writeJSON(w, http.StatusOK, token)
+}
+
+func (server *Server) serveIdentity(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":              server.URL + identityUri + OrgId + "/" + UserId,
+		"organization_id": OrgId,
+		"user_id":         UserId,
+		"username":        "user@forcetest.example.com",
+		"display_name":    "forcetest",
+		"active":          true,
+	})
+}
+
+func (server *Server) serveResources(w http.ResponseWriter, r *http.Request, version string) {
+	base := "/services/data/" + version
+	resources := map[string]string{}
+	for _, resource := range []string{"sobjects", "query", "queryAll", "limits"} {
+		resources[resource] = base + "/" + resource
+	}
+
+	writeJSON(w, http.StatusOK, resources)
+}
+
+func (server *Server) serveSObjects(w http.ResponseWriter, r *http.Request, version string, path []string) {
+	base := "/services/data/" + version + "/sobjects"
+
+	switch {
+	// sobjects
+	case len(path) == 0:
+		type sObject struct {
+			Name       string            `json:"name"`
+			Label      string            `json:"label"`
+			KeyPrefix  string            `json:"keyPrefix"`
+			Custom     bool              `json:"custom"`
+			Queryable  bool              `json:"queryable"`
+			Createable bool              `json:"createable"`
+			Updateable bool              `json:"updateable"`
+			Deletable  bool              `json:"deletable"`
+			URLs       map[string]string `json:"urls"`
+		}
+		list := []*sObject{}
+		for _, info := range server.Backend.SObjects() {
+			list = append(list, &sObject{
+				Name:       info.Name,
+				Label:      info.Label,
+				KeyPrefix:  info.KeyPrefix,
+				Custom:     info.Custom,
+				Queryable:  true,
+				Createable: true,
+				Updateable: true,
+				Deletable:  true,
+				URLs: map[string]string{
+					"sobject":     base + "/" + info.Name,
+					"describe":    base + "/" + info.Name + "/describe",
+					"rowTemplate": base + "/" + info.Name + "/{ID}",
+				},
+			})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"encoding": "UTF-8", "maxBatchSize": 200, "sobjects": list})
+
+	// sobjects/Account
+	case len(path) == 1:
+		if r.Method != "POST" {
+			writeError(w, methodNotAllowed(r, "POST"))
+			return
+		}
+		fields, err := readRecord(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		id, err := server.Backend.Insert(path[0], fields)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, map[string]interface{}{"id": id, "success": true, "errors": []string{}})
+
+	// sobjects/Account/describe
+	case len(path) == 2 && path[1] == "describe":
+		description, err := server.Backend.Describe(path[0])
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, description)
+
+	// sobjects/Account/001000000000001AAA
+	case len(path) == 2:
+		server.serveRecord(w, r, version, path[0], path[1])
+
+	// sobjects/Account/External_Id__c/value
+	case len(path) == 3:
+		server.serveExternalIdRecord(w, r, version, path[0], path[1], path[2])
+
+	default:
+		writeError(w, NotFound())
+	}
+}
+
+func (server *Server) serveRecord(w http.ResponseWriter, r *http.Request, version, name, id string) {
+	switch r.Method {
+	case "GET":
+		record, err := server.Backend.Get(name, id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, selectFields(version, record, r.URL.Query().Get("fields")))
+
+	case "PATCH":
+		fields, err := readRecord(r)
+		if err == nil {
+			err = server.Backend.Update(name, id, fields)
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case "DELETE":
+		if err := server.Backend.Delete(name, id); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, methodNotAllowed(r, "GET", "PATCH", "DELETE"))
+	}
+}
+
+func (server *Server) serveExternalIdRecord(w http.ResponseWriter, r *http.Request, version, name, field, value string) {
+	switch r.Method {
+	case "GET":
+		record, err := server.Backend.GetByExternalId(name, field, value)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, selectFields(version, record, r.URL.Query().Get("fields")))
+
+	case "PATCH":
+		fields, err := readRecord(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		id, created, err := server.Backend.Upsert(name, field, value, fields)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+		writeJSON(w, status, map[string]interface{}{"id": id, "success": true, "errors": []string{}, "created": created})
+
+	case "DELETE":
+		record, err := server.Backend.GetByExternalId(name, field, value)
+		if err == nil {
+			id, _ := record["Id"].(string)
+			err = server.Backend.Delete(name, id)
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, methodNotAllowed(r, "GET", "PATCH", "DELETE"))
+	}
+}
+
+func (server *Server) serveQuery(w http.ResponseWriter, r *http.Request, version string, path []string) {
+	if r.Method != "GET" {
+		writeError(w, methodNotAllowed(r, "GET"))
+		return
+	}
+
+	var cursor *queryCursor
+	switch len(path) {
+	// query?q=SELECT...
+	case 1:
+		records, err := server.Backend.Query(r.URL.Query().Get("q"), path[0] == "queryAll")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		cursor = &queryCursor{records: records, totalSize: len(records)}
+
+	// query/01g000000000001-2000
+	case 2:
+		server.mu.Lock()
+		cursor = server.locators[path[1]]
+		delete(server.locators, path[1])
+		server.mu.Unlock()
+		if cursor == nil {
+			writeError(w, Errorf(http.StatusBadRequest, "INVALID_QUERY_LOCATOR", "invalid query locator"))
+			return
+		}
+
+	default:
+		writeError(w, NotFound())
+		return
+	}
+
+	page := cursor.records
+	response := map[string]interface{}{"totalSize": cursor.totalSize, "done": true}
+	if batchSize := server.batchSize(); len(page) > batchSize {
+		page = page[:batchSize]
+		next := &queryCursor{records: cursor.records[batchSize:], totalSize: cursor.totalSize}
+
+		server.mu.Lock()
+		server.lastCursor++
+		locator := fmt.Sprintf("01g%012d-%d", server.lastCursor, cursor.totalSize-len(next.records))
+		server.locators[locator] = next
+		server.mu.Unlock()
+
+		response["done"] = false
+		response["nextRecordsUrl"] = "/services/data/" + version + "/" + path[0] + "/" + locator
+	}
+	for _, record := range page {
+		setUrl(version, record)
+	}
+	response["records"] = page
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (server *Server) serveLimits(w http.ResponseWriter, r *http.Request) {
+	server.mu.Lock()
+	limit, remaining := server.apiLimit(), server.apiLimit()-server.requests
+	server.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"DailyApiRequests": map[string]int{"Max": limit, "Remaining": remaining},
+	})
+}
+
+// authorized reports whether the request carries the access token of the current session.
+func (server *Server) authorized(r *http.Request) bool {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	return len(server.accessToken) > 0 && r.Header.Get("Authorization") == "Bearer "+server.accessToken
+}
+
+func (server *Server) newAccessToken() string {
+	server.tokens++
+	return fmt.Sprintf("%v!forcetest.%v", OrgId[:15], server.tokens)
+}
+
+func (server *Server) batchSize() int {
+	if server.BatchSize <= 0 {
+		return defaultBatchSize
+	}
+
+	return server.BatchSize
+}
+
+func (server *Server) apiLimit() int {
+	if server.ApiLimit <= 0 {
+		return defaultApiLimit
+	}
+
+	return server.ApiLimit
+}
+
+// selectFields returns record limited to the comma separated fields, if any.
+func selectFields(version string, record Record, fields string) Record {
+	setUrl(version, record)
+	if len(fields) == 0 {
+		return record
+	}
+
+	selected := Record{"attributes": record["attributes"]}
+	for _, field := range strings.Split(fields, ",") {
+		selected[field], _ = record.Get(field)
+	}
+
+	return selected
+}
+
+// setUrl adds the url of the record to its attributes.
+func setUrl(version string, record Record) {
+	attributes, ok := record["attributes"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	id, _ := record.Get("Id")
+	if id, ok := id.(string); ok && len(id) > 0 {
+		attributes["url"] = fmt.Sprintf("/services/data/%v/sobjects/%v/%v", version, attributes["type"], id)
+	}
+}
+
+func readRecord(r *http.Request) (Record, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	record := Record{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &record); err != nil {
+			return nil, Errorf(http.StatusBadRequest, "JSON_PARSER_ERROR", "Unable to parse the request body: %v", err)
+		}
+	}
+
+	return record, nil
+}
+
+func methodNotAllowed(r *http.Request, allowed ...string) *Error {
+	return Errorf(http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "HTTP Method '%v' not allowed. Allowed are %v", r.Method, strings.Join(allowed, ","))
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	apiError := &Error{}
+	if !errors.As(err, &apiError) {
+		apiError = Errorf(http.StatusInternalServerError, UnknownErrorCode, "%v", err)
+	}
+
+	fields := apiError.Fields
+	if fields == nil {
+		fields = []string{}
+	}
+	writeJSON(w, apiError.StatusCode, []map[string]interface{}{{
+		"message":   apiError.Message,
+		"errorCode": apiError.ErrorCode,
+		"fields":    fields,
+	}})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		body = []byte(fmt.Sprintf(`[{"message":%q,"errorCode":%q}]`, err.Error(), UnknownErrorCode))
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
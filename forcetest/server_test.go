@@ -0,0 +1,189 @@
+package forcetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const testData = "/services/data/v58.0"
+
+// do sends a request with the current access token and decodes the json response into out.
+func do(t *testing.T, server *Server, method, path, body string, out interface{}) int {
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Unable to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+server.AccessToken())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Unable to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("Unable to decode %v %v response: %v", method, path, err)
+		}
+	}
+
+	return resp.StatusCode
+}
+
+func TestServerToken(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+
+	expired := server.AccessToken()
+	resp, err := http.PostForm(server.URL+tokenUri, url.Values{"grant_type": {"password"}})
+	if err != nil {
+		t.Fatalf("Unable to request a token: %v", err)
+	}
+	defer resp.Body.Close()
+
+	token := map[string]string{}
+	json.NewDecoder(resp.Body).Decode(&token)
+	if token["access_token"] != server.AccessToken() || token["access_token"] == expired || token["instance_url"] != server.URL {
+		t.Fatalf("Unexpected token: %v", token)
+	}
+
+	server.ExpireSession()
+	var apiErrors []*Error
+	if status := do(t, server, "GET", testData, "", &apiErrors); status != http.StatusUnauthorized {
+		t.Fatalf("Expected the expired session to be rejected, got %v", status)
+	}
+}
+
+func TestServerRecords(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+
+	resources := map[string]string{}
+	do(t, server, "GET", testData, "", &resources)
+	if resources["query"] != testData+"/query" {
+		t.Fatalf("Unexpected resources: %v", resources)
+	}
+
+	inserted := map[string]interface{}{}
+	if status := do(t, server, "POST", testData+"/sobjects/Account", `{"Name":"Acme"}`, &inserted); status != http.StatusCreated {
+		t.Fatalf("Unexpected insert status %v: %v", status, inserted)
+	}
+	id := inserted["id"].(string)
+	if !strings.HasPrefix(id, "001") || len(id) != 18 {
+		t.Fatalf("Unexpected id: %v", id)
+	}
+
+	if status := do(t, server, "PATCH", testData+"/sobjects/Account/"+id, `{"Phone":"555"}`, nil); status != http.StatusNoContent {
+		t.Fatalf("Unexpected update status: %v", status)
+	}
+
+	record := Record{}
+	do(t, server, "GET", testData+"/sobjects/Account/"+id[:15]+"?fields=Name,Phone", "", &record)
+	attributes, _ := record["attributes"].(map[string]interface{})
+	if record["Name"] != "Acme" || record["Phone"] != "555" || len(record) != 3 ||
+		attributes["url"] != testData+"/sobjects/Account/"+id {
+		t.Fatalf("Unexpected record: %v", record)
+	}
+
+	if status := do(t, server, "DELETE", testData+"/sobjects/Account/"+id, "", nil); status != http.StatusNoContent {
+		t.Fatalf("Unexpected delete status: %v", status)
+	}
+	var apiErrors []*Error
+	if status := do(t, server, "GET", testData+"/sobjects/Account/"+id, "", &apiErrors); status != http.StatusNotFound ||
+		apiErrors[0].ErrorCode != EntityIsDeletedErrorCode {
+		t.Fatalf("Expected the deleted record not to be found, got %v %+v", status, apiErrors[0])
+	}
+
+	if status := do(t, server, "GET", testData+"/sobjects/Widget__c/describe", "", &apiErrors); status != http.StatusNotFound {
+		t.Fatalf("Expected an unknown sobject not to be found, got %v", status)
+	}
+}
+
+func TestServerUpsert(t *testing.T) {
+	backend := NewMemoryBackend()
+	backend.AddSObject("Widget__c", "a01", "Name", "External_Id__c")
+	server := NewServer(backend)
+	defer server.Close()
+
+	path := testData + "/sobjects/Widget__c/External_Id__c/W-1"
+	response := map[string]interface{}{}
+	if status := do(t, server, "PATCH", path, `{"Name":"First"}`, &response); status != http.StatusCreated || response["created"] != true {
+		t.Fatalf("Expected the record to be created, got %v %v", status, response)
+	}
+	if status := do(t, server, "PATCH", path, `{"Name":"Second"}`, &response); status != http.StatusOK || response["created"] != false {
+		t.Fatalf("Expected the record to be updated, got %v %v", status, response)
+	}
+
+	record := Record{}
+	do(t, server, "GET", path, "", &record)
+	if record["Name"] != "Second" || record["External_Id__c"] != "W-1" {
+		t.Fatalf("Unexpected record: %v", record)
+	}
+
+	if status := do(t, server, "DELETE", path, "", nil); status != http.StatusNoContent {
+		t.Fatalf("Unexpected delete status: %v", status)
+	}
+	if status := do(t, server, "GET", path, "", &[]*Error{}); status != http.StatusNotFound {
+		t.Fatalf("Expected the deleted record not to be found, got %v", status)
+	}
+}
+
+func TestServerQuery(t *testing.T) {
+	backend := NewMemoryBackend()
+	for i := 0; i < 5; i++ {
+		backend.Seed("Account", Record{"Name": fmt.Sprintf("Account %v", i), "Type": "Customer"})
+	}
+	deleted := backend.Seed("Account", Record{"Name": "Deleted", "Type": "Customer", "IsDeleted": true})
+	server := NewServer(backend)
+	defer server.Close()
+	server.BatchSize = 2
+
+	type queryResponse struct {
+		TotalSize      int      `json:"totalSize"`
+		Done           bool     `json:"done"`
+		NextRecordsUrl string   `json:"nextRecordsUrl"`
+		Records        []Record `json:"records"`
+	}
+
+	q := url.Values{"q": {"SELECT Name FROM Account WHERE Type = 'Customer'"}}.Encode()
+	var names []string
+	path := testData + "/query?" + q
+	for path != "" {
+		page := &queryResponse{}
+		if status := do(t, server, "GET", path, "", page); status != http.StatusOK {
+			t.Fatalf("Unexpected query status: %v", status)
+		}
+		if page.TotalSize != 5 || page.Done != (page.NextRecordsUrl == "") || len(page.Records) > 2 {
+			t.Fatalf("Unexpected page: %+v", page)
+		}
+		for _, record := range page.Records {
+			names = append(names, record["Name"].(string))
+		}
+		path = page.NextRecordsUrl
+	}
+	if fmt.Sprint(names) != "[Account 0 Account 1 Account 2 Account 3 Account 4]" {
+		t.Fatalf("Unexpected records: %v", names)
+	}
+
+	page := &queryResponse{}
+	q = url.Values{"q": {"SELECT Id, IsDeleted FROM Account WHERE Name = 'Deleted'"}}.Encode()
+	do(t, server, "GET", testData+"/queryAll?"+q, "", page)
+	if len(page.Records) != 1 || page.Records[0]["Id"] != deleted[0] || page.Records[0]["IsDeleted"] != true {
+		t.Fatalf("Expected queryAll to find the deleted record, got %+v", page)
+	}
+	do(t, server, "GET", testData+"/query?"+q, "", page)
+	if page.TotalSize != 0 {
+		t.Fatalf("Expected query not to find the deleted record, got %+v", page)
+	}
+
+	var apiErrors []*Error
+	q = url.Values{"q": {"SELECT Name FROM Account GROUP BY Name"}}.Encode()
+	if status := do(t, server, "GET", testData+"/query?"+q, "", &apiErrors); status != http.StatusBadRequest ||
+		apiErrors[0].ErrorCode != MalformedQueryErrorCode {
+		t.Fatalf("Expected an unsupported query to be rejected, got %v", status)
+	}
+}
@@ -34,8 +34,9 @@ type BaseSObject struct {
 }
 
 type SObjectAttributes struct {
-	Type string `force:"type,omitempty"`
-	Url  string `force:"url,omitempty"`
+	Type        string `force:"type,omitempty"`
+	Url         string `force:"url,omitempty"`
+	ReferenceId string `force:"referenceId,omitempty"` // Identifies a record in sObject Tree requests.
 }
 
 // Implementing this here because most objects don't have an external id and as such this is not needed.
@@ -55,6 +56,15 @@ type BaseQuery struct {
 	NextRecordsUri string  `json:"NextRecordsUrl" force:"nextRecordsUrl"`
 }
 
+// QueryResponse is a query response holding records of type T, which saves writing a response
+// struct for every object.
+// list := &sobjects.QueryResponse[*MyCustomSObject]{}
+// err := forceApi.Query("SELECT Id FROM MyCustomSObject__c", list)
+type QueryResponse[T any] struct {
+	BaseQuery
+	Records []T `json:"Records" force:"records"`
+}
+
 // ConvertFieldNames takes in any interface that inplements SObject and a comma separated list of json field names.
 // It converts the json field names to the force struct tag stated equivalent.
 func ConvertFieldNames(obj interface{}, jsonFields string) string {
@@ -17,7 +17,4 @@ func (t *Lead) ApiName() string {
 	return "Lead"
 }
 
-type LeadQueryResponse struct {
-	BaseQuery
-	Records []Lead `json:"Records" force:"records"`
-}
+type LeadQueryResponse = QueryResponse[Lead]
@@ -21,7 +21,4 @@ func (t *Opportunity) ApiName() string {
 	return "Opportunity"
 }
 
-type OpportunityQueryResponse struct {
-	BaseQuery
-	Records []Opportunity `json:"Records" force:"records"`
-}
+type OpportunityQueryResponse = QueryResponse[Opportunity]
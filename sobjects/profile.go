@@ -16,7 +16,4 @@ func (t *Profile) ApiName() string {
 	return "Profile"
 }
 
-type ProfileQueryResponse struct {
-	BaseQuery
-	Records []Profile `json:"Records" force:"records"`
-}
+type ProfileQueryResponse = QueryResponse[Profile]
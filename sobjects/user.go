@@ -21,7 +21,4 @@ func (t *User) ApiName() string {
 	return "User"
 }
 
-type UserQueryResponse struct {
-	BaseQuery
-	Records []User `json:"Records" force:"records"`
-}
+type UserQueryResponse = QueryResponse[User]
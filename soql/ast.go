@@ -0,0 +1,246 @@
+package soql
+
+// Node is a node of the syntax tree of a query. The String method of every node renders it
+// canonically.
+type Node interface {
+	String() string
+}
+
+// SelectItem is an item of a SELECT clause: a *Field, *FunctionCall, *Subquery or *TypeOf.
+type SelectItem interface {
+	Node
+	selectItem()
+}
+
+// Expr is an operand of a condition or function: a *Field, *FunctionCall, *Subquery, *List or a
+// literal.
+type Expr interface {
+	Node
+	expr()
+}
+
+// Condition is a WHERE or HAVING condition: a *Comparison, *Logical or *Not.
+type Condition interface {
+	Node
+	condition()
+}
+
+// Query is a SOQL query, or a subquery of one.
+type Query struct {
+	Select  []SelectItem
+	From    *From
+	Where   Condition
+	With    *With
+	GroupBy *GroupBy
+	Having  Condition
+	OrderBy []*OrderItem
+	Limit   *int
+	Offset  *int
+	// VIEW, REFERENCE or UPDATE, for a FOR clause.
+	For string
+	// TRACKING or VIEWSTAT, for an UPDATE clause.
+	Update string
+}
+
+// From is the FROM clause of a query.
+type From struct {
+	SObject string
+	Alias   string
+	// The filter scope of a USING SCOPE clause, such as Mine.
+	Scope string
+}
+
+// With is the WITH clause of a query: either a filter such as SECURITY_ENFORCED or USER_MODE, or
+// data category filters.
+type With struct {
+	Name           string
+	DataCategories []*DataCategoryFilter
+}
+
+// DataCategoryFilter filters a data category group with a selector, AT, ABOVE, BELOW or
+// ABOVE_OR_BELOW.
+type DataCategoryFilter struct {
+	Group      string
+	Selector   string
+	Categories []string
+}
+
+// GroupBy is the GROUP BY clause of a query. Kind is ROLLUP or CUBE for grouping by those
+// functions, and empty otherwise.
+type GroupBy struct {
+	Kind  string
+	Exprs []Expr
+}
+
+// OrderItem is an item of an ORDER BY clause. Nulls is FIRST, LAST or empty for the default.
+type OrderItem struct {
+	Expr       Expr
+	Descending bool
+	Nulls      string
+}
+
+// Field is a field, or a field of a parent such as Account.Owner.Name. An alias may be given to
+// fields of aggregate queries.
+type Field struct {
+	Name  string
+	Alias string
+}
+
+// FunctionCall is a call of an aggregate, date or other function, such as COUNT(Id) or
+// toLabel(Status).
+type FunctionCall struct {
+	Name  string
+	Args  []Expr
+	Alias string
+}
+
+// Subquery is a parent-to-child relationship query in a SELECT clause, or a semi-join or
+// anti-join query in a condition.
+type Subquery struct {
+	Query *Query
+}
+
+// TypeOf selects fields of a polymorphic relationship depending on the type of the referenced
+// record.
+type TypeOf struct {
+	Field string
+	Whens []*TypeOfWhen
+	Else  []*Field
+}
+
+// TypeOfWhen is a WHEN clause of a TYPEOF.
+type TypeOfWhen struct {
+	SObject string
+	Fields  []*Field
+}
+
+// Comparison compares a field or function with a value. Operator is =, !=, <, <=, >, >=, LIKE, IN,
+// NOT IN, INCLUDES or EXCLUDES. The values of IN, NOT IN, INCLUDES and EXCLUDES are a *List, or a
+// *Subquery for IN and NOT IN.
+type Comparison struct {
+	Left     Expr
+	Operator string
+	Right    Expr
+}
+
+// Logical is conditions joined by AND or OR.
+type Logical struct {
+	Operator   string
+	Conditions []Condition
+}
+
+// Not negates a condition.
+type Not struct {
+	Condition Condition
+}
+
+// List is a parenthesized list of values.
+type List struct {
+	Values []Expr
+}
+
+// StringLiteral is a quoted string, holding its unescaped value. Escaped LIKE wildcards, \% and
+// \_, and escaped backslashes, \\, are kept escaped, so that 'a\%' and 'a\\%' stay apart.
+type StringLiteral struct {
+	Value string
+}
+
+// NumberLiteral is a number as written.
+type NumberLiteral struct {
+	Value string
+}
+
+type BooleanLiteral struct {
+	Value bool
+}
+
+type NullLiteral struct{}
+
+// DateLiteral is a date or datetime, such as 2024-01-31 or 2024-01-31T12:00:00Z.
+type DateLiteral struct {
+	Value string
+}
+
+// RelativeDateLiteral is a date relative to today, such as TODAY or LAST_N_DAYS:30. N is only set
+// for literals that take a number.
+type RelativeDateLiteral struct {
+	Name string
+	N    *int
+}
+
+func (*Field) selectItem()        {}
+func (*FunctionCall) selectItem() {}
+func (*Subquery) selectItem()     {}
+func (*TypeOf) selectItem()       {}
+
+func (*Field) expr()               {}
+func (*FunctionCall) expr()        {}
+func (*Subquery) expr()            {}
+func (*List) expr()                {}
+func (*StringLiteral) expr()       {}
+func (*NumberLiteral) expr()       {}
+func (*BooleanLiteral) expr()      {}
+func (*NullLiteral) expr()         {}
+func (*DateLiteral) expr()         {}
+func (*RelativeDateLiteral) expr() {}
+
+func (*Comparison) condition() {}
+func (*Logical) condition()    {}
+func (*Not) condition()        {}
+
+// Inspect traverses the syntax tree of node depth first, calling f for each node, including
+// subqueries. If f returns false, the children of the node aren't traversed.
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+
+	switch node := node.(type) {
+	case *Query:
+		for _, item := range node.Select {
+			Inspect(item, f)
+		}
+		if node.Where != nil {
+			Inspect(node.Where, f)
+		}
+		if node.GroupBy != nil {
+			for _, expr := range node.GroupBy.Exprs {
+				Inspect(expr, f)
+			}
+		}
+		if node.Having != nil {
+			Inspect(node.Having, f)
+		}
+		for _, item := range node.OrderBy {
+			Inspect(item.Expr, f)
+		}
+	case *FunctionCall:
+		for _, arg := range node.Args {
+			Inspect(arg, f)
+		}
+	case *Subquery:
+		Inspect(node.Query, f)
+	case *TypeOf:
+		for _, when := range node.Whens {
+			for _, field := range when.Fields {
+				Inspect(field, f)
+			}
+		}
+		for _, field := range node.Else {
+			Inspect(field, f)
+		}
+	case *Comparison:
+		Inspect(node.Left, f)
+		Inspect(node.Right, f)
+	case *Logical:
+		for _, condition := range node.Conditions {
+			Inspect(condition, f)
+		}
+	case *Not:
+		Inspect(node.Condition, f)
+	case *List:
+		for _, value := range node.Values {
+			Inspect(value, f)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package soql
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	query, err := Parse("SELECT Name, (SELECT Email FROM Contacts), COUNT(Id) FROM Account " +
+		"WHERE Id IN (SELECT AccountId FROM Opportunity WHERE Amount > 100) AND NOT Type = 'Partner' " +
+		"GROUP BY Name HAVING COUNT(Id) > 1 ORDER BY Industry")
+	if err != nil {
+		t.Fatalf("Unable to parse: %v", err)
+	}
+
+	var fields []string
+	Inspect(query, func(node Node) bool {
+		if field, ok := node.(*Field); ok {
+			fields = append(fields, field.Name)
+		}
+		return true
+	})
+	if fmt.Sprint(fields) != "[Name Email Id Id AccountId Amount Type Name Id Industry]" {
+		t.Fatalf("Unexpected fields: %v", fields)
+	}
+
+	// Subqueries are skipped when f returns false for them.
+	var sObjects []string
+	Inspect(query, func(node Node) bool {
+		if query, ok := node.(*Query); ok {
+			sObjects = append(sObjects, query.From.SObject)
+		}
+		_, subquery := node.(*Subquery)
+		return !subquery
+	})
+	if fmt.Sprint(sObjects) != "[Account]" {
+		t.Fatalf("Unexpected sobjects: %v", sObjects)
+	}
+}
@@ -0,0 +1,11 @@
+// Package soql parses SOQL queries into syntax trees, which can be analyzed, rewritten and rendered
+// back to SOQL, so that queries can be checked before they are sent to the api:
+//
+//	query, err := soql.Parse("select Id, (select Name from Contacts) from Account where Name like 'A%'")
+//	if err != nil {
+//		// err is a *soql.SyntaxError reporting the position of the error.
+//	}
+//	limit := 10
+//	query.Limit = &limit
+//	fmt.Println(query) // SELECT Id, (SELECT Name FROM Contacts) FROM Account WHERE Name LIKE 'A%' LIMIT 10
+package soql
@@ -0,0 +1,239 @@
+package soql
+
+import (
+	"strconv"
+	"strings"
+)
+
+// String renders the query canonically: keywords are upper case, clauses and items are separated
+// by single spaces and commas, <> is written as != and strings are quoted with escapes. Names of
+// sobjects, fields and functions are written as parsed.
+func (query *Query) String() string {
+	var s strings.Builder
+
+	s.WriteString("SELECT ")
+	for i, item := range query.Select {
+		if i > 0 {
+			s.WriteString(", ")
+		}
+		s.WriteString(item.String())
+	}
+
+	if query.From != nil {
+		s.WriteString(" FROM ")
+		s.WriteString(query.From.String())
+	}
+	if query.Where != nil {
+		s.WriteString(" WHERE ")
+		s.WriteString(query.Where.String())
+	}
+	if query.With != nil {
+		s.WriteString(" WITH ")
+		s.WriteString(query.With.String())
+	}
+	if query.GroupBy != nil {
+		s.WriteString(" GROUP BY ")
+		s.WriteString(query.GroupBy.String())
+	}
+	if query.Having != nil {
+		s.WriteString(" HAVING ")
+		s.WriteString(query.Having.String())
+	}
+	for i, item := range query.OrderBy {
+		if i == 0 {
+			s.WriteString(" ORDER BY ")
+		} else {
+			s.WriteString(", ")
+		}
+		s.WriteString(item.String())
+	}
+	if query.Limit != nil {
+		s.WriteString(" LIMIT ")
+		s.WriteString(strconv.Itoa(*query.Limit))
+	}
+	if query.Offset != nil {
+		s.WriteString(" OFFSET ")
+		s.WriteString(strconv.Itoa(*query.Offset))
+	}
+	if len(query.For) > 0 {
+		s.WriteString(" FOR ")
+		s.WriteString(query.For)
+	}
+	if len(query.Update) > 0 {
+		s.WriteString(" UPDATE ")
+		s.WriteString(query.Update)
+	}
+
+	return s.String()
+}
+
+func (from *From) String() string {
+	s := from.SObject
+	if len(from.Alias) > 0 {
+		s += " " + from.Alias
+	}
+	if len(from.Scope) > 0 {
+		s += " USING SCOPE " + from.Scope
+	}
+
+	return s
+}
+
+func (with *With) String() string {
+	if len(with.DataCategories) == 0 {
+		return with.Name
+	}
+
+	filters := make([]string, len(with.DataCategories))
+	for i, filter := range with.DataCategories {
+		filters[i] = filter.String()
+	}
+
+	return "DATA CATEGORY " + strings.Join(filters, " AND ")
+}
+
+func (filter *DataCategoryFilter) String() string {
+	categories := strings.Join(filter.Categories, ", ")
+	if len(filter.Categories) > 1 {
+		categories = "(" + categories + ")"
+	}
+
+	return filter.Group + " " + filter.Selector + " " + categories
+}
+
+func (groupBy *GroupBy) String() string {
+	exprs := joinExprs(groupBy.Exprs)
+	if len(groupBy.Kind) > 0 {
+		return groupBy.Kind + "(" + exprs + ")"
+	}
+
+	return exprs
+}
+
+func (item *OrderItem) String() string {
+	s := item.Expr.String()
+	if item.Descending {
+		s += " DESC"
+	}
+	if len(item.Nulls) > 0 {
+		s += " NULLS " + item.Nulls
+	}
+
+	return s
+}
+
+func (field *Field) String() string {
+	return withAlias(field.Name, field.Alias)
+}
+
+func (call *FunctionCall) String() string {
+	return withAlias(call.Name+"("+joinExprs(call.Args)+")", call.Alias)
+}
+
+func (subquery *Subquery) String() string {
+	return "(" + subquery.Query.String() + ")"
+}
+
+func (typeOf *TypeOf) String() string {
+	var s strings.Builder
+	s.WriteString("TYPEOF ")
+	s.WriteString(typeOf.Field)
+	for _, when := range typeOf.Whens {
+		s.WriteString(" WHEN ")
+		s.WriteString(when.SObject)
+		s.WriteString(" THEN ")
+		s.WriteString(joinFields(when.Fields))
+	}
+	if len(typeOf.Else) > 0 {
+		s.WriteString(" ELSE ")
+		s.WriteString(joinFields(typeOf.Else))
+	}
+	s.WriteString(" END")
+
+	return s.String()
+}
+
+func (comparison *Comparison) String() string {
+	return comparison.Left.String() + " " + comparison.Operator + " " + comparison.Right.String()
+}
+
+// String renders the conditions joined by the operator, with conditions that are themselves
+// joined in parentheses.
+func (logical *Logical) String() string {
+	conditions := make([]string, len(logical.Conditions))
+	for i, condition := range logical.Conditions {
+		conditions[i] = parenthesize(condition)
+	}
+
+	return strings.Join(conditions, " "+logical.Operator+" ")
+}
+
+func (not *Not) String() string {
+	return "NOT " + parenthesize(not.Condition)
+}
+
+func (list *List) String() string {
+	return "(" + joinExprs(list.Values) + ")"
+}
+
+func (literal *StringLiteral) String() string {
+	return quote(literal.Value)
+}
+
+func (literal *NumberLiteral) String() string {
+	return literal.Value
+}
+
+func (literal *BooleanLiteral) String() string {
+	return strconv.FormatBool(literal.Value)
+}
+
+func (*NullLiteral) String() string {
+	return "null"
+}
+
+func (literal *DateLiteral) String() string {
+	return literal.Value
+}
+
+func (literal *RelativeDateLiteral) String() string {
+	if literal.N != nil {
+		return literal.Name + ":" + strconv.Itoa(*literal.N)
+	}
+
+	return literal.Name
+}
+
+func parenthesize(condition Condition) string {
+	if _, ok := condition.(*Logical); ok {
+		return "(" + condition.String() + ")"
+	}
+
+	return condition.String()
+}
+
+func withAlias(s, alias string) string {
+	if len(alias) > 0 {
+		return s + " " + alias
+	}
+
+	return s
+}
+
+func joinExprs(exprs []Expr) string {
+	s := make([]string, len(exprs))
+	for i, expr := range exprs {
+		s[i] = expr.String()
+	}
+
+	return strings.Join(s, ", ")
+}
+
+func joinFields(fields []*Field) string {
+	s := make([]string, len(fields))
+	for i, field := range fields {
+		s[i] = field.String()
+	}
+
+	return strings.Join(s, ", ")
+}
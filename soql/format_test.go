@@ -0,0 +1,56 @@
+package soql
+
+import (
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	limit := 50
+	n := 7
+	query := &Query{
+		Select: []SelectItem{
+			&Field{Name: "Id"},
+			&FunctionCall{Name: "toLabel", Args: []Expr{&Field{Name: "Status"}}},
+			&Subquery{Query: &Query{Select: []SelectItem{&Field{Name: "Subject"}}, From: &From{SObject: "Tasks"}}},
+		},
+		From: &From{SObject: "Case"},
+		Where: &Logical{Operator: "AND", Conditions: []Condition{
+			&Comparison{Left: &Field{Name: "IsClosed"}, Operator: "=", Right: &BooleanLiteral{Value: false}},
+			&Not{Condition: &Logical{Operator: "OR", Conditions: []Condition{
+				&Comparison{Left: &Field{Name: "Origin"}, Operator: "IN", Right: &List{Values: []Expr{&StringLiteral{Value: "Web"}, &StringLiteral{Value: "Phone"}}}},
+				&Comparison{Left: &Field{Name: "Priority"}, Operator: "=", Right: &NullLiteral{}},
+			}}},
+			&Comparison{Left: &Field{Name: "CreatedDate"}, Operator: "=", Right: &RelativeDateLiteral{Name: "LAST_N_DAYS", N: &n}},
+		}},
+		OrderBy: []*OrderItem{{Expr: &Field{Name: "CreatedDate"}, Descending: true}},
+		Limit:   &limit,
+	}
+
+	expected := "SELECT Id, toLabel(Status), (SELECT Subject FROM Tasks) FROM Case " +
+		"WHERE IsClosed = false AND NOT (Origin IN ('Web', 'Phone') OR Priority = null) AND CreatedDate = LAST_N_DAYS:7 " +
+		"ORDER BY CreatedDate DESC LIMIT 50"
+	if query.String() != expected {
+		t.Fatalf("Unexpected rendering:\n%v\nexpected:\n%v", query, expected)
+	}
+}
+
+func TestStringRewrite(t *testing.T) {
+	query, err := Parse("SELECT Id FROM Account WHERE Name = 'Acme'")
+	if err != nil {
+		t.Fatalf("Unable to parse: %v", err)
+	}
+
+	// Restrict the query to active accounts, and return at most one.
+	query.Where = &Logical{Operator: "AND", Conditions: []Condition{
+		query.Where,
+		&Comparison{Left: &Field{Name: "Active__c"}, Operator: "=", Right: &BooleanLiteral{Value: true}},
+	}}
+	limit := 1
+	query.Limit = &limit
+	query.With = &With{Name: "SECURITY_ENFORCED"}
+
+	expected := "SELECT Id FROM Account WHERE Name = 'Acme' AND Active__c = true WITH SECURITY_ENFORCED LIMIT 1"
+	if query.String() != expected {
+		t.Fatalf("Unexpected rendering:\n%v\nexpected:\n%v", query, expected)
+	}
+}
@@ -0,0 +1,260 @@
+package soql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Position is a position in a query. Lines and columns are counted in characters from 1.
+type Position struct {
+	Offset int // Byte offset, from 0.
+	Line   int
+	Column int
+}
+
+func (pos Position) String() string {
+	return fmt.Sprintf("line %d, column %d", pos.Line, pos.Column)
+}
+
+// SyntaxError is the error returned for a query that can't be parsed.
+type SyntaxError struct {
+	Pos     Position
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("Syntax error at %v: %v", e.Pos, e.Message)
+}
+
+type tokenKind int
+
+const (
+	eofToken tokenKind = iota
+	identToken
+	stringToken
+	numberToken
+	dateToken
+	symbolToken
+)
+
+type token struct {
+	kind tokenKind
+	// The text of the token as written, or the value of a string.
+	text string
+	pos  Position
+}
+
+// String describes the token in error messages.
+func (t token) String() string {
+	switch t.kind {
+	case eofToken:
+		return "end of query"
+	case stringToken:
+		return fmt.Sprintf("string %v", quote(t.text))
+	}
+
+	return fmt.Sprintf("'%v'", t.text)
+}
+
+// datePattern matches date and datetime literals, such as 2024-01-31 and 2024-01-31T12:00:00Z.
+var datePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2}))?$`)
+
+var numberPattern = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// lexer splits a query into tokens.
+type lexer struct {
+	input string
+	pos   Position
+}
+
+func lex(input string) ([]token, error) {
+	lexer := &lexer{input: input, pos: Position{Line: 1, Column: 1}}
+
+	var tokens []token
+	for {
+		token, err := lexer.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+		if token.kind == eofToken {
+			return tokens, nil
+		}
+	}
+}
+
+func (lexer *lexer) peek(n int) rune {
+	offset := lexer.pos.Offset
+	for ; n > 0 && offset < len(lexer.input); n-- {
+		_, size := utf8.DecodeRuneInString(lexer.input[offset:])
+		offset += size
+	}
+	if offset >= len(lexer.input) {
+		return -1
+	}
+	r, _ := utf8.DecodeRuneInString(lexer.input[offset:])
+
+	return r
+}
+
+func (lexer *lexer) advance() rune {
+	r, size := utf8.DecodeRuneInString(lexer.input[lexer.pos.Offset:])
+	lexer.pos.Offset += size
+	if r == '\n' {
+		lexer.pos.Line++
+		lexer.pos.Column = 1
+	} else {
+		lexer.pos.Column++
+	}
+
+	return r
+}
+
+func (lexer *lexer) next() (token, error) {
+	for unicode.IsSpace(lexer.peek(0)) {
+		lexer.advance()
+	}
+
+	start := lexer.pos
+	r := lexer.peek(0)
+	switch {
+	case r == -1:
+		return token{kind: eofToken, pos: start}, nil
+
+	case r == '\'':
+		return lexer.string()
+
+	case unicode.IsLetter(r) || r == '_':
+		for isIdentRune(lexer.peek(0)) {
+			lexer.advance()
+		}
+		return token{kind: identToken, text: lexer.input[start.Offset:lexer.pos.Offset], pos: start}, nil
+
+	case isDigit(r) || (r == '-' && isDigit(lexer.peek(1))):
+		lexer.advance()
+		for r := lexer.peek(0); isDigit(r) || strings.ContainsRune(".:-+TZ", r); r = lexer.peek(0) {
+			lexer.advance()
+		}
+		text := lexer.input[start.Offset:lexer.pos.Offset]
+		switch {
+		case datePattern.MatchString(text):
+			return token{kind: dateToken, text: text, pos: start}, nil
+		case numberPattern.MatchString(text):
+			return token{kind: numberToken, text: text, pos: start}, nil
+		}
+		return token{}, &SyntaxError{Pos: start, Message: fmt.Sprintf("invalid number '%v'", text)}
+
+	case strings.ContainsRune("!<>", r) && lexer.peek(1) == '=', r == '<' && lexer.peek(1) == '>':
+		lexer.advance()
+		lexer.advance()
+		return token{kind: symbolToken, text: lexer.input[start.Offset:lexer.pos.Offset], pos: start}, nil
+
+	case strings.ContainsRune("=<>(),:", r):
+		lexer.advance()
+		return token{kind: symbolToken, text: string(r), pos: start}, nil
+	}
+
+	return token{}, &SyntaxError{Pos: start, Message: fmt.Sprintf("unexpected character '%c'", r)}
+}
+
+// string lexes a quoted string, whose token text is its unescaped value.
+func (lexer *lexer) string() (token, error) {
+	start := lexer.pos
+	lexer.advance()
+
+	var value strings.Builder
+	for {
+		switch r := lexer.peek(0); r {
+		case -1:
+			return token{}, &SyntaxError{Pos: start, Message: "unterminated string"}
+
+		case '\'':
+			lexer.advance()
+			return token{kind: stringToken, text: value.String(), pos: start}, nil
+
+		case '\\':
+			escapePos := lexer.pos
+			lexer.advance()
+			escaped := lexer.advance()
+			switch escaped {
+			case 'n':
+				value.WriteRune('\n')
+			case 'r':
+				value.WriteRune('\r')
+			case 't':
+				value.WriteRune('\t')
+			case 'b':
+				value.WriteRune('\b')
+			case 'f':
+				value.WriteRune('\f')
+			case '\'', '"':
+				value.WriteRune(escaped)
+			case '\\', '%', '_':
+				// \% and \_ match literal wildcards in LIKE patterns, so they're kept escaped,
+				// as are backslashes, to tell 'a\%' from 'a\\%'.
+				value.WriteRune('\\')
+				value.WriteRune(escaped)
+			default:
+				return token{}, &SyntaxError{Pos: escapePos, Message: fmt.Sprintf("invalid escape sequence '\\%c'", escaped)}
+			}
+
+		default:
+			value.WriteRune(lexer.advance())
+		}
+	}
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || isDigit(r) || r == '_' || r == '.'
+}
+
+// quote returns s, a StringLiteral value, as a quoted SOQL string. Backslashes escaping a
+// backslash, % or _ are kept as they are, and other backslashes are escaped.
+func quote(s string) string {
+	var quoted strings.Builder
+	quoted.WriteByte('\'')
+	escaped := false
+	for _, r := range s {
+		if escaped {
+			// Escaped backslashes and LIKE wildcards are kept as they are.
+			escaped = false
+			if r == '\\' || r == '%' || r == '_' {
+				quoted.WriteRune(r)
+				continue
+			}
+			quoted.WriteRune('\\')
+		}
+		switch r {
+		case '\'':
+			quoted.WriteString(`\'`)
+		case '\\':
+			quoted.WriteRune('\\')
+			escaped = true
+		case '\n':
+			quoted.WriteString(`\n`)
+		case '\r':
+			quoted.WriteString(`\r`)
+		case '\t':
+			quoted.WriteString(`\t`)
+		case '\b':
+			quoted.WriteString(`\b`)
+		case '\f':
+			quoted.WriteString(`\f`)
+		default:
+			quoted.WriteRune(r)
+		}
+	}
+	if escaped {
+		quoted.WriteRune('\\')
+	}
+	quoted.WriteByte('\'')
+
+	return quoted.String()
+}
@@ -0,0 +1,59 @@
+package soql
+
+import (
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	tokens, err := lex("SELECT Id\n  FROM Account WHERE Name != 'It\\'s' AND CreatedDate >= 2024-01-31T12:00:00+01:00")
+	if err != nil {
+		t.Fatalf("Unable to lex: %v", err)
+	}
+
+	expected := []token{
+		{identToken, "SELECT", Position{0, 1, 1}},
+		{identToken, "Id", Position{7, 1, 8}},
+		{identToken, "FROM", Position{12, 2, 3}},
+		{identToken, "Account", Position{17, 2, 8}},
+		{identToken, "WHERE", Position{25, 2, 16}},
+		{identToken, "Name", Position{31, 2, 22}},
+		{symbolToken, "!=", Position{36, 2, 27}},
+		{stringToken, "It's", Position{39, 2, 30}},
+		{identToken, "AND", Position{47, 2, 38}},
+		{identToken, "CreatedDate", Position{51, 2, 42}},
+		{symbolToken, ">=", Position{63, 2, 54}},
+		{dateToken, "2024-01-31T12:00:00+01:00", Position{66, 2, 57}},
+		{eofToken, "", Position{91, 2, 82}},
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("Unexpected tokens: %+v", tokens)
+	}
+	for i := range tokens {
+		if tokens[i] != expected[i] {
+			t.Errorf("Unexpected token %v: %+v, expected %+v", i, tokens[i], expected[i])
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := map[string]string{
+		"Acme":          `'Acme'`,
+		"O'Brien":       `'O\'Brien'`,
+		"a\\\\b":        `'a\\b'`,
+		"line\nbreak\t": `'line\nbreak\t'`,
+		"100\\%":        `'100\%'`,
+		"under\\_score": `'under\_score'`,
+		"a\\\\%":        `'a\\%'`,
+		"a\\\\_b":       `'a\\_b'`,
+	}
+	for value, quoted := range tests {
+		if quote(value) != quoted {
+			t.Errorf("Unexpected quoting of %q: %v, expected %v", value, quote(value), quoted)
+		}
+
+		tokens, err := lex(quoted)
+		if err != nil || tokens[0].text != value {
+			t.Errorf("Expected %v to lex to %q, got %+v: %v", quoted, value, tokens, err)
+		}
+	}
+}
@@ -0,0 +1,719 @@
+package soql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Reserved keywords, which can't be used as names.
+var reserved = map[string]bool{
+	"AND": true, "ASC": true, "DESC": true, "EXCLUDES": true, "FIRST": true, "FROM": true, "GROUP": true,
+	"HAVING": true, "IN": true, "INCLUDES": true, "LAST": true, "LIKE": true, "LIMIT": true, "NOT": true,
+	"NULL": true, "NULLS": true, "OR": true, "SELECT": true, "WHERE": true, "WITH": true,
+}
+
+// Keywords that start a clause following FROM, which aren't aliases.
+var clauseKeywords = map[string]bool{
+	"USING": true, "WHERE": true, "WITH": true, "GROUP": true, "HAVING": true, "ORDER": true,
+	"LIMIT": true, "OFFSET": true, "FOR": true, "UPDATE": true,
+}
+
+var comparisonOperators = map[string]bool{"=": true, "!=": true, "<>": true, "<": true, "<=": true, ">": true, ">=": true}
+
+// Relative date literals that don't take a number.
+var relativeDates = map[string]bool{
+	"YESTERDAY": true, "TODAY": true, "TOMORROW": true,
+	"LAST_WEEK": true, "THIS_WEEK": true, "NEXT_WEEK": true,
+	"LAST_MONTH": true, "THIS_MONTH": true, "NEXT_MONTH": true,
+	"LAST_90_DAYS": true, "NEXT_90_DAYS": true,
+	"LAST_QUARTER": true, "THIS_QUARTER": true, "NEXT_QUARTER": true,
+	"LAST_YEAR": true, "THIS_YEAR": true, "NEXT_YEAR": true,
+	"LAST_FISCAL_QUARTER": true, "THIS_FISCAL_QUARTER": true, "NEXT_FISCAL_QUARTER": true,
+	"LAST_FISCAL_YEAR": true, "THIS_FISCAL_YEAR": true, "NEXT_FISCAL_YEAR": true,
+}
+
+// relativeDatesN matches relative date literals that take a number, such as LAST_N_DAYS:30.
+var relativeDatesN = regexp.MustCompile(`^(?:(?:LAST|NEXT)_N_(?:DAYS|WEEKS|MONTHS|QUARTERS|YEARS|FISCAL_QUARTERS|FISCAL_YEARS)|N_(?:DAYS|WEEKS|MONTHS|QUARTERS|YEARS|FISCAL_QUARTERS|FISCAL_YEARS)_AGO)$`)
+
+// Parse parses a SOQL query. Errors are returned as a *SyntaxError.
+func Parse(soql string) (*Query, error) {
+	tokens, err := lex(soql)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &parser{tokens: tokens}
+	query, err := parser.query()
+	if err != nil {
+		return nil, err
+	}
+	if token := parser.peek(); token.kind != eofToken {
+		return nil, parser.unexpected(token, "end of query")
+	}
+
+	return query, nil
+}
+
+type parser struct {
+	tokens []token
+}
+
+func (parser *parser) peek() token {
+	return parser.tokens[0]
+}
+
+func (parser *parser) peekAt(n int) token {
+	if n >= len(parser.tokens) {
+		return parser.tokens[len(parser.tokens)-1]
+	}
+
+	return parser.tokens[n]
+}
+
+func (parser *parser) next() token {
+	token := parser.tokens[0]
+	if token.kind != eofToken {
+		parser.tokens = parser.tokens[1:]
+	}
+
+	return token
+}
+
+// isKeyword reports whether token is the given keyword.
+func isKeyword(token token, keyword string) bool {
+	return token.kind == identToken && strings.EqualFold(token.text, keyword)
+}
+
+// keyword consumes the next token if it's one of the given keywords, and returns it in upper case.
+func (parser *parser) keyword(keywords ...string) (string, bool) {
+	for _, keyword := range keywords {
+		if isKeyword(parser.peek(), keyword) {
+			parser.next()
+			return keyword, true
+		}
+	}
+
+	return "", false
+}
+
+func (parser *parser) expectKeyword(keywords ...string) (string, error) {
+	keyword, ok := parser.keyword(keywords...)
+	if !ok {
+		return "", parser.unexpected(parser.peek(), strings.Join(keywords, " or "))
+	}
+
+	return keyword, nil
+}
+
+// symbol consumes the next token if it's the given symbol.
+func (parser *parser) symbol(symbol string) bool {
+	if token := parser.peek(); token.kind != symbolToken || token.text != symbol {
+		return false
+	}
+	parser.next()
+
+	return true
+}
+
+func (parser *parser) expectSymbol(symbol string) error {
+	if !parser.symbol(symbol) {
+		return parser.unexpected(parser.peek(), "'"+symbol+"'")
+	}
+
+	return nil
+}
+
+// name consumes a name, such as a field or sobject name, which isn't a reserved keyword.
+func (parser *parser) name(expected string) (string, error) {
+	token := parser.peek()
+	if token.kind != identToken || reserved[strings.ToUpper(token.text)] {
+		return "", parser.unexpected(token, expected)
+	}
+	parser.next()
+
+	return token.text, nil
+}
+
+// alias consumes an alias following a select item or sobject, if there is one.
+func (parser *parser) alias() string {
+	token := parser.peek()
+	if token.kind != identToken || strings.Contains(token.text, ".") ||
+		reserved[strings.ToUpper(token.text)] || clauseKeywords[strings.ToUpper(token.text)] {
+		return ""
+	}
+	parser.next()
+
+	return token.text
+}
+
+func (parser *parser) integer() (*int, error) {
+	token := parser.peek()
+	if token.kind != numberToken {
+		return nil, parser.unexpected(token, "an integer")
+	}
+	n, err := strconv.Atoi(token.text)
+	if err != nil || n < 0 {
+		return nil, parser.unexpected(token, "a non-negative integer")
+	}
+	parser.next()
+
+	return &n, nil
+}
+
+func (parser *parser) unexpected(token token, expected string) *SyntaxError {
+	return &SyntaxError{Pos: token.pos, Message: fmt.Sprintf("unexpected %v, expected %v", token, expected)}
+}
+
+func (parser *parser) query() (query *Query, err error) {
+	query = &Query{}
+
+	if _, err = parser.expectKeyword("SELECT"); err != nil {
+		return
+	}
+	if query.Select, err = parser.selectItems(); err != nil {
+		return
+	}
+
+	if _, err = parser.expectKeyword("FROM"); err != nil {
+		return
+	}
+	if query.From, err = parser.from(); err != nil {
+		return
+	}
+
+	if _, ok := parser.keyword("WHERE"); ok {
+		if query.Where, err = parser.condition(); err != nil {
+			return
+		}
+	}
+	if _, ok := parser.keyword("WITH"); ok {
+		if query.With, err = parser.with(); err != nil {
+			return
+		}
+	}
+	if _, ok := parser.keyword("GROUP"); ok {
+		if query.GroupBy, err = parser.groupBy(); err != nil {
+			return
+		}
+		if _, ok := parser.keyword("HAVING"); ok {
+			if query.Having, err = parser.condition(); err != nil {
+				return
+			}
+		}
+	}
+	if _, ok := parser.keyword("ORDER"); ok {
+		if query.OrderBy, err = parser.orderBy(); err != nil {
+			return
+		}
+	}
+	if _, ok := parser.keyword("LIMIT"); ok {
+		if query.Limit, err = parser.integer(); err != nil {
+			return
+		}
+	}
+	if _, ok := parser.keyword("OFFSET"); ok {
+		if query.Offset, err = parser.integer(); err != nil {
+			return
+		}
+	}
+	if _, ok := parser.keyword("FOR"); ok {
+		if query.For, err = parser.expectKeyword("VIEW", "REFERENCE", "UPDATE"); err != nil {
+			return
+		}
+	}
+	if _, ok := parser.keyword("UPDATE"); ok {
+		if query.Update, err = parser.expectKeyword("TRACKING", "VIEWSTAT"); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (parser *parser) selectItems() ([]SelectItem, error) {
+	var items []SelectItem
+	for {
+		item, err := parser.selectItem()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if !parser.symbol(",") {
+			return items, nil
+		}
+	}
+}
+
+func (parser *parser) selectItem() (SelectItem, error) {
+	switch token := parser.peek(); {
+	case token.kind == symbolToken && token.text == "(":
+		return parser.subquery()
+
+	case isKeyword(token, "TYPEOF"):
+		return parser.typeOf()
+
+	case token.kind == identToken && isFunction(parser.peekAt(1)):
+		call, err := parser.functionCall()
+		if err != nil {
+			return nil, err
+		}
+		call.Alias = parser.alias()
+		return call, nil
+	}
+
+	name, err := parser.name("a field")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Field{Name: name, Alias: parser.alias()}, nil
+}
+
+// isFunction reports whether the token following a name opens the arguments of a function.
+func isFunction(next token) bool {
+	return next.kind == symbolToken && next.text == "("
+}
+
+func (parser *parser) subquery() (*Subquery, error) {
+	if err := parser.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	query, err := parser.query()
+	if err != nil {
+		return nil, err
+	}
+	if err := parser.expectSymbol(")"); err != nil {
+		return nil, err
+	}
+
+	return &Subquery{Query: query}, nil
+}
+
+func (parser *parser) typeOf() (*TypeOf, error) {
+	parser.next()
+
+	field, err := parser.name("a polymorphic relationship")
+	if err != nil {
+		return nil, err
+	}
+	typeOf := &TypeOf{Field: field}
+
+	for {
+		if _, ok := parser.keyword("WHEN"); !ok {
+			break
+		}
+		sObject, err := parser.name("an sobject")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := parser.expectKeyword("THEN"); err != nil {
+			return nil, err
+		}
+		fields, err := parser.fields()
+		if err != nil {
+			return nil, err
+		}
+		typeOf.Whens = append(typeOf.Whens, &TypeOfWhen{SObject: sObject, Fields: fields})
+	}
+	if len(typeOf.Whens) == 0 {
+		return nil, parser.unexpected(parser.peek(), "WHEN")
+	}
+
+	if _, ok := parser.keyword("ELSE"); ok {
+		if typeOf.Else, err = parser.fields(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := parser.expectKeyword("END"); err != nil {
+		return nil, err
+	}
+
+	return typeOf, nil
+}
+
+// fields parses a comma separated list of fields.
+func (parser *parser) fields() ([]*Field, error) {
+	var fields []*Field
+	for {
+		name, err := parser.name("a field")
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, &Field{Name: name})
+		if !parser.symbol(",") {
+			return fields, nil
+		}
+	}
+}
+
+func (parser *parser) functionCall() (*FunctionCall, error) {
+	call := &FunctionCall{Name: parser.next().text}
+	parser.next()
+
+	if parser.symbol(")") {
+		return call, nil
+	}
+	for {
+		arg, err := parser.operand()
+		if err != nil {
+			return nil, err
+		}
+		call.Args = append(call.Args, arg)
+		if !parser.symbol(",") {
+			break
+		}
+	}
+	if err := parser.expectSymbol(")"); err != nil {
+		return nil, err
+	}
+
+	return call, nil
+}
+
+// operand parses a field, a function call or a literal.
+func (parser *parser) operand() (Expr, error) {
+	token := parser.peek()
+	if token.kind == identToken && isFunction(parser.peekAt(1)) {
+		return parser.functionCall()
+	}
+	if token.kind == identToken && !isLiteral(token) {
+		name, err := parser.name("a field")
+		if err != nil {
+			return nil, err
+		}
+		return &Field{Name: name}, nil
+	}
+
+	return parser.literal()
+}
+
+// field parses a field or a function call.
+func (parser *parser) field() (Expr, error) {
+	if token := parser.peek(); token.kind == identToken && isFunction(parser.peekAt(1)) {
+		return parser.functionCall()
+	}
+
+	name, err := parser.name("a field")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Field{Name: name}, nil
+}
+
+// isLiteral reports whether an identifier is a literal, such as true or TODAY.
+func isLiteral(token token) bool {
+	name := strings.ToUpper(token.text)
+	switch name {
+	case "TRUE", "FALSE", "NULL":
+		return true
+	}
+
+	return relativeDates[name] || relativeDatesN.MatchString(name)
+}
+
+func (parser *parser) literal() (Expr, error) {
+	token := parser.peek()
+	switch token.kind {
+	case stringToken:
+		parser.next()
+		return &StringLiteral{Value: token.text}, nil
+
+	case numberToken:
+		parser.next()
+		return &NumberLiteral{Value: token.text}, nil
+
+	case dateToken:
+		parser.next()
+		return &DateLiteral{Value: token.text}, nil
+
+	case identToken:
+		name := strings.ToUpper(token.text)
+		switch {
+		case name == "TRUE" || name == "FALSE":
+			parser.next()
+			return &BooleanLiteral{Value: name == "TRUE"}, nil
+
+		case name == "NULL":
+			parser.next()
+			return &NullLiteral{}, nil
+
+		case relativeDates[name]:
+			parser.next()
+			return &RelativeDateLiteral{Name: name}, nil
+
+		case relativeDatesN.MatchString(name):
+			parser.next()
+			if err := parser.expectSymbol(":"); err != nil {
+				return nil, err
+			}
+			n, err := parser.integer()
+			if err != nil {
+				return nil, err
+			}
+			return &RelativeDateLiteral{Name: name, N: n}, nil
+		}
+	}
+
+	return nil, parser.unexpected(token, "a value")
+}
+
+func (parser *parser) from() (*From, error) {
+	sObject, err := parser.name("an sobject")
+	if err != nil {
+		return nil, err
+	}
+	from := &From{SObject: sObject, Alias: parser.alias()}
+
+	if _, ok := parser.keyword("USING"); ok {
+		if _, err := parser.expectKeyword("SCOPE"); err != nil {
+			return nil, err
+		}
+		if from.Scope, err = parser.name("a filter scope"); err != nil {
+			return nil, err
+		}
+	}
+
+	return from, nil
+}
+
+// condition parses conditions joined by AND or OR. Joining with both requires parentheses.
+func (parser *parser) condition() (Condition, error) {
+	condition, err := parser.unaryCondition()
+	if err != nil {
+		return nil, err
+	}
+
+	var logical *Logical
+	for {
+		token := parser.peek()
+		operator, ok := parser.keyword("AND", "OR")
+		if !ok {
+			break
+		}
+		if logical == nil {
+			logical = &Logical{Operator: operator, Conditions: []Condition{condition}}
+		} else if operator != logical.Operator {
+			return nil, &SyntaxError{Pos: token.pos, Message: fmt.Sprintf("unexpected %v, conditions joined by both AND and OR must be parenthesized", token)}
+		}
+
+		next, err := parser.unaryCondition()
+		if err != nil {
+			return nil, err
+		}
+		logical.Conditions = append(logical.Conditions, next)
+	}
+	if logical != nil {
+		return logical, nil
+	}
+
+	return condition, nil
+}
+
+func (parser *parser) unaryCondition() (Condition, error) {
+	if _, ok := parser.keyword("NOT"); ok {
+		condition, err := parser.unaryCondition()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Condition: condition}, nil
+	}
+
+	if parser.symbol("(") {
+		condition, err := parser.condition()
+		if err != nil {
+			return nil, err
+		}
+		if err := parser.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		return condition, nil
+	}
+
+	return parser.comparison()
+}
+
+func (parser *parser) comparison() (*Comparison, error) {
+	left, err := parser.field()
+	if err != nil {
+		return nil, err
+	}
+	comparison := &Comparison{Left: left}
+
+	token := parser.next()
+	switch {
+	case token.kind == symbolToken && comparisonOperators[token.text]:
+		comparison.Operator = token.text
+		if comparison.Operator == "<>" {
+			comparison.Operator = "!="
+		}
+		comparison.Right, err = parser.literal()
+		return comparison, err
+
+	case isKeyword(token, "LIKE"):
+		comparison.Operator = "LIKE"
+		comparison.Right, err = parser.literal()
+		return comparison, err
+
+	case isKeyword(token, "NOT"):
+		if _, err := parser.expectKeyword("IN"); err != nil {
+			return nil, err
+		}
+		comparison.Operator = "NOT IN"
+
+	case isKeyword(token, "IN"), isKeyword(token, "INCLUDES"), isKeyword(token, "EXCLUDES"):
+		comparison.Operator = strings.ToUpper(token.text)
+
+	default:
+		return nil, parser.unexpected(token, "an operator")
+	}
+
+	// The values of IN, NOT IN, INCLUDES and EXCLUDES.
+	if (comparison.Operator == "IN" || comparison.Operator == "NOT IN") && isKeyword(parser.peekAt(1), "SELECT") {
+		comparison.Right, err = parser.subquery()
+		return comparison, err
+	}
+
+	if err := parser.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	list := &List{}
+	for {
+		value, err := parser.literal()
+		if err != nil {
+			return nil, err
+		}
+		list.Values = append(list.Values, value)
+		if !parser.symbol(",") {
+			break
+		}
+	}
+	if err := parser.expectSymbol(")"); err != nil {
+		return nil, err
+	}
+	comparison.Right = list
+
+	return comparison, nil
+}
+
+func (parser *parser) with() (*With, error) {
+	if !isKeyword(parser.peek(), "DATA") || !isKeyword(parser.peekAt(1), "CATEGORY") {
+		name, err := parser.name("a filter")
+		if err != nil {
+			return nil, err
+		}
+		return &With{Name: strings.ToUpper(name)}, nil
+	}
+	parser.next()
+	parser.next()
+
+	with := &With{Name: "DATA CATEGORY"}
+	for {
+		group, err := parser.name("a data category group")
+		if err != nil {
+			return nil, err
+		}
+		selector, err := parser.expectKeyword("AT", "ABOVE", "BELOW", "ABOVE_OR_BELOW")
+		if err != nil {
+			return nil, err
+		}
+		filter := &DataCategoryFilter{Group: group, Selector: selector}
+
+		if parser.symbol("(") {
+			if filter.Categories, err = parser.names("a data category"); err != nil {
+				return nil, err
+			}
+			if err := parser.expectSymbol(")"); err != nil {
+				return nil, err
+			}
+		} else {
+			category, err := parser.name("a data category")
+			if err != nil {
+				return nil, err
+			}
+			filter.Categories = []string{category}
+		}
+		with.DataCategories = append(with.DataCategories, filter)
+
+		if _, ok := parser.keyword("AND"); !ok {
+			return with, nil
+		}
+	}
+}
+
+// names parses a comma separated list of names.
+func (parser *parser) names(expected string) ([]string, error) {
+	var names []string
+	for {
+		name, err := parser.name(expected)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if !parser.symbol(",") {
+			return names, nil
+		}
+	}
+}
+
+func (parser *parser) groupBy() (*GroupBy, error) {
+	if _, err := parser.expectKeyword("BY"); err != nil {
+		return nil, err
+	}
+
+	groupBy := &GroupBy{}
+	if token := parser.peek(); (isKeyword(token, "ROLLUP") || isKeyword(token, "CUBE")) && isFunction(parser.peekAt(1)) {
+		groupBy.Kind = strings.ToUpper(parser.next().text)
+		parser.next()
+	}
+
+	for {
+		expr, err := parser.field()
+		if err != nil {
+			return nil, err
+		}
+		groupBy.Exprs = append(groupBy.Exprs, expr)
+		if !parser.symbol(",") {
+			break
+		}
+	}
+
+	if len(groupBy.Kind) > 0 {
+		if err := parser.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	return groupBy, nil
+}
+
+func (parser *parser) orderBy() ([]*OrderItem, error) {
+	if _, err := parser.expectKeyword("BY"); err != nil {
+		return nil, err
+	}
+
+	var items []*OrderItem
+	for {
+		expr, err := parser.field()
+		if err != nil {
+			return nil, err
+		}
+		item := &OrderItem{Expr: expr}
+
+		if direction, ok := parser.keyword("ASC", "DESC"); ok {
+			item.Descending = direction == "DESC"
+		}
+		if _, ok := parser.keyword("NULLS"); ok {
+			if item.Nulls, err = parser.expectKeyword("FIRST", "LAST"); err != nil {
+				return nil, err
+			}
+		}
+		items = append(items, item)
+
+		if !parser.symbol(",") {
+			return items, nil
+		}
+	}
+}
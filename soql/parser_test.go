@@ -0,0 +1,158 @@
+package soql
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		soql      string
+		canonical string
+	}{
+		{"select id,name from account", "SELECT id, name FROM account"},
+		{"SELECT Id, Account.Owner.Name FROM Contact WHERE Account.Name = 'Acme'", ""},
+		{"SELECT Name, (SELECT LastName FROM Contacts WHERE Email != null ORDER BY LastName) FROM Account", ""},
+		{"SELECT Name, (SELECT Name, (SELECT Id FROM Cases) FROM Contacts) FROM Account", ""},
+		{"SELECT TYPEOF What WHEN Account THEN Phone, NumberOfEmployees WHEN Opportunity THEN Amount, CloseDate ELSE Name, Email END FROM Event", ""},
+		{"SELECT Id FROM Account a USING SCOPE Mine", ""},
+		{"SELECT Id FROM Account WHERE Name LIKE 'Ac%' AND (Type = 'Customer' OR Type = 'Partner')", ""},
+		{"SELECT Id FROM Account WHERE NOT Name = 'Acme' AND NOT (Type = 'Customer' OR Type = null)", ""},
+		{"SELECT Id FROM Account WHERE ((Name = 'A' OR Name = 'B') AND Type = 'Customer')", "SELECT Id FROM Account WHERE (Name = 'A' OR Name = 'B') AND Type = 'Customer'"},
+		{"SELECT Id FROM Account WHERE Type <> 'Customer'", "SELECT Id FROM Account WHERE Type != 'Customer'"},
+		{"SELECT Id FROM Account WHERE Type IN ('Customer', 'Partner') AND Industry NOT IN ('Banking')", ""},
+		{"SELECT Id FROM Account WHERE Id IN (SELECT AccountId FROM Contact WHERE LastName LIKE 'S%')", ""},
+		{"SELECT Id FROM Account WHERE Id NOT IN (SELECT AccountId FROM Opportunity WHERE IsClosed = false)", ""},
+		{"SELECT Id FROM Contact WHERE Languages__c INCLUDES ('English;French', 'German') AND Tags__c EXCLUDES ('Spam')", ""},
+		{"SELECT Id FROM Opportunity WHERE Amount > 1000.50 AND Probability >= -1 AND CloseDate <= 2024-12-31", ""},
+		{"SELECT Id FROM Opportunity WHERE CreatedDate > 2024-01-01T00:00:00Z AND LastModifiedDate < 2024-01-01T00:00:00.000+0000", ""},
+		{"SELECT Id FROM Opportunity WHERE CloseDate = next_n_days:30 OR CreatedDate = today", "SELECT Id FROM Opportunity WHERE CloseDate = NEXT_N_DAYS:30 OR CreatedDate = TODAY"},
+		{"SELECT Id FROM Opportunity WHERE CALENDAR_YEAR(CloseDate) = 2024", ""},
+		{"SELECT Id FROM Account WHERE Name = 'O\\'Brien\\\\ \\n' AND Name LIKE '100\\%'", ""},
+		{"SELECT Id FROM Account WHERE Name LIKE 'a\\\\%' OR Name LIKE 'a\\%'", ""},
+		{"SELECT Id FROM Account WHERE Name LIKE 'a\\\\_b' OR Name LIKE 'a\\_b'", ""},
+		{"SELECT Id FROM Account WITH SECURITY_ENFORCED", ""},
+		{"SELECT Id FROM Account with user_mode", "SELECT Id FROM Account WITH USER_MODE"},
+		{"SELECT Title FROM KnowledgeArticleVersion WITH DATA CATEGORY Geography__c AT (usa__c, uk__c) AND Product__c ABOVE_OR_BELOW mobile__c", ""},
+		{"SELECT Industry, COUNT(Id) total, MAX(AnnualRevenue) FROM Account GROUP BY Industry HAVING COUNT(Id) > 1", ""},
+		{"SELECT COUNT() FROM Account", ""},
+		{"SELECT LeadSource, Rating, GROUPING(LeadSource) grpLS, COUNT(Name) cnt FROM Lead GROUP BY ROLLUP(LeadSource, Rating)", ""},
+		{"SELECT Type, COUNT(Id) FROM Account GROUP BY CUBE(Type)", ""},
+		{"SELECT toLabel(Status), FORMAT(Amount) amt, convertCurrency(Amount) FROM Opportunity", ""},
+		{"SELECT Name FROM Account WHERE DISTANCE(Location__c, GEOLOCATION(37.775, -122.418), 'mi') < 20", ""},
+		{"SELECT Name FROM Account ORDER BY Name ASC NULLS LAST, CreatedDate DESC, Industry NULLS FIRST", "SELECT Name FROM Account ORDER BY Name NULLS LAST, CreatedDate DESC, Industry NULLS FIRST"},
+		{"SELECT Name FROM Account ORDER BY Name LIMIT 10 OFFSET 20", ""},
+		{"SELECT Id FROM Account LIMIT 1 FOR UPDATE", ""},
+		{"SELECT Id FROM Account FOR VIEW", ""},
+		{"SELECT Id FROM FAQ__kav UPDATE VIEWSTAT", ""},
+	}
+
+	for _, test := range tests {
+		query, err := Parse(test.soql)
+		if err != nil {
+			t.Errorf("Unable to parse %q: %v", test.soql, err)
+			continue
+		}
+
+		canonical := test.canonical
+		if len(canonical) == 0 {
+			canonical = test.soql
+		}
+		if query.String() != canonical {
+			t.Errorf("Unexpected rendering of %q:\n%v\nexpected:\n%v", test.soql, query, canonical)
+			continue
+		}
+
+		// Canonical queries parse to themselves.
+		reparsed, err := Parse(query.String())
+		if err != nil || reparsed.String() != canonical {
+			t.Errorf("Unable to reparse %q: %v", canonical, err)
+		}
+	}
+}
+
+func TestParseTree(t *testing.T) {
+	query, err := Parse("SELECT Name, COUNT(Id) cnt FROM Account a WHERE Type IN ('Customer') AND Name LIKE 'A%' " +
+		"GROUP BY Name HAVING COUNT(Id) > 1 ORDER BY Name DESC NULLS LAST LIMIT 5")
+	if err != nil {
+		t.Fatalf("Unable to parse: %v", err)
+	}
+
+	if field, ok := query.Select[0].(*Field); !ok || field.Name != "Name" {
+		t.Fatalf("Unexpected select item: %#v", query.Select[0])
+	}
+	if call, ok := query.Select[1].(*FunctionCall); !ok || call.Name != "COUNT" || call.Alias != "cnt" ||
+		call.Args[0].(*Field).Name != "Id" {
+		t.Fatalf("Unexpected select item: %#v", query.Select[1])
+	}
+	if query.From.SObject != "Account" || query.From.Alias != "a" {
+		t.Fatalf("Unexpected from: %#v", query.From)
+	}
+
+	where, ok := query.Where.(*Logical)
+	if !ok || where.Operator != "AND" || len(where.Conditions) != 2 {
+		t.Fatalf("Unexpected where: %#v", query.Where)
+	}
+	in := where.Conditions[0].(*Comparison)
+	if in.Operator != "IN" || in.Right.(*List).Values[0].(*StringLiteral).Value != "Customer" {
+		t.Fatalf("Unexpected comparison: %#v", in)
+	}
+	if like := where.Conditions[1].(*Comparison); like.Operator != "LIKE" || like.Right.(*StringLiteral).Value != "A%" {
+		t.Fatalf("Unexpected comparison: %#v", like)
+	}
+
+	if query.GroupBy.Kind != "" || query.GroupBy.Exprs[0].(*Field).Name != "Name" {
+		t.Fatalf("Unexpected group by: %#v", query.GroupBy)
+	}
+	if having := query.Having.(*Comparison); having.Left.(*FunctionCall).Name != "COUNT" || having.Right.(*NumberLiteral).Value != "1" {
+		t.Fatalf("Unexpected having: %#v", query.Having)
+	}
+	if order := query.OrderBy[0]; !order.Descending || order.Nulls != "LAST" {
+		t.Fatalf("Unexpected order by: %#v", order)
+	}
+	if *query.Limit != 5 || query.Offset != nil {
+		t.Fatalf("Unexpected limit %v and offset %v", *query.Limit, query.Offset)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		soql    string
+		line    int
+		column  int
+		message string
+	}{
+		{"", 1, 1, "unexpected end of query, expected SELECT"},
+		{"SELECT FROM Account", 1, 8, "unexpected 'FROM', expected a field"},
+		{"SELECT Id, FROM Account", 1, 12, "unexpected 'FROM', expected a field"},
+		{"SELECT Id Account", 1, 18, "unexpected end of query, expected FROM"},
+		{"SELECT Id\nFROM Account\nWHERE Name = 'Acme' AND Type = 'Customer' OR Type = null", 3, 43, "unexpected 'OR', conditions joined by both AND and OR must be parenthesized"},
+		{"SELECT Id FROM Account WHERE Name = ", 1, 37, "unexpected end of query, expected a value"},
+		{"SELECT Id FROM Account WHERE Name = Type", 1, 37, "unexpected 'Type', expected a value"},
+		{"SELECT Id FROM Account WHERE Name 'Acme'", 1, 35, "unexpected string 'Acme', expected an operator"},
+		{"SELECT Id FROM Account WHERE Name IN 'Acme'", 1, 38, "unexpected string 'Acme', expected '('"},
+		{"SELECT Id FROM Account WHERE (Name = 'Acme'", 1, 44, "unexpected end of query, expected ')'"},
+		{"SELECT Id FROM Account WHERE Name = 'Acme", 1, 37, "unterminated string"},
+		{"SELECT Id FROM Account WHERE Name = 'Ac\\me'", 1, 40, "invalid escape sequence '\\m'"},
+		{"SELECT Id FROM Account WHERE CloseDate = LAST_N_DAYS", 1, 53, "unexpected end of query, expected ':'"},
+		{"SELECT Id FROM Account LIMIT -1", 1, 30, "unexpected '-1', expected a non-negative integer"},
+		{"SELECT Id FROM Account LIMIT 10 ORDER BY Name", 1, 33, "unexpected 'ORDER', expected end of query"},
+		{"SELECT Id FROM Account FOR DELETE", 1, 28, "unexpected 'DELETE', expected VIEW or REFERENCE or UPDATE"},
+		{"SELECT TYPEOF What ELSE Name END FROM Event", 1, 20, "unexpected 'ELSE', expected WHEN"},
+		{"SELECT Id FROM Account WHERE Name = 'é' AND Amount > 1.2.3", 1, 54, "invalid number '1.2.3'"},
+		{"SELECT Id FROM Account WHERE Name = 'Acme' ;", 1, 44, "unexpected character ';'"},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test.soql)
+		syntaxError := &SyntaxError{}
+		if !errors.As(err, &syntaxError) {
+			t.Errorf("Expected a syntax error parsing %q, got %v", test.soql, err)
+			continue
+		}
+		if syntaxError.Pos.Line != test.line || syntaxError.Pos.Column != test.column || syntaxError.Message != test.message {
+			t.Errorf("Unexpected error parsing %q: %v (%+v), expected line %v, column %v: %v",
+				test.soql, err, syntaxError.Pos, test.line, test.column, test.message)
+		}
+	}
+}